@@ -2,14 +2,36 @@ package main
 
 import (
 	"app/internal/application"
-	"fmt"
+	"app/platform/auth"
+	"app/platform/logging"
+	"app/platform/tracing"
+	"log/slog"
+	"os"
 
 	"github.com/go-sql-driver/mysql"
 )
 
 func main() {
+	// logger
+	lg := logging.New(os.Stdout, slog.LevelInfo)
+
 	// env
-	// ...
+	// - API_KEYS: comma separated key:role:subject entries, roles are viewer, clerk and admin
+	// - JWT_SECRET: HMAC key of the HS256 bearer tokens
+	keys, err := auth.ParseAPIKeys(os.Getenv("API_KEYS"))
+	if err != nil {
+		lg.Error("error parsing API_KEYS", "error", err)
+		return
+	}
+
+	// tracer
+	// - spans are appended to traces.jsonl, one JSON line per span
+	exp, err := tracing.NewFileExporter("traces.jsonl")
+	if err != nil {
+		lg.Error("error opening the trace file", "error", err)
+		return
+	}
+	defer exp.Close()
 
 	// app
 	// - Default Config
@@ -21,7 +43,13 @@ func main() {
 			Addr:   "localhost:3306",
 			DBName: "fantasy_products",
 		},
-		Addr: "127.0.0.1:8080",
+		Addr:          "127.0.0.1:8080",
+		Logger:        lg,
+		TraceExporter: exp,
+		Auth: auth.Config{
+			APIKeys: keys,
+			Secret:  []byte(os.Getenv("JWT_SECRET")),
+		},
 	}
 	app := application.NewApplicationDefault(cfg)
 
@@ -38,16 +66,45 @@ func main() {
 	// }
 	// app := application.NewApplicationMigrate(cfg)
 
+	// - Check Config
+	// cfg := &application.ConfigApplicationCheck{
+	// 	Db: &mysql.Config{
+	// 		User:   "root",
+	// 		Passwd: "",
+	// 		Net:    "tcp",
+	// 		Addr:   "localhost:3306",
+	// 		DBName: "fantasy_products",
+	// 	},
+	// 	SampleSize: 10,
+	// 	OutputDir:  "docs/db/check",
+	// }
+	// app := application.NewApplicationCheck(cfg)
+
+	// - Generate Config
+	// cfg := &application.ConfigApplicationGenerate{
+	// 	Generator: &generator.ConfigDatasetGenerator{
+	// 		Seed:      42,
+	// 		Customers: 10000,
+	// 		Products:  2000,
+	// 		Invoices:  100000,
+	// 		Sales:     1000000,
+	// 	},
+	// 	OutputDir: "docs/db/generated",
+	// }
+	// app := application.NewApplicationGenerate(cfg)
+
+	// - tear down
+	defer app.TearDown()
 	// - set up
-	err := app.SetUp()
+	err = app.SetUp()
 	if err != nil {
-		fmt.Println(err)
+		lg.Error("error setting up the application", "error", err)
 		return
 	}
 	// - run
 	err = app.Run()
 	if err != nil {
-		fmt.Println(err)
+		lg.Error("error running the application", "error", err)
 		return
 	}
 }
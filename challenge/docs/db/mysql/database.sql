@@ -45,4 +45,30 @@ CREATE TABLE `sales` (
     KEY `idx_sales_product_id` (`product_id`),
     CONSTRAINT `fk_sales_invoice_id` FOREIGN KEY (`invoice_id`) REFERENCES `invoices` (`id`) ON DELETE CASCADE ON UPDATE CASCADE,
     CONSTRAINT `fk_sales_product_id` FOREIGN KEY (`product_id`) REFERENCES `products` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
-);
\ No newline at end of file
+);
+
+-- Table structure for table `schema_version`
+-- The application checks on readiness that the version is at least the one it needs
+CREATE TABLE `schema_version` (
+    `version` int NOT NULL,
+    `applied_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`version`)
+);
+
+-- Table structure for table `idempotency_keys`
+-- The creates store their response by client and Idempotency-Key header, replayed until the key expires
+-- `status` is NULL while the request that reserved the key is in progress
+CREATE TABLE `idempotency_keys` (
+    `client` varchar(255) NOT NULL,
+    `key` varchar(255) NOT NULL,
+    `request_hash` char(64) NOT NULL,
+    `status` int DEFAULT NULL,
+    `content_type` varchar(255) DEFAULT NULL,
+    `body` mediumblob,
+    `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `expires_at` datetime NOT NULL,
+    PRIMARY KEY (`client`, `key`),
+    KEY `idx_idempotency_keys_expires_at` (`expires_at`)
+);
+
+INSERT INTO `schema_version` (`version`) VALUES (1), (2);
@@ -0,0 +1,19 @@
+-- Upgrades a database created with version 1 of database.sql to version 2
+
+USE `fantasy_products`;
+
+-- Table structure for table `idempotency_keys`, see database.sql
+CREATE TABLE IF NOT EXISTS `idempotency_keys` (
+    `client` varchar(255) NOT NULL,
+    `key` varchar(255) NOT NULL,
+    `request_hash` char(64) NOT NULL,
+    `status` int DEFAULT NULL,
+    `content_type` varchar(255) DEFAULT NULL,
+    `body` mediumblob,
+    `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `expires_at` datetime NOT NULL,
+    PRIMARY KEY (`client`, `key`),
+    KEY `idx_idempotency_keys_expires_at` (`expires_at`)
+);
+
+INSERT IGNORE INTO `schema_version` (`version`) VALUES (2);
@@ -6,4 +6,6 @@ type Application interface {
 	Run() (err error)
 	// SetUp sets up the application.
 	SetUp() (err error)
-}
\ No newline at end of file
+	// TearDown tears down the application, releasing what SetUp acquired.
+	TearDown() (err error)
+}
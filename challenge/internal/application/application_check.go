@@ -0,0 +1,141 @@
+package application
+
+import (
+	"app/internal/loader"
+	"app/internal/repository"
+	"app/internal/service"
+	"app/platform/logging"
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// ConfigApplicationCheck is the configuration for NewApplicationCheck.
+type ConfigApplicationCheck struct {
+	// Db is the database configuration.
+	Db *mysql.Config
+	// SampleSize is the maximum number of ids reported per check.
+	SampleSize int
+	// OutputDir is the directory where the offending rows are written, in the docs/db/json layout.
+	// If empty, the offending rows are not written.
+	OutputDir string
+	// Logger is the logger of the application, JSON lines on stdout by default.
+	Logger *slog.Logger
+}
+
+// NewApplicationCheck creates a new ApplicationCheck.
+func NewApplicationCheck(config *ConfigApplicationCheck) *ApplicationCheck {
+	// default values
+	defaultCfg := &ConfigApplicationCheck{
+		Db:         nil,
+		SampleSize: 10,
+		OutputDir:  "",
+		Logger:     logging.New(os.Stdout, slog.LevelInfo),
+	}
+	if config != nil {
+		if config.Db != nil {
+			defaultCfg.Db = config.Db
+		}
+		if config.SampleSize > 0 {
+			defaultCfg.SampleSize = config.SampleSize
+		}
+		if config.OutputDir != "" {
+			defaultCfg.OutputDir = config.OutputDir
+		}
+		if config.Logger != nil {
+			defaultCfg.Logger = config.Logger
+		}
+	}
+
+	return &ApplicationCheck{
+		cfgDb:         defaultCfg.Db,
+		cfgSampleSize: defaultCfg.SampleSize,
+		cfgOutputDir:  defaultCfg.OutputDir,
+		lg:            defaultCfg.Logger,
+	}
+}
+
+// ApplicationCheck is an implementation of the Application interface.
+// It checks the referential integrity and data quality of the database and reports the offending rows.
+type ApplicationCheck struct {
+	// cfgDb is the database configuration.
+	cfgDb *mysql.Config
+	// cfgSampleSize is the maximum number of ids reported per check.
+	cfgSampleSize int
+	// cfgOutputDir is the directory where the offending rows are written.
+	cfgOutputDir string
+	// lg is the logger of the application.
+	lg *slog.Logger
+	// db is the database connection.
+	db *sql.DB
+}
+
+// SetUp sets up the application.
+func (a *ApplicationCheck) SetUp() (err error) {
+	// dependencies
+	// - db: init
+	a.db, err = sql.Open("mysql", a.cfgDb.FormatDSN())
+	if err != nil {
+		return
+	}
+	// - db: ping
+	err = a.db.Ping()
+	if err != nil {
+		return
+	}
+	return
+}
+
+// Run runs the application.
+func (a *ApplicationCheck) Run() (err error) {
+	// check
+	// - the check is a batch job, its queries are not bounded by a timeout
+	rpIntegrity := repository.NewIntegrityMySQL(a.db, 0, a.lg)
+	svIntegrity := service.NewIntegrityDefault(rpIntegrity, a.cfgSampleSize)
+	rp, err := svIntegrity.Check(context.Background())
+	if err != nil {
+		return
+	}
+
+	// report
+	for _, v := range rp.Issues {
+		fmt.Printf("%-30s %-10s %8d %v\n", v.Check, v.Entity, v.Count, v.SampleIds)
+	}
+
+	// write the offending rows
+	if a.cfgOutputDir == "" {
+		return
+	}
+	err = loader.NewCustomerLoaderJSON(nil, filepath.Join(a.cfgOutputDir, "customers.json")).Dump(rp.Customers)
+	if err != nil {
+		return
+	}
+	err = loader.NewInvoiceLoaderJSON(nil, filepath.Join(a.cfgOutputDir, "invoices.json")).Dump(rp.Invoices)
+	if err != nil {
+		return
+	}
+	err = loader.NewProductLoaderJSON(nil, filepath.Join(a.cfgOutputDir, "products.json")).Dump(rp.Products)
+	if err != nil {
+		return
+	}
+	err = loader.NewSaleLoaderJSON(nil, filepath.Join(a.cfgOutputDir, "sales.json")).Dump(rp.Sales)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+// TearDown tears down the application.
+func (a *ApplicationCheck) TearDown() (err error) {
+	// close the database connection
+	if a.db != nil {
+		err = a.db.Close()
+	}
+	return
+}
@@ -2,30 +2,100 @@ package application
 
 import (
 	"app/internal/handler"
+	"app/internal/loader"
 	"app/internal/repository"
 	"app/internal/service"
+	"app/platform/auth"
+	"app/platform/health"
+	"app/platform/idempotency"
+	"app/platform/logging"
+	"app/platform/metrics"
+	"app/platform/ratelimit"
+	"app/platform/sqlstats"
+	"app/platform/tracing"
+	"app/platform/web/response"
+	"context"
 	"database/sql"
+	"log/slog"
 	"net/http"
+	"os"
+	"time"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-sql-driver/mysql"
 )
 
+// schemaVersion is the version of the database schema the application needs, see docs/db/mysql.
+const schemaVersion = 2
+
 // ConfigApplicationDefault is the configuration for NewApplicationDefault.
 type ConfigApplicationDefault struct {
 	// Db is the database configuration.
 	Db *mysql.Config
 	// Addr is the server address.
 	Addr string
+	// QueryTimeout bounds each database query, on top of the request being cancelled. Zero means no timeout.
+	QueryTimeout time.Duration
+	// ReadTimeout is the maximum duration for reading a request, including its body.
+	ReadTimeout time.Duration
+	// WriteTimeout is the maximum duration for writing a response, streamed responses are not bounded by it.
+	WriteTimeout time.Duration
+	// IdleTimeout is the maximum duration a keep-alive connection waits for the next request.
+	IdleTimeout time.Duration
+	// MaxHeaderBytes is the maximum size of the request headers.
+	MaxHeaderBytes int
+	// ShutdownTimeout is the maximum duration to wait for in-flight requests on shutdown.
+	ShutdownTimeout time.Duration
+	// StartupTimeout is the maximum duration to wait for the database to be reachable on set up.
+	StartupTimeout time.Duration
+	// ReadinessTimeout is the deadline of the readiness checks.
+	ReadinessTimeout time.Duration
+	// Logger is the logger of the application, JSON lines on stdout by default.
+	Logger *slog.Logger
+	// SlowQueryThreshold is the duration above which a statement is logged with its EXPLAIN plan.
+	SlowQueryThreshold time.Duration
+	// TraceExporter receives the spans of the requests, e.g. a tracing.JSONExporter.
+	// If nil, traces are propagated but not exported.
+	TraceExporter tracing.Exporter
+	// Auth configures the API keys and the secret of the bearer tokens.
+	// Every route but the health and metrics ones requires a role, so the zero value rejects them all.
+	Auth auth.Config
+	// RateLimit is the rate allowed to each client on most routes.
+	RateLimit ratelimit.Limit
+	// ReportRateLimit is the tighter rate allowed to each client on the report routes, which aggregate whole tables.
+	ReportRateLimit ratelimit.Limit
+	// BulkRateLimit is the tighter rate allowed to each client on the bulk create routes, which write many rows per request.
+	BulkRateLimit ratelimit.Limit
+	// ReportCacheTTL is the duration a report result is cached for, unless a write through the services purges it first.
+	ReportCacheTTL time.Duration
+	// ReportCacheSize is the maximum number of results cached per report.
+	ReportCacheSize int
+	// IdempotencyTTL is the duration the responses of the Idempotency-Key headers are replayed for.
+	IdempotencyTTL time.Duration
 }
 
 // NewApplicationDefault creates a new ApplicationDefault.
 func NewApplicationDefault(config *ConfigApplicationDefault) *ApplicationDefault {
 	// default values
 	defaultCfg := &ConfigApplicationDefault{
-		Db:   nil,
-		Addr: ":8080",
+		Db:                 nil,
+		Addr:               ":8080",
+		QueryTimeout:       5 * time.Second,
+		ReadTimeout:        10 * time.Second,
+		WriteTimeout:       30 * time.Second,
+		IdleTimeout:        60 * time.Second,
+		MaxHeaderBytes:     1 << 20,
+		ShutdownTimeout:    15 * time.Second,
+		StartupTimeout:     30 * time.Second,
+		ReadinessTimeout:   2 * time.Second,
+		Logger:             logging.New(os.Stdout, slog.LevelInfo),
+		SlowQueryThreshold: 500 * time.Millisecond,
+		RateLimit:          ratelimit.Limit{Rate: 20, Burst: 40},
+		ReportRateLimit:    ratelimit.Limit{Rate: 0.5, Burst: 5},
+		BulkRateLimit:      ratelimit.Limit{Rate: 1, Burst: 5},
+		ReportCacheTTL:     time.Minute,
+		ReportCacheSize:    100,
+		IdempotencyTTL:     24 * time.Hour,
 	}
 	if config != nil {
 		if config.Db != nil {
@@ -34,11 +104,81 @@ func NewApplicationDefault(config *ConfigApplicationDefault) *ApplicationDefault
 		if config.Addr != "" {
 			defaultCfg.Addr = config.Addr
 		}
+		if config.QueryTimeout != 0 {
+			defaultCfg.QueryTimeout = config.QueryTimeout
+		}
+		if config.ReadTimeout != 0 {
+			defaultCfg.ReadTimeout = config.ReadTimeout
+		}
+		if config.WriteTimeout != 0 {
+			defaultCfg.WriteTimeout = config.WriteTimeout
+		}
+		if config.IdleTimeout != 0 {
+			defaultCfg.IdleTimeout = config.IdleTimeout
+		}
+		if config.MaxHeaderBytes != 0 {
+			defaultCfg.MaxHeaderBytes = config.MaxHeaderBytes
+		}
+		if config.ShutdownTimeout != 0 {
+			defaultCfg.ShutdownTimeout = config.ShutdownTimeout
+		}
+		if config.StartupTimeout != 0 {
+			defaultCfg.StartupTimeout = config.StartupTimeout
+		}
+		if config.ReadinessTimeout != 0 {
+			defaultCfg.ReadinessTimeout = config.ReadinessTimeout
+		}
+		if config.Logger != nil {
+			defaultCfg.Logger = config.Logger
+		}
+		if config.SlowQueryThreshold != 0 {
+			defaultCfg.SlowQueryThreshold = config.SlowQueryThreshold
+		}
+		if config.TraceExporter != nil {
+			defaultCfg.TraceExporter = config.TraceExporter
+		}
+		defaultCfg.Auth = config.Auth
+		if config.RateLimit != (ratelimit.Limit{}) {
+			defaultCfg.RateLimit = config.RateLimit
+		}
+		if config.ReportRateLimit != (ratelimit.Limit{}) {
+			defaultCfg.ReportRateLimit = config.ReportRateLimit
+		}
+		if config.BulkRateLimit != (ratelimit.Limit{}) {
+			defaultCfg.BulkRateLimit = config.BulkRateLimit
+		}
+		if config.ReportCacheTTL != 0 {
+			defaultCfg.ReportCacheTTL = config.ReportCacheTTL
+		}
+		if config.ReportCacheSize != 0 {
+			defaultCfg.ReportCacheSize = config.ReportCacheSize
+		}
+		if config.IdempotencyTTL != 0 {
+			defaultCfg.IdempotencyTTL = config.IdempotencyTTL
+		}
 	}
 
 	return &ApplicationDefault{
-		cfgDb:   defaultCfg.Db,
-		cfgAddr: defaultCfg.Addr,
+		cfgDb:                 defaultCfg.Db,
+		cfgAddr:               defaultCfg.Addr,
+		cfgQueryTimeout:       defaultCfg.QueryTimeout,
+		cfgReadTimeout:        defaultCfg.ReadTimeout,
+		cfgWriteTimeout:       defaultCfg.WriteTimeout,
+		cfgIdleTimeout:        defaultCfg.IdleTimeout,
+		cfgMaxHeaderBytes:     defaultCfg.MaxHeaderBytes,
+		cfgShutdownTimeout:    defaultCfg.ShutdownTimeout,
+		cfgStartupTimeout:     defaultCfg.StartupTimeout,
+		cfgReadinessTimeout:   defaultCfg.ReadinessTimeout,
+		cfgSlowQueryThreshold: defaultCfg.SlowQueryThreshold,
+		cfgRateLimit:          defaultCfg.RateLimit,
+		cfgReportRateLimit:    defaultCfg.ReportRateLimit,
+		cfgBulkRateLimit:      defaultCfg.BulkRateLimit,
+		cfgReportCacheTTL:     defaultCfg.ReportCacheTTL,
+		cfgReportCacheSize:    defaultCfg.ReportCacheSize,
+		cfgIdempotencyTTL:     defaultCfg.IdempotencyTTL,
+		lg:                    defaultCfg.Logger,
+		tracer:                tracing.NewTracer(defaultCfg.TraceExporter),
+		au:                    auth.NewAuthenticator(defaultCfg.Auth),
 	}
 }
 
@@ -48,8 +188,46 @@ type ApplicationDefault struct {
 	cfgDb *mysql.Config
 	// cfgAddr is the server address.
 	cfgAddr string
+	// cfgQueryTimeout bounds each database query.
+	cfgQueryTimeout time.Duration
+	// cfgReadTimeout is the maximum duration for reading a request.
+	cfgReadTimeout time.Duration
+	// cfgWriteTimeout is the maximum duration for writing a response.
+	cfgWriteTimeout time.Duration
+	// cfgIdleTimeout is the maximum duration a keep-alive connection waits for the next request.
+	cfgIdleTimeout time.Duration
+	// cfgMaxHeaderBytes is the maximum size of the request headers.
+	cfgMaxHeaderBytes int
+	// cfgShutdownTimeout is the maximum duration to wait for in-flight requests on shutdown.
+	cfgShutdownTimeout time.Duration
+	// cfgStartupTimeout is the maximum duration to wait for the database on set up.
+	cfgStartupTimeout time.Duration
+	// cfgReadinessTimeout is the deadline of the readiness checks.
+	cfgReadinessTimeout time.Duration
+	// cfgSlowQueryThreshold is the duration above which a statement is logged with its EXPLAIN plan.
+	cfgSlowQueryThreshold time.Duration
+	// cfgRateLimit is the rate allowed to each client on most routes.
+	cfgRateLimit ratelimit.Limit
+	// cfgReportRateLimit is the rate allowed to each client on the report routes.
+	cfgReportRateLimit ratelimit.Limit
+	// cfgBulkRateLimit is the rate allowed to each client on the bulk create routes.
+	cfgBulkRateLimit ratelimit.Limit
+	// cfgReportCacheTTL is the duration a report result is cached for.
+	cfgReportCacheTTL time.Duration
+	// cfgReportCacheSize is the maximum number of results cached per report.
+	cfgReportCacheSize int
+	// cfgIdempotencyTTL is the duration the responses of the Idempotency-Key headers are replayed for.
+	cfgIdempotencyTTL time.Duration
+	// lg is the logger of the application.
+	lg *slog.Logger
+	// tracer starts the spans of the requests.
+	tracer *tracing.Tracer
+	// au authenticates the requests.
+	au *auth.Authenticator
 	// db is the database connection.
 	db *sql.DB
+	// stats records the statements run through db.
+	stats *sqlstats.Stats
 	// router is the chi router.
 	router *chi.Mux
 }
@@ -57,79 +235,177 @@ type ApplicationDefault struct {
 // SetUp sets up the application.
 func (a *ApplicationDefault) SetUp() (err error) {
 	// dependencies
-	// - db: init
-	a.db, err = sql.Open("mysql", a.cfgDb.FormatDSN())
+	// - db: init, tracing and recording each statement
+	cn, err := mysql.NewConnector(a.cfgDb)
 	if err != nil {
 		return
 	}
-	// - db: ping
-	err = a.db.Ping()
+	a.db, a.stats = sqlstats.Open(tracing.WrapConnector(cn), sqlstats.Config{
+		SlowThreshold: a.cfgSlowQueryThreshold,
+		Logger:        a.lg,
+	})
+	// - db: ping, retrying with backoff until the database is reachable
+	err = health.Retry(context.Background(), health.Ping(a.db), 100*time.Millisecond, a.cfgStartupTimeout)
 	if err != nil {
 		return
 	}
+
+	a.setUpRouter()
+	return
+}
+
+// setUpRouter builds the dependencies on top of the database and registers the routes.
+// It does not use the database, so that the routes can be checked without one.
+func (a *ApplicationDefault) setUpRouter() {
 	// - repository
-	rpCustomer := repository.NewCustomersMySQL(a.db)
-	rpProduct := repository.NewProductsMySQL(a.db)
-	rpInvoice := repository.NewInvoicesMySQL(a.db)
-	rpSale := repository.NewSalesMySQL(a.db)
-	// - service
-	svCustomer := service.NewCustomersDefault(rpCustomer)
+	rpCustomer := repository.NewCustomersMySQL(a.db, a.cfgQueryTimeout, a.lg)
+	rpProduct := repository.NewProductsMySQL(a.db, a.cfgQueryTimeout, a.lg)
+	rpInvoice := repository.NewInvoicesMySQL(a.db, a.cfgQueryTimeout, a.lg)
+	rpSale := repository.NewSalesMySQL(a.db, a.cfgQueryTimeout, a.lg)
+	rpIntegrity := repository.NewIntegrityMySQL(a.db, a.cfgQueryTimeout, a.lg)
+	// - service: reports are cached, and purged by the writes they depend on
+	rc := service.NewReportCache(a.cfgReportCacheSize, a.cfgReportCacheTTL)
+	svCustomer := service.NewCustomersCached(service.NewCustomersDefault(rpCustomer, rpInvoice), rc)
 	svProduct := service.NewProductsDefault(rpProduct)
-	svInvoice := service.NewInvoicesDefault(rpInvoice)
-	svSale := service.NewSalesDefault(rpSale)
+	svInvoice := service.NewInvoicesCached(service.NewInvoicesDefault(rpInvoice), rc)
+	svSale := service.NewSalesCached(service.NewSalesDefault(rpSale, rpProduct), rc)
+	svIntegrity := service.NewIntegrityDefault(rpIntegrity, 10)
 	// - handler
-	hdCustomer := handler.NewCustomersDefault(svCustomer)
-	hdProduct := handler.NewProductsDefault(svProduct)
-	hdInvoice := handler.NewInvoicesDefault(svInvoice)
-	hdSale := handler.NewSalesDefault(svSale)
+	hdCustomer := handler.NewCustomersDefault(svCustomer, a.lg)
+	hdProduct := handler.NewProductsDefault(svProduct, a.lg)
+	hdInvoice := handler.NewInvoicesDefault(svInvoice, a.lg)
+	hdSale := handler.NewSalesDefault(svSale, a.lg)
+	hdIntegrity := handler.NewIntegrityDefault(svIntegrity, a.lg)
+	hdQueryStats := handler.NewQueryStatsDefault(a.stats)
+	// - health
+	ck := health.NewChecker(a.cfgReadinessTimeout)
+	ck.Add("database", health.Ping(a.db))
+	ck.Add("schema", health.SchemaVersion(a.db, schemaVersion))
+	hdHealth := handler.NewHealthDefault(ck, a.lg)
+	// - metrics
+	mtHTTP := metrics.NewHTTP()
+	reg := metrics.NewRegistry()
+	reg.Register(mtHTTP.Collectors()...)
+	reg.Register(metrics.DBStats(a.db)...)
+	reg.Register(repository.QueryDuration, loader.Imported, loader.ImportErrors, service.CacheRequests)
+	// - auth: roles required by the routes, health and metrics stay public
+	viewer, clerk, admin := auth.Require(auth.RoleViewer), auth.Require(auth.RoleClerk), auth.Require(auth.RoleAdmin)
+	// - rate limits: a bucket per client, reports and table wide updates, and bulk creates, have their own tighter ones
+	limit := ratelimit.Middleware(ratelimit.NewLimiter(a.cfgRateLimit), ratelimit.ClientKey)
+	report := ratelimit.Middleware(ratelimit.NewLimiter(a.cfgReportRateLimit), ratelimit.ClientKey)
+	bulk := ratelimit.Middleware(ratelimit.NewLimiter(a.cfgBulkRateLimit), ratelimit.ClientKey)
+	// - idempotency: the creates replay the response of an Idempotency-Key of the same client, kept in the database
+	idempotent := idempotency.Middleware(idempotency.Config{
+		Store:  idempotency.NewMySQL(a.db),
+		TTL:    a.cfgIdempotencyTTL,
+		Client: ratelimit.ClientKey,
+		Logger: a.lg,
+	})
 
 	// routes
 	// - router
 	a.router = chi.NewRouter()
 	// - middlewares
-	a.router.Use(middleware.Logger)
-	a.router.Use(middleware.Recoverer)
+	a.router.Use(logging.RequestIDMiddleware)
+	a.router.Use(tracing.Middleware(a.tracer))
+	a.router.Use(mtHTTP.Middleware)
+	a.router.Use(logging.Middleware(a.lg))
+	a.router.Use(logging.Recoverer(a.lg))
+	a.router.Use(a.au.Middleware)
+	// - errors of the router, in the format of the ones of the handlers
+	a.router.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		response.Error(w, http.StatusNotFound, "route not found")
+	})
+	a.router.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		response.Error(w, http.StatusMethodNotAllowed, "method not allowed")
+	})
 	// - endpoints
+	// - GET /healthz
+	a.router.Get("/healthz", hdHealth.Live())
+	// - GET /readyz
+	a.router.Get("/readyz", hdHealth.Ready())
+	// - GET /metrics
+	a.router.Get("/metrics", reg.Handler())
 	a.router.Route("/customers", func(r chi.Router) {
 		// - GET /customers
-		r.Get("/", hdCustomer.GetAll())
+		r.With(viewer, limit).Get("/", hdCustomer.GetAll())
 		// - POST /customers
-		r.Post("/", hdCustomer.Create())
+		r.With(clerk, limit, idempotent).Post("/", hdCustomer.Create())
+		// - POST /customers/bulk
+		r.With(clerk, bulk, idempotent).Post("/bulk", hdCustomer.CreateBulk())
 		// - GET /customers/total/condition
-		r.Get("/total/condition", hdCustomer.GetTotalByCondition())
+		r.With(viewer, report).Get("/total/condition", hdCustomer.GetTotalByCondition())
 		// - GET /customers/top/active
-		r.Get("/top/active", hdCustomer.GetTopActive(5))
+		r.With(viewer, report).Get("/top/active", hdCustomer.GetTopActive(5))
+		// - GET /customers/{id}/invoices
+		r.With(viewer, limit).Get("/{id}/invoices", hdCustomer.GetInvoices())
+		// - GET /customers/{id}/statement
+		r.With(viewer, report).Get("/{id}/statement", hdCustomer.GetStatement())
 	})
 	a.router.Route("/products", func(r chi.Router) {
 		// - GET /products
-		r.Get("/", hdProduct.GetAll())
+		r.With(viewer, limit).Get("/", hdProduct.GetAll())
 		// - POST /products
-		r.Post("/", hdProduct.Create())
+		r.With(clerk, limit, idempotent).Post("/", hdProduct.Create())
+		// - POST /products/bulk
+		r.With(clerk, bulk, idempotent).Post("/bulk", hdProduct.CreateBulk())
+		// - GET /products/{id}/sales
+		r.With(viewer, limit).Get("/{id}/sales", hdSale.GetByProductId())
 	})
 	a.router.Route("/invoices", func(r chi.Router) {
 		// - GET /invoices
-		r.Get("/", hdInvoice.GetAll())
+		r.With(viewer, limit).Get("/", hdInvoice.GetAll())
+		// - GET /invoices/{id}
+		r.With(viewer, limit).Get("/{id}", hdInvoice.GetById())
 		// - POST /invoices
-		r.Post("/", hdInvoice.Create())
+		r.With(clerk, limit, idempotent).Post("/", hdInvoice.Create())
+		// - POST /invoices/bulk
+		r.With(clerk, bulk, idempotent).Post("/bulk", hdInvoice.CreateBulk())
 		// - POST /invoices/total
-		r.Put("/total", hdInvoice.UpdateTotal())
+		r.With(admin, report).Put("/total", hdInvoice.UpdateTotal())
 	})
 	a.router.Route("/sales", func(r chi.Router) {
 		// - GET /sales
-		r.Get("/", hdSale.GetAll())
+		r.With(viewer, limit).Get("/", hdSale.GetAll())
 		// - POST /sales
-		r.Post("/", hdSale.Create())
+		r.With(clerk, limit, idempotent).Post("/", hdSale.Create())
+		// - POST /sales/bulk
+		r.With(clerk, bulk, idempotent).Post("/bulk", hdSale.CreateBulk())
 		// - GET /sales/top
-		r.Get("/top", hdSale.GetTopProductSales(5))
+		r.With(viewer, report).Get("/top", hdSale.GetTopProductSales(5))
 	})
-
-	return
+	// - GET /check
+	a.router.With(admin, report).Get("/check", hdIntegrity.Check())
+	a.router.Route("/admin", func(r chi.Router) {
+		r.Use(admin, limit)
+		// - GET /admin/queries
+		r.Get("/queries", hdQueryStats.GetTop())
+		// - DELETE /admin/queries
+		r.Delete("/queries", hdQueryStats.Reset())
+	})
+	// - GET /openapi.json
+	a.router.Get("/openapi.json", openAPI().Handler(a.router))
 }
 
-// Run runs the application.
+// Run runs the application until it receives SIGINT or SIGTERM, then drains the in-flight requests.
 func (a *ApplicationDefault) Run() (err error) {
-	defer a.db.Close()
+	srv := &http.Server{
+		Addr:           a.cfgAddr,
+		Handler:        a.router,
+		ReadTimeout:    a.cfgReadTimeout,
+		WriteTimeout:   a.cfgWriteTimeout,
+		IdleTimeout:    a.cfgIdleTimeout,
+		MaxHeaderBytes: a.cfgMaxHeaderBytes,
+	}
+	err = serve(srv, a.cfgShutdownTimeout)
+	return
+}
 
-	err = http.ListenAndServe(a.cfgAddr, a.router)
+// TearDown tears down the application.
+func (a *ApplicationDefault) TearDown() (err error) {
+	// close the database connection
+	if a.db != nil {
+		err = a.db.Close()
+	}
 	return
 }
@@ -0,0 +1,97 @@
+package application
+
+import (
+	"app/internal/generator"
+	"app/internal/loader"
+	"os"
+	"path/filepath"
+)
+
+// ConfigApplicationGenerate is the configuration for NewApplicationGenerate.
+type ConfigApplicationGenerate struct {
+	// Generator is the dataset generator configuration.
+	Generator *generator.ConfigDatasetGenerator
+	// OutputDir is the directory where the dataset is written, in the docs/db/json layout. It defaults to docs/db/generated
+	// so that the bundled datasets of docs/db/json are not overwritten.
+	OutputDir string
+}
+
+// NewApplicationGenerate creates a new ApplicationGenerate.
+func NewApplicationGenerate(config *ConfigApplicationGenerate) *ApplicationGenerate {
+	// default values
+	defaultCfg := &ConfigApplicationGenerate{
+		Generator: nil,
+		OutputDir: "docs/db/generated",
+	}
+	if config != nil {
+		if config.Generator != nil {
+			defaultCfg.Generator = config.Generator
+		}
+		if config.OutputDir != "" {
+			defaultCfg.OutputDir = config.OutputDir
+		}
+	}
+
+	return &ApplicationGenerate{
+		cfgGenerator: defaultCfg.Generator,
+		cfgOutputDir: defaultCfg.OutputDir,
+	}
+}
+
+// ApplicationGenerate is an implementation of the Application interface.
+// It generates a synthetic dataset in the format read by the loaders of ApplicationMigrate.
+type ApplicationGenerate struct {
+	// cfgGenerator is the dataset generator configuration.
+	cfgGenerator *generator.ConfigDatasetGenerator
+	// cfgOutputDir is the directory where the dataset is written.
+	cfgOutputDir string
+	// gn is the dataset generator.
+	gn *generator.DatasetGenerator
+}
+
+// SetUp sets up the application.
+func (a *ApplicationGenerate) SetUp() (err error) {
+	// dependencies
+	// - output dir
+	err = os.MkdirAll(a.cfgOutputDir, 0755)
+	if err != nil {
+		return
+	}
+	// - generator
+	a.gn = generator.NewDatasetGenerator(a.cfgGenerator)
+	return
+}
+
+// Run runs the application.
+func (a *ApplicationGenerate) Run() (err error) {
+	// generate
+	d, err := a.gn.Generate()
+	if err != nil {
+		return
+	}
+
+	// write
+	err = loader.NewCustomerLoaderJSON(nil, filepath.Join(a.cfgOutputDir, "customers.json")).Dump(d.Customers)
+	if err != nil {
+		return
+	}
+	err = loader.NewProductLoaderJSON(nil, filepath.Join(a.cfgOutputDir, "products.json")).Dump(d.Products)
+	if err != nil {
+		return
+	}
+	err = loader.NewInvoiceLoaderJSON(nil, filepath.Join(a.cfgOutputDir, "invoices.json")).Dump(d.Invoices)
+	if err != nil {
+		return
+	}
+	err = loader.NewSaleLoaderJSON(nil, filepath.Join(a.cfgOutputDir, "sales.json")).Dump(d.Sales)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+// TearDown tears down the application.
+func (a *ApplicationGenerate) TearDown() (err error) {
+	return
+}
@@ -3,7 +3,12 @@ package application
 import (
 	"app/internal/loader"
 	"app/internal/repository"
+	"app/platform/logging"
+	"app/platform/metrics"
+	"context"
 	"database/sql"
+	"log/slog"
+	"os"
 
 	"github.com/go-sql-driver/mysql"
 )
@@ -14,14 +19,21 @@ type ConfigApplicationMigrate struct {
 	Db *mysql.Config
 	// Addr is the server address.
 	Addr string
+	// MetricsFile is the file where the metrics are written after the migration, in the
+	// Prometheus text exposition format, e.g. for the node exporter textfile collector.
+	// If empty, the metrics are not written.
+	MetricsFile string
+	// Logger is the logger of the application, JSON lines on stdout by default.
+	Logger *slog.Logger
 }
 
 // NewApplicationMigrate creates a new ApplicationMigrate.
 func NewApplicationMigrate(config *ConfigApplicationMigrate) *ApplicationMigrate {
 	// default values
 	defaultCfg := &ConfigApplicationMigrate{
-		Db:   nil,
-		Addr: ":8080",
+		Db:     nil,
+		Addr:   ":8080",
+		Logger: logging.New(os.Stdout, slog.LevelInfo),
 	}
 	if config != nil {
 		if config.Db != nil {
@@ -30,11 +42,19 @@ func NewApplicationMigrate(config *ConfigApplicationMigrate) *ApplicationMigrate
 		if config.Addr != "" {
 			defaultCfg.Addr = config.Addr
 		}
+		if config.MetricsFile != "" {
+			defaultCfg.MetricsFile = config.MetricsFile
+		}
+		if config.Logger != nil {
+			defaultCfg.Logger = config.Logger
+		}
 	}
 
 	return &ApplicationMigrate{
-		cfgDb:   defaultCfg.Db,
-		cfgAddr: defaultCfg.Addr,
+		cfgDb:          defaultCfg.Db,
+		cfgAddr:        defaultCfg.Addr,
+		cfgMetricsFile: defaultCfg.MetricsFile,
+		lg:             defaultCfg.Logger,
 	}
 }
 
@@ -44,6 +64,10 @@ type ApplicationMigrate struct {
 	cfgDb *mysql.Config
 	// cfgAddr is the server address.
 	cfgAddr string
+	// cfgMetricsFile is the file where the metrics are written after the migration.
+	cfgMetricsFile string
+	// lg is the logger of the application.
+	lg *slog.Logger
 	// db is the database connection.
 	db *sql.DB
 }
@@ -66,42 +90,86 @@ func (a *ApplicationMigrate) SetUp() (err error) {
 
 // Run runs the application.
 func (a *ApplicationMigrate) Run() (err error) {
+	// the migration is a batch job, its queries are not bounded by a timeout
+	ctx := context.Background()
+
 	// customer
-	customerRepository := repository.NewCustomersMySQL(a.db)
+	customerRepository := repository.NewCustomersMySQL(a.db, 0, a.lg)
 	customerLoader := loader.NewCustomerLoaderJSON(customerRepository, "docs/db/json/customers.json")
 
 	// product
-	productRepository := repository.NewProductsMySQL(a.db)
+	productRepository := repository.NewProductsMySQL(a.db, 0, a.lg)
 	productLoader := loader.NewProductLoaderJSON(productRepository, "docs/db/json/products.json")
 
 	// invoice
-	invoiceRepository := repository.NewInvoicesMySQL(a.db)
+	invoiceRepository := repository.NewInvoicesMySQL(a.db, 0, a.lg)
 	invoiceLoader := loader.NewInvoiceLoaderJSON(invoiceRepository, "docs/db/json/invoices.json")
 
 	// sale
-	saleRepository := repository.NewSalesMySQL(a.db)
+	saleRepository := repository.NewSalesMySQL(a.db, 0, a.lg)
 	saleLoader := loader.NewSaleLoaderJSON(saleRepository, "docs/db/json/sales.json")
 
+	// write the metrics, also when the migration fails
+	if a.cfgMetricsFile != "" {
+		defer func() {
+			errMetrics := a.writeMetrics()
+			if err == nil {
+				err = errMetrics
+			}
+		}()
+	}
+
 	// migrate
-	err = customerLoader.Migrate()
+	err = customerLoader.Migrate(ctx)
 	if err != nil {
 		return
 	}
 
-	err = productLoader.Migrate()
+	err = productLoader.Migrate(ctx)
 	if err != nil {
 		return
 	}
 
-	err = invoiceLoader.Migrate()
+	err = invoiceLoader.Migrate(ctx)
 	if err != nil {
 		return
 	}
 
-	err = saleLoader.Migrate()
+	err = saleLoader.Migrate(ctx)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+// writeMetrics writes the loader and repository metrics to the metrics file.
+// The file is written aside and renamed so that collectors never read it half written.
+func (a *ApplicationMigrate) writeMetrics() (err error) {
+	reg := metrics.NewRegistry()
+	reg.Register(loader.Imported, loader.ImportErrors, repository.QueryDuration)
+
+	tmp := a.cfgMetricsFile + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return
+	}
+	err = reg.Write(f)
+	if errClose := f.Close(); err == nil {
+		err = errClose
+	}
 	if err != nil {
 		return
 	}
+	err = os.Rename(tmp, a.cfgMetricsFile)
+	return
+}
 
+// TearDown tears down the application.
+func (a *ApplicationMigrate) TearDown() (err error) {
+	// close the database connection
+	if a.db != nil {
+		err = a.db.Close()
+	}
 	return
 }
@@ -0,0 +1,213 @@
+package application
+
+import (
+	"app/internal/handler"
+	"app/platform/auth"
+	"app/platform/idempotency"
+	"app/platform/metrics"
+	"app/platform/openapi"
+	"app/platform/web/response"
+	"net/http"
+)
+
+// envelope is the body of the successful responses.
+type envelope[T any] struct {
+	Message string `json:"message"`
+	Data    T      `json:"data"`
+}
+
+// pageEnvelope is the body of a page of a list.
+type pageEnvelope[T any] struct {
+	Message string           `json:"message"`
+	Data    T                `json:"data"`
+	Page    handler.PageJSON `json:"page"`
+}
+
+// healthBody is the body of the liveness and readiness responses.
+type healthBody struct {
+	Status string                             `json:"status"`
+	Checks map[string]handler.HealthCheckJSON `json:"checks,omitempty"`
+}
+
+// productSalesData is the data of the sales of a product.
+type productSalesData struct {
+	Sales      []handler.ProductSaleJSON        `json:"sales"`
+	Aggregates []handler.ProductSalesPeriodJSON `json:"aggregates,omitempty"`
+}
+
+// integrityData is the data of the integrity check.
+type integrityData struct {
+	Issues []handler.IntegrityIssueJSON `json:"issues"`
+	Rows   map[string][]map[string]any  `json:"rows,omitempty"`
+}
+
+// listParams are the query parameters of the lists, any other parameter is a filter.
+var listParams = []openapi.Param{
+	{Name: "limit", Type: "integer", Description: "Page size, or the total number of items when streaming with Accept: application/x-ndjson."},
+	{Name: "cursor", Description: "The next_cursor of the previous page."},
+	{Name: "sort", Description: "Field to sort by, prefixed with - for descending order."},
+}
+
+// formatParam is the query parameter of the lists and reports choosing their format, see response.NegotiateFormat.
+var formatParam = openapi.Param{Name: "format", Description: "json, csv or xml, overriding the Accept header. csv and xml are downloads."}
+
+// negotiated returns op of a list or report, with the format parameter and the csv and xml responses of response.Negotiated.
+func negotiated(op openapi.Operation) openapi.Operation {
+	hasFormat := false
+	for _, p := range op.Params {
+		hasFormat = hasFormat || p.Name == formatParam.Name
+	}
+	if !hasFormat {
+		op.Params = append(append([]openapi.Param{}, op.Params...), formatParam)
+	}
+	op.Responses = append(op.Responses,
+		openapi.Response{Status: http.StatusOK, ContentType: "text/csv", Body: openapi.Schema{"type": "string"}},
+		openapi.Response{Status: http.StatusOK, ContentType: "application/xml", Body: openapi.Schema{"type": "string"}},
+	)
+	op.Responses = append(op.Responses, fails(http.StatusBadRequest, http.StatusNotAcceptable)...)
+	return op
+}
+
+// bulkParams are the query parameters of the bulk creates.
+var bulkParams = []openapi.Param{
+	{Name: "mode", Description: "atomic, the default, to create all the items or none, or best_effort to create those that can be."},
+}
+
+// bulk returns the operation creating the items of body in bulk at path, body being a slice of request bodies.
+func bulk(path, summary, tag string, body any) openapi.Operation {
+	return secured(auth.RoleClerk, idempotent(openapi.Operation{Method: http.MethodPost, Path: path, Summary: summary, Tags: []string{tag},
+		Params: bulkParams,
+		Body:   body,
+		Responses: append([]openapi.Response{
+			{Status: http.StatusCreated, Description: "Every item created.", Body: envelope[handler.BulkJSON]{}},
+			{Status: http.StatusMultiStatus, Description: "Some items not created, the status and error of each are listed.", Body: envelope[handler.BulkJSON]{}},
+		}, fails(http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnsupportedMediaType, http.StatusUnprocessableEntity)...)}))
+}
+
+// idempotencyParam is the header parameter of the creates replaying their response, see idempotency.Middleware.
+var idempotencyParam = openapi.Param{Name: idempotency.HeaderKey, In: "header",
+	Description: "Key of the request, to retry it safely: the response of the first request with the key is replayed, " +
+		"with Idempotent-Replayed: true, until the key expires. Reusing it with another request fails with 422."}
+
+// idempotent returns op of a create, with the Idempotency-Key header and the responses of idempotency.Middleware.
+func idempotent(op openapi.Operation) openapi.Operation {
+	op.Params = append(append([]openapi.Param{}, op.Params...), idempotencyParam)
+	op.Responses = append(op.Responses, fails(http.StatusBadRequest, http.StatusConflict, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity)...)
+	return op
+}
+
+// ok returns the successful response of an operation.
+func ok(status int, body any) openapi.Response {
+	return openapi.Response{Status: status, Body: body}
+}
+
+// fails returns the error responses of an operation with the given status codes.
+// Operations requiring a role may also fail with 401, 403 and 429, added by secured.
+func fails(codes ...int) (rs []openapi.Response) {
+	for _, c := range codes {
+		rs = append(rs, openapi.Response{Status: c, ContentType: response.ContentTypeProblem, Body: response.Problem{}})
+	}
+	return
+}
+
+// secured returns op requiring role, with the responses of the authentication and rate limit middlewares.
+func secured(role auth.Role, op openapi.Operation) openapi.Operation {
+	op.Role = role
+	op.Responses = append(op.Responses, fails(http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests)...)
+	return op
+}
+
+// openAPI returns the OpenAPI document of the routes registered in ApplicationDefault.setUpRouter.
+// TestOpenAPI fails when a registered route is missing from it.
+func openAPI() (d *openapi.Document) {
+	d = openapi.New("fantasy products", "1.0.0")
+
+	// operations
+	d.Add(
+		openapi.Operation{Method: http.MethodGet, Path: "/healthz", Summary: "Liveness of the process.", Tags: []string{"operations"},
+			Responses: []openapi.Response{ok(http.StatusOK, healthBody{})}},
+		openapi.Operation{Method: http.MethodGet, Path: "/readyz", Summary: "Readiness of the dependencies.", Tags: []string{"operations"},
+			Responses: []openapi.Response{ok(http.StatusOK, healthBody{}), ok(http.StatusServiceUnavailable, healthBody{})}},
+		openapi.Operation{Method: http.MethodGet, Path: "/metrics", Summary: "Prometheus metrics.", Tags: []string{"operations"},
+			Responses: []openapi.Response{{Status: http.StatusOK, ContentType: metrics.ContentType, Body: openapi.Schema{"type": "string"}}}},
+		openapi.Operation{Method: http.MethodGet, Path: "/openapi.json", Summary: "This document.", Tags: []string{"operations"},
+			Responses: []openapi.Response{ok(http.StatusOK, openapi.Schema{"type": "object"})}},
+		secured(auth.RoleAdmin, openapi.Operation{Method: http.MethodGet, Path: "/check", Summary: "Check the integrity of the data.", Tags: []string{"operations"},
+			Params:    []openapi.Param{{Name: "rows", Type: "boolean", Description: "Include the offending rows, keyed by the docs/db/json file they belong to."}},
+			Responses: append([]openapi.Response{ok(http.StatusOK, envelope[integrityData]{})}, fails(http.StatusBadRequest, http.StatusInternalServerError)...)}),
+		secured(auth.RoleAdmin, openapi.Operation{Method: http.MethodGet, Path: "/admin/queries", Summary: "Top queries by total time.", Tags: []string{"operations"},
+			Params:    []openapi.Param{{Name: "limit", Type: "integer", Description: "Number of queries, 10 by default."}},
+			Responses: append([]openapi.Response{ok(http.StatusOK, envelope[[]handler.QueryStatsJSON]{})}, fails(http.StatusBadRequest)...)}),
+		secured(auth.RoleAdmin, openapi.Operation{Method: http.MethodDelete, Path: "/admin/queries", Summary: "Reset the query statistics.", Tags: []string{"operations"},
+			Responses: []openapi.Response{{Status: http.StatusNoContent}}}),
+	)
+
+	// customers
+	d.Add(
+		secured(auth.RoleViewer, negotiated(openapi.Operation{Method: http.MethodGet, Path: "/customers", Summary: "List the customers.", Tags: []string{"customers"},
+			Params:    listParams,
+			Responses: append([]openapi.Response{ok(http.StatusOK, pageEnvelope[[]handler.CustomerJSON]{})}, fails(http.StatusBadRequest, http.StatusInternalServerError)...)})),
+		secured(auth.RoleClerk, idempotent(openapi.Operation{Method: http.MethodPost, Path: "/customers", Summary: "Create a customer.", Tags: []string{"customers"},
+			Body:      handler.RequestBodyCustomer{},
+			Responses: append([]openapi.Response{ok(http.StatusCreated, envelope[handler.CustomerJSON]{})}, fails(http.StatusBadRequest, http.StatusUnprocessableEntity, http.StatusInternalServerError)...)})),
+		bulk("/customers/bulk", "Create customers in bulk.", "customers", []handler.RequestBodyCustomer{}),
+		secured(auth.RoleViewer, negotiated(openapi.Operation{Method: http.MethodGet, Path: "/customers/total/condition", Summary: "Total invoiced by customer condition.", Tags: []string{"customers", "reports"},
+			Responses: append([]openapi.Response{ok(http.StatusOK, envelope[[]handler.TotalByConditionJSON]{})}, fails(http.StatusInternalServerError)...)})),
+		secured(auth.RoleViewer, negotiated(openapi.Operation{Method: http.MethodGet, Path: "/customers/top/active", Summary: "Top active customers by amount spent.", Tags: []string{"customers", "reports"},
+			Responses: append([]openapi.Response{ok(http.StatusOK, envelope[[]handler.CustomerAmountJSON]{})}, fails(http.StatusInternalServerError)...)})),
+		secured(auth.RoleViewer, negotiated(openapi.Operation{Method: http.MethodGet, Path: "/customers/{id}/invoices", Summary: "Invoices of a customer with their lines.", Tags: []string{"customers"},
+			Params:    listParams,
+			Responses: append([]openapi.Response{ok(http.StatusOK, pageEnvelope[[]handler.InvoiceDetailJSON]{})}, fails(http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError)...)})),
+		secured(auth.RoleViewer, negotiated(openapi.Operation{Method: http.MethodGet, Path: "/customers/{id}/statement", Summary: "Statement of a customer.", Tags: []string{"customers", "reports"},
+			Params: []openapi.Param{
+				{Name: "from", Description: "First date, inclusive, YYYY-MM-DD."},
+				{Name: "to", Description: "Last date, inclusive, YYYY-MM-DD."},
+				{Name: "format", Description: "text for a plain text statement, or json, csv or xml, overriding the Accept header. csv and xml are downloads."},
+			},
+			Responses: append([]openapi.Response{ok(http.StatusOK, envelope[handler.CustomerStatementJSON]{})}, fails(http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError)...)})),
+	)
+
+	// products
+	d.Add(
+		secured(auth.RoleViewer, negotiated(openapi.Operation{Method: http.MethodGet, Path: "/products", Summary: "List the products.", Tags: []string{"products"},
+			Params:    listParams,
+			Responses: append([]openapi.Response{ok(http.StatusOK, pageEnvelope[[]handler.ProductJSON]{})}, fails(http.StatusBadRequest, http.StatusInternalServerError)...)})),
+		secured(auth.RoleClerk, idempotent(openapi.Operation{Method: http.MethodPost, Path: "/products", Summary: "Create a product.", Tags: []string{"products"},
+			Body:      handler.RequestBodyProduct{},
+			Responses: append([]openapi.Response{ok(http.StatusCreated, envelope[handler.ProductJSON]{})}, fails(http.StatusBadRequest, http.StatusUnprocessableEntity, http.StatusInternalServerError)...)})),
+		bulk("/products/bulk", "Create products in bulk.", "products", []handler.RequestBodyProduct{}),
+		secured(auth.RoleViewer, negotiated(openapi.Operation{Method: http.MethodGet, Path: "/products/{id}/sales", Summary: "Sales of a product.", Tags: []string{"products", "sales"},
+			Params:    append([]openapi.Param{{Name: "period", Description: "day or month, to aggregate the sales by."}}, listParams...),
+			Responses: append([]openapi.Response{ok(http.StatusOK, pageEnvelope[productSalesData]{})}, fails(http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError)...)})),
+	)
+
+	// invoices
+	d.Add(
+		secured(auth.RoleViewer, negotiated(openapi.Operation{Method: http.MethodGet, Path: "/invoices", Summary: "List the invoices.", Tags: []string{"invoices"},
+			Params:    append([]openapi.Param{{Name: "expand", Description: "Comma separated customer and lines, to include them."}}, listParams...),
+			Responses: append([]openapi.Response{ok(http.StatusOK, pageEnvelope[[]handler.InvoiceDetailJSON]{})}, fails(http.StatusBadRequest, http.StatusInternalServerError)...)})),
+		secured(auth.RoleViewer, openapi.Operation{Method: http.MethodGet, Path: "/invoices/{id}", Summary: "Get an invoice with its customer and lines.", Tags: []string{"invoices"},
+			Responses: append([]openapi.Response{ok(http.StatusOK, envelope[handler.InvoiceDetailJSON]{})}, fails(http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError)...)}),
+		secured(auth.RoleClerk, idempotent(openapi.Operation{Method: http.MethodPost, Path: "/invoices", Summary: "Create an invoice.", Tags: []string{"invoices"},
+			Body:      handler.RequestBodyInvoice{},
+			Responses: append([]openapi.Response{ok(http.StatusOK, envelope[handler.InvoiceJSON]{})}, fails(http.StatusBadRequest, http.StatusUnprocessableEntity, http.StatusInternalServerError)...)})),
+		bulk("/invoices/bulk", "Create invoices in bulk.", "invoices", []handler.RequestBodyInvoice{}),
+		secured(auth.RoleAdmin, openapi.Operation{Method: http.MethodPut, Path: "/invoices/total", Summary: "Recompute the total of every invoice from its sales.", Tags: []string{"invoices"},
+			Responses: append([]openapi.Response{ok(http.StatusOK, envelope[map[string]int]{})}, fails(http.StatusInternalServerError)...)}),
+	)
+
+	// sales
+	d.Add(
+		secured(auth.RoleViewer, negotiated(openapi.Operation{Method: http.MethodGet, Path: "/sales", Summary: "List the sales.", Tags: []string{"sales"},
+			Params:    listParams,
+			Responses: append([]openapi.Response{ok(http.StatusOK, pageEnvelope[[]handler.SaleJSON]{})}, fails(http.StatusBadRequest, http.StatusInternalServerError)...)})),
+		secured(auth.RoleClerk, idempotent(openapi.Operation{Method: http.MethodPost, Path: "/sales", Summary: "Create a sale.", Tags: []string{"sales"},
+			Body:      handler.RequestBodySale{},
+			Responses: append([]openapi.Response{ok(http.StatusOK, envelope[handler.SaleJSON]{})}, fails(http.StatusBadRequest, http.StatusUnprocessableEntity, http.StatusInternalServerError)...)})),
+		bulk("/sales/bulk", "Create sales in bulk.", "sales", []handler.RequestBodySale{}),
+		secured(auth.RoleViewer, negotiated(openapi.Operation{Method: http.MethodGet, Path: "/sales/top", Summary: "Top products by units sold.", Tags: []string{"sales", "reports"},
+			Responses: append([]openapi.Response{ok(http.StatusOK, envelope[[]handler.ProductSalesJSON]{})}, fails(http.StatusInternalServerError)...)})),
+	)
+
+	return
+}
@@ -0,0 +1,37 @@
+package application
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for openAPI
+func TestOpenAPI(t *testing.T) {
+	t.Run("describes every registered route", func(t *testing.T) {
+		// arrange
+		a := NewApplicationDefault(nil)
+		a.setUpRouter()
+
+		// act
+		missing, err := openAPI().Missing(a.router)
+
+		// assert
+		require.NoError(t, err)
+		require.Empty(t, missing, "routes missing from the openapi document")
+	})
+
+	t.Run("builds the document of the registered routes", func(t *testing.T) {
+		// arrange
+		a := NewApplicationDefault(nil)
+		a.setUpRouter()
+
+		// act
+		doc, err := openAPI().Build(a.router)
+
+		// assert
+		require.NoError(t, err)
+		require.Contains(t, doc["paths"], "/customers/{id}/statement")
+		require.Contains(t, doc["components"], "securitySchemes")
+	})
+}
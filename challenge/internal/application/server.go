@@ -0,0 +1,72 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// cancelTimeout is the maximum duration to wait for the requests cancelled after the shutdown timeout to return.
+const cancelTimeout = 2 * time.Second
+
+// serve listens and serves srv until the process receives SIGINT or SIGTERM.
+// Then it stops accepting connections and waits at most shutdownTimeout for the in-flight requests to finish.
+// Requests still running after the deadline have their context cancelled, which aborts their queries,
+// and serve waits at most cancelTimeout more for them to return.
+func serve(srv *http.Server, shutdownTimeout time.Duration) (err error) {
+	// signals
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// base context of the requests, cancelled when the shutdown deadline is exceeded
+	ctxBase, cancelBase := context.WithCancel(context.Background())
+	defer cancelBase()
+	srv.BaseContext = func(net.Listener) context.Context { return ctxBase }
+
+	// in-flight requests, waited for once cancelled
+	var inFlight atomic.Int64
+	h := srv.Handler
+	if h == nil {
+		h = http.DefaultServeMux
+	}
+	srv.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inFlight.Add(1)
+		defer inFlight.Add(-1)
+		h.ServeHTTP(w, r)
+	})
+
+	// serve
+	errServe := make(chan error, 1)
+	go func() {
+		errServe <- srv.ListenAndServe()
+	}()
+
+	// wait for the server to fail or for a signal
+	select {
+	case err = <-errServe:
+		return
+	case <-ctx.Done():
+	}
+	// - a second signal kills the process
+	stop()
+
+	// shut down
+	ctxShutdown, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	err = srv.Shutdown(ctxShutdown)
+	if errors.Is(err, context.DeadlineExceeded) {
+		cancelBase()
+		// - give the cancelled requests a moment to return, so that their queries are aborted before the process exits
+		deadline := time.Now().Add(cancelTimeout)
+		for inFlight.Load() > 0 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	return
+}
@@ -0,0 +1,20 @@
+package internal
+
+import "errors"
+
+// BulkMode is how a bulk save treats the items that fail.
+type BulkMode string
+
+const (
+	// BulkAtomic saves all the items or none of them.
+	BulkAtomic BulkMode = "atomic"
+	// BulkBestEffort saves the items that can be saved and reports the others.
+	BulkBestEffort BulkMode = "best_effort"
+)
+
+var (
+	// ErrBulkAborted is the error of the items of an atomic bulk save not saved because another item failed.
+	ErrBulkAborted = errors.New("bulk aborted: another item failed")
+	// ErrRepositoryReferenceNotFound is returned when an item references an entity that does not exist, e.g. the invoice of a sale.
+	ErrRepositoryReferenceNotFound = errors.New("repository: referenced entity not found")
+)
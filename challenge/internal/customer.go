@@ -1,5 +1,7 @@
 package internal
 
+import "errors"
+
 // CustomerAttributes is the struct that represents the attributes of a customer.
 type CustomerAttributes struct {
 	// FirstName is the first name of the customer.
@@ -35,3 +37,36 @@ type CustomerAmount struct {
 	// Amount is the amount spent by customer.
 	Amount float64
 }
+
+// StatementEntry is the struct that represents an invoice in a customer statement.
+type StatementEntry struct {
+	// InvoiceDetail is the invoice with its lines.
+	InvoiceDetail
+	// Balance is the running balance, the total spent up to and including this invoice.
+	Balance float64
+}
+
+// CustomerStatement is the struct that represents the purchase history of a customer in a period.
+type CustomerStatement struct {
+	// Customer is the customer of the statement.
+	Customer Customer
+	// From is the first day of the period, empty if unbounded.
+	From string
+	// To is the last day of the period, empty if unbounded.
+	To string
+	// Entries are the invoices of the period, ordered by date.
+	Entries []StatementEntry
+	// TotalSpent is the sum of the invoices total.
+	TotalSpent float64
+	// AverageBasket is the average invoice total.
+	AverageBasket float64
+	// FirstPurchase is the datetime of the first invoice, empty if there are no invoices.
+	FirstPurchase string
+	// LastPurchase is the datetime of the last invoice, empty if there are no invoices.
+	LastPurchase string
+}
+
+var (
+	// ErrRepositoryCustomerNotFound is returned when a customer is not found.
+	ErrRepositoryCustomerNotFound = errors.New("repository: customer not found")
+)
@@ -1,6 +1,9 @@
 package internal
 
+import "context"
+
 type CustomerLoader interface {
 	Load() (c []Customer, err error)
-	Migrate() (err error)
+	Migrate(ctx context.Context) (err error)
+	Dump(c []Customer) (err error)
 }
@@ -1,13 +1,21 @@
 package internal
 
+import "context"
+
 // RepositoryCustomer is the interface that wraps the basic methods that a customer repository should implement.
 type RepositoryCustomer interface {
-	// FindAll returns all customers saved in the database.
-	FindAll() (c []Customer, err error)
+	// FindAll returns a page of the customers saved in the database.
+	FindAll(ctx context.Context, q ListQuery) (c []Customer, p Page, err error)
+	// Stream calls fn with each customer of the list, stopping at the first error.
+	Stream(ctx context.Context, q ListQuery, fn func(cs Customer) error) (err error)
+	// FindById returns the customer with the given id.
+	FindById(ctx context.Context, id int) (c Customer, err error)
 	// Save saves a customer into the database.
-	Save(c *Customer) (err error)
+	Save(ctx context.Context, c *Customer) (err error)
+	// SaveBulk saves customers into the database in mode, setting their ids, and returns the error of each one, nil if it was saved.
+	SaveBulk(ctx context.Context, c []Customer, mode BulkMode) (errs []error)
 	// FindTotalByCondition returns the aggregated money from invoices by customer condition.
-	FindTotalByCondition() (t []TotalByCondition, err error)
+	FindTotalByCondition(ctx context.Context) (t []TotalByCondition, err error)
 	// FindTopActive returns the top n active customers in the database by total spent
-	FindTopActive(n int) (c []CustomerAmount, err error)
+	FindTopActive(ctx context.Context, n int) (c []CustomerAmount, err error)
 }
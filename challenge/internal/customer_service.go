@@ -1,13 +1,23 @@
 package internal
 
+import "context"
+
 // ServiceCustomer is the interface that wraps the basic methods that a customer service should implement.
 type ServiceCustomer interface {
-	// FindAll returns all customers
-	FindAll() (c []Customer, err error)
+	// FindAll returns a page of customers
+	FindAll(ctx context.Context, q ListQuery) (c []Customer, p Page, err error)
+	// Stream calls fn with each customer of the list, stopping at the first error
+	Stream(ctx context.Context, q ListQuery, fn func(cs Customer) error) (err error)
 	// Save saves a customer
-	Save(c *Customer) (err error)
+	Save(ctx context.Context, c *Customer) (err error)
+	// SaveBulk saves customers in mode, setting their ids, and returns the error of each one, nil if it was saved
+	SaveBulk(ctx context.Context, c []Customer, mode BulkMode) (errs []error)
 	// FindTotalByCondition returns the aggregated money from invoices by customer condition
-	FindTotalByCondition() (t []TotalByCondition, err error)
+	FindTotalByCondition(ctx context.Context) (t []TotalByCondition, err error)
 	// FindTopActive returns the top n active customers in the database by total spent
-	FindTopActive(n int) (c []CustomerAmount, err error)
+	FindTopActive(ctx context.Context, n int) (c []CustomerAmount, err error)
+	// FindInvoices returns a page of the invoices of a customer with their lines, ordered by date unless sorted otherwise
+	FindInvoices(ctx context.Context, id int, q ListQuery) (i []InvoiceDetail, p Page, err error)
+	// FindStatement returns the statement of a customer between from and to, both optional and inclusive
+	FindStatement(ctx context.Context, id int, from, to string) (s CustomerStatement, err error)
 }
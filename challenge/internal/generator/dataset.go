@@ -0,0 +1,230 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"app/internal"
+)
+
+var (
+	// ErrGeneratorInvalidConfig is returned when the generator configuration is invalid.
+	ErrGeneratorInvalidConfig = errors.New("generator: invalid config")
+)
+
+// ConfigDatasetGenerator is the configuration for NewDatasetGenerator.
+type ConfigDatasetGenerator struct {
+	// Seed is the seed of the random generator, the same seed always generates the same dataset.
+	Seed int64
+	// Customers is the number of customers to generate.
+	Customers int
+	// Products is the number of products to generate.
+	Products int
+	// Invoices is the number of invoices to generate.
+	Invoices int
+	// Sales is the number of sales to generate, at least one per invoice.
+	Sales int
+	// From is the first day invoices can be dated.
+	From time.Time
+	// To is the last day invoices can be dated.
+	To time.Time
+}
+
+// NewDatasetGenerator creates a new DatasetGenerator.
+func NewDatasetGenerator(config *ConfigDatasetGenerator) *DatasetGenerator {
+	// default values
+	defaultCfg := &ConfigDatasetGenerator{
+		Seed:      1,
+		Customers: 100,
+		Products:  100,
+		Invoices:  100,
+		Sales:     1000,
+		From:      time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC),
+		To:        time.Date(2022, time.December, 31, 0, 0, 0, 0, time.UTC),
+	}
+	if config != nil {
+		if config.Seed != 0 {
+			defaultCfg.Seed = config.Seed
+		}
+		if config.Customers != 0 {
+			defaultCfg.Customers = config.Customers
+		}
+		if config.Products != 0 {
+			defaultCfg.Products = config.Products
+		}
+		if config.Invoices != 0 {
+			defaultCfg.Invoices = config.Invoices
+		}
+		if config.Sales != 0 {
+			defaultCfg.Sales = config.Sales
+		}
+		if !config.From.IsZero() {
+			defaultCfg.From = config.From
+		}
+		if !config.To.IsZero() {
+			defaultCfg.To = config.To
+		}
+	}
+
+	return &DatasetGenerator{
+		cfg: *defaultCfg,
+	}
+}
+
+// Dataset is the struct that represents a generated dataset.
+type Dataset struct {
+	// Customers are the generated customers.
+	Customers []internal.Customer
+	// Products are the generated products.
+	Products []internal.Product
+	// Invoices are the generated invoices, with their total matching their sales.
+	Invoices []internal.Invoice
+	// Sales are the generated sales.
+	Sales []internal.Sale
+}
+
+// DatasetGenerator generates realistic datasets of customers, products, invoices and sales.
+// - product popularity and customer activity follow a Pareto (zipf) distribution
+// - invoice dates follow a seasonal distribution, with more invoices at the end of the year
+type DatasetGenerator struct {
+	// cfg is the configuration of the generator.
+	cfg ConfigDatasetGenerator
+}
+
+var (
+	// firstNames are the first names used to generate customers.
+	firstNames = []string{"Ike", "Brannon", "Arel", "Thomasina", "Stefan", "Delano", "Maria", "John", "Jane", "Lucia", "Mateo", "Sofia", "Liam", "Emma", "Noah", "Olivia", "Hugo", "Valentina", "Bruno", "Camila"}
+	// lastNames are the last names used to generate customers.
+	lastNames = []string{"Fifield", "Cowland", "Saint", "Kave", "Rolfe", "Izhaky", "Doe", "Smith", "Garcia", "Lopez", "Martinez", "Gomez", "Fernandez", "Perez", "Brown", "Wilson", "Taylor", "Moore", "Clark", "Hall"}
+	// productNames are the names used to generate product descriptions.
+	productNames = []string{"Pastry", "Beans", "Juice", "Sea Weed", "Pepperoni", "Seed", "Flour", "Wine", "Cheese", "Bread", "Pasta", "Coffee", "Tea", "Chocolate", "Cookies", "Rice", "Oil", "Salt", "Sugar", "Honey"}
+	// productVariants are the variants used to generate product descriptions.
+	productVariants = []string{"Mini", "Organic", "Frozen", "Dried", "Fresh", "Canned", "Whole", "Sliced", "Red", "White", "Black", "Sweet", "Dark", "Light", "Family Pack"}
+	// monthWeights are the relative number of invoices per month, from january to december.
+	monthWeights = [12]float64{0.8, 0.7, 0.8, 0.9, 1.0, 0.9, 1.0, 0.9, 0.9, 1.0, 1.4, 2.0}
+)
+
+// Generate generates the dataset.
+// Ids start at 1 and every foreign key references an existing row.
+func (g *DatasetGenerator) Generate() (d Dataset, err error) {
+	// validate
+	if g.cfg.Customers < 1 || g.cfg.Products < 1 || g.cfg.Invoices < 1 || g.cfg.Sales < g.cfg.Invoices {
+		err = fmt.Errorf("%w: customers, products and invoices must be positive and sales at least invoices", ErrGeneratorInvalidConfig)
+		return
+	}
+	if g.cfg.To.Before(g.cfg.From) {
+		err = fmt.Errorf("%w: to must not be before from", ErrGeneratorInvalidConfig)
+		return
+	}
+
+	rd := rand.New(rand.NewSource(g.cfg.Seed))
+
+	// customers
+	d.Customers = make([]internal.Customer, g.cfg.Customers)
+	for ix := range d.Customers {
+		condition := 0
+		if rd.Float64() < 0.7 {
+			condition = 1
+		}
+		d.Customers[ix] = internal.Customer{
+			Id: ix + 1,
+			CustomerAttributes: internal.CustomerAttributes{
+				FirstName: firstNames[rd.Intn(len(firstNames))],
+				LastName:  lastNames[rd.Intn(len(lastNames))],
+				Condition: condition,
+			},
+		}
+	}
+
+	// products
+	// - price is log-normal, most products are cheap and a few are expensive
+	d.Products = make([]internal.Product, g.cfg.Products)
+	for ix := range d.Products {
+		price := math.Exp(rd.NormFloat64()*0.8 + 3)
+		price = math.Round(math.Min(math.Max(price, 0.5), 999.99)*100) / 100
+		d.Products[ix] = internal.Product{
+			Id: ix + 1,
+			ProductAttributes: internal.ProductAttributes{
+				Description: fmt.Sprintf("%s - %s", productNames[rd.Intn(len(productNames))], productVariants[rd.Intn(len(productVariants))]),
+				Price:       price,
+			},
+		}
+	}
+
+	// invoices
+	customerPick := newParetoPicker(rd, g.cfg.Customers)
+	days := int(g.cfg.To.Sub(g.cfg.From).Hours()/24) + 1
+	d.Invoices = make([]internal.Invoice, g.cfg.Invoices)
+	for ix := range d.Invoices {
+		d.Invoices[ix] = internal.Invoice{
+			Id: ix + 1,
+			InvoiceAttributes: internal.InvoiceAttributes{
+				Datetime:   g.seasonalDate(rd, days).Format(time.DateOnly),
+				CustomerId: customerPick() + 1,
+			},
+		}
+	}
+
+	// sales
+	// - the first sale of each invoice guarantees no invoice is left without sales
+	productPick := newParetoPicker(rd, g.cfg.Products)
+	totals := make([]float64, g.cfg.Invoices)
+	d.Sales = make([]internal.Sale, g.cfg.Sales)
+	for ix := range d.Sales {
+		invoice := ix
+		if ix >= g.cfg.Invoices {
+			invoice = rd.Intn(g.cfg.Invoices)
+		}
+		product := productPick()
+		// - quantity is geometric, most sales are of a few units
+		quantity := 1 + int(rd.ExpFloat64()*4)
+		if quantity > 50 {
+			quantity = 50
+		}
+		d.Sales[ix] = internal.Sale{
+			Id: ix + 1,
+			SaleAttributes: internal.SaleAttributes{
+				Quantity:  quantity,
+				ProductId: product + 1,
+				InvoiceId: invoice + 1,
+			},
+		}
+		totals[invoice] += float64(quantity) * d.Products[product].Price
+	}
+
+	// invoices total
+	for ix := range d.Invoices {
+		d.Invoices[ix].Total = math.Round(totals[ix]*100) / 100
+	}
+
+	return
+}
+
+// seasonalDate returns a random day between from and to, weighted by monthWeights.
+func (g *DatasetGenerator) seasonalDate(rd *rand.Rand, days int) (t time.Time) {
+	maxWeight := 0.0
+	for _, w := range monthWeights {
+		maxWeight = math.Max(maxWeight, w)
+	}
+
+	// rejection sampling
+	for {
+		t = g.cfg.From.AddDate(0, 0, rd.Intn(days))
+		if rd.Float64()*maxWeight < monthWeights[t.Month()-1] {
+			return
+		}
+	}
+}
+
+// newParetoPicker returns a function that picks an index in [0, n) following a zipf distribution.
+// Indexes are shuffled so that popularity does not follow the ids.
+func newParetoPicker(rd *rand.Rand, n int) func() int {
+	perm := rd.Perm(n)
+	zipf := rand.NewZipf(rd, 1.1, 1, uint64(n-1))
+	return func() int {
+		return perm[zipf.Uint64()]
+	}
+}
@@ -0,0 +1,78 @@
+package generator_test
+
+import (
+	"app/internal/generator"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for DatasetGenerator.Generate
+func TestDatasetGeneratorGenerate(t *testing.T) {
+	t.Run("same seed generates the same dataset", func(t *testing.T) {
+		// arrange
+		cfg := &generator.ConfigDatasetGenerator{Seed: 7, Customers: 20, Products: 30, Invoices: 50, Sales: 200}
+
+		// act
+		d1, err1 := generator.NewDatasetGenerator(cfg).Generate()
+		d2, err2 := generator.NewDatasetGenerator(cfg).Generate()
+
+		// assert
+		require.NoError(t, err1)
+		require.NoError(t, err2)
+		require.Equal(t, d1, d2)
+	})
+
+	t.Run("foreign keys and invoice totals are consistent", func(t *testing.T) {
+		// arrange
+		from := time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+		to := time.Date(2022, time.December, 31, 0, 0, 0, 0, time.UTC)
+		cfg := &generator.ConfigDatasetGenerator{Seed: 3, Customers: 10, Products: 15, Invoices: 40, Sales: 300, From: from, To: to}
+
+		// act
+		d, err := generator.NewDatasetGenerator(cfg).Generate()
+
+		// assert
+		require.NoError(t, err)
+		require.Len(t, d.Customers, 10)
+		require.Len(t, d.Products, 15)
+		require.Len(t, d.Invoices, 40)
+		require.Len(t, d.Sales, 300)
+
+		prices := make(map[int]float64)
+		for _, p := range d.Products {
+			require.Greater(t, p.Price, 0.0)
+			prices[p.Id] = p.Price
+		}
+		totals := make(map[int]float64)
+		for _, s := range d.Sales {
+			require.Contains(t, prices, s.ProductId)
+			require.GreaterOrEqual(t, s.InvoiceId, 1)
+			require.LessOrEqual(t, s.InvoiceId, 40)
+			require.Greater(t, s.Quantity, 0)
+			totals[s.InvoiceId] += float64(s.Quantity) * prices[s.ProductId]
+		}
+		for _, i := range d.Invoices {
+			require.GreaterOrEqual(t, i.CustomerId, 1)
+			require.LessOrEqual(t, i.CustomerId, 10)
+			require.Contains(t, totals, i.Id)
+			require.InDelta(t, math.Round(totals[i.Id]*100)/100, i.Total, 0.001)
+			dt, err := time.Parse(time.DateOnly, i.Datetime)
+			require.NoError(t, err)
+			require.False(t, dt.Before(from) || dt.After(to))
+		}
+	})
+
+	t.Run("error - fewer sales than invoices", func(t *testing.T) {
+		// arrange
+		cfg := &generator.ConfigDatasetGenerator{Invoices: 10, Sales: 5}
+
+		// act
+		_, err := generator.NewDatasetGenerator(cfg).Generate()
+
+		// assert
+		require.ErrorIs(t, err, generator.ErrGeneratorInvalidConfig)
+	})
+}
@@ -1,23 +1,31 @@
 package handler
 
 import (
-	"log"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"app/internal"
 	"app/platform/web/request"
 	"app/platform/web/response"
+
+	"github.com/go-chi/chi/v5"
 )
 
 // NewCustomersDefault returns a new CustomersDefault
-func NewCustomersDefault(sv internal.ServiceCustomer) *CustomersDefault {
-	return &CustomersDefault{sv: sv}
+func NewCustomersDefault(sv internal.ServiceCustomer, lg *slog.Logger) *CustomersDefault {
+	return &CustomersDefault{sv: sv, lg: lg}
 }
 
 // CustomersDefault is a struct that returns the customer handlers
 type CustomersDefault struct {
 	// sv is the customer's service
 	sv internal.ServiceCustomer
+	// lg is the logger of the errors behind 5xx responses
+	lg *slog.Logger
 }
 
 // CustomerJSON is a struct that represents a customer in JSON format
@@ -41,17 +49,42 @@ type CustomerAmountJSON struct {
 	Amount    float64 `json:"amount"`
 }
 
-// GetAll returns all customers
+// GetAll returns a page of customers
+// - query param format (json, csv or xml) or header Accept negotiates the format, csv and xml as downloads
+// - header Accept: application/x-ndjson streams the whole list instead, one item per line
 func (h *CustomersDefault) GetAll() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// request
-		// ...
+		// - query params: limit, cursor, sort and filters
+		q, err := listQuery(r)
+		if err != nil {
+			response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParameter, err.Error())
+			return
+		}
 
 		// process
-		c, err := h.sv.FindAll()
+		// - stream the whole list when newline delimited json is accepted
+		if wantsNDJSON(r) {
+			streamList(w, r.Context(), h.lg, q, h.sv.Stream, func(v internal.Customer) any {
+				return CustomerJSON{
+					Id:        v.Id,
+					FirstName: v.FirstName,
+					LastName:  v.LastName,
+					Condition: v.Condition,
+				}
+			})
+			return
+		}
+
+		// - otherwise find a single page, in the negotiated format
+		f, err := response.NegotiateFormat(r)
 		if err != nil {
-			log.Println(err)
-			response.Error(w, http.StatusInternalServerError, "error getting customers")
+			writeError(w, r.Context(), h.lg, err, "error negotiating format")
+			return
+		}
+		c, p, err := h.sv.FindAll(r.Context(), q)
+		if err != nil {
+			writeError(w, r.Context(), h.lg, err, "error getting customers")
 			return
 		}
 
@@ -66,18 +99,23 @@ func (h *CustomersDefault) GetAll() http.HandlerFunc {
 				Condition: v.Condition,
 			}
 		}
-		response.JSON(w, http.StatusOK, map[string]any{
-			"message": "customers found",
-			"data":    csJSON,
+		response.Negotiated(w, r, http.StatusOK, f, response.Table{
+			Body: map[string]any{
+				"message": "customers found",
+				"data":    csJSON,
+				"page":    PageJSON{Limit: q.Limit, NextCursor: p.NextCursor},
+			},
+			Rows:     csJSON,
+			Filename: "customers",
 		})
 	}
 }
 
 // RequestBodyCustomer is a struct that represents the request body for a customer
 type RequestBodyCustomer struct {
-	FirstName string `json:"first_name"`
-	LastName  string `json:"last_name"`
-	Condition int    `json:"condition"`
+	FirstName string             `json:"first_name" validate:"required,max=45"`
+	LastName  string             `json:"last_name" validate:"required,max=45"`
+	Condition request.Field[int] `json:"condition" validate:"required,oneof=0 1"`
 }
 
 // Create creates a new customer
@@ -88,7 +126,7 @@ func (h *CustomersDefault) Create() http.HandlerFunc {
 		var reqBody RequestBodyCustomer
 		err := request.JSON(r, &reqBody)
 		if err != nil {
-			response.Error(w, http.StatusBadRequest, "error deserializing request body")
+			writeError(w, r.Context(), h.lg, err, "error deserializing request body")
 			return
 		}
 
@@ -98,13 +136,13 @@ func (h *CustomersDefault) Create() http.HandlerFunc {
 			CustomerAttributes: internal.CustomerAttributes{
 				FirstName: reqBody.FirstName,
 				LastName:  reqBody.LastName,
-				Condition: reqBody.Condition,
+				Condition: reqBody.Condition.Value,
 			},
 		}
 		// - save
-		err = h.sv.Save(&c)
+		err = h.sv.Save(r.Context(), &c)
 		if err != nil {
-			response.Error(w, http.StatusInternalServerError, "error saving customer")
+			writeError(w, r.Context(), h.lg, err, "error saving customer")
 			return
 		}
 
@@ -123,17 +161,39 @@ func (h *CustomersDefault) Create() http.HandlerFunc {
 	}
 }
 
+// CreateBulk creates the customers of a json array, each validated like in Create, see createBulk
+func (h *CustomersDefault) CreateBulk() http.HandlerFunc {
+	deserialize := func(b RequestBodyCustomer) internal.Customer {
+		return internal.Customer{
+			CustomerAttributes: internal.CustomerAttributes{
+				FirstName: b.FirstName,
+				LastName:  b.LastName,
+				Condition: b.Condition.Value,
+			},
+		}
+	}
+	id := func(c internal.Customer) int { return c.Id }
+	return func(w http.ResponseWriter, r *http.Request) {
+		createBulk(w, r, h.lg, "customers", h.sv.SaveBulk, deserialize, id)
+	}
+}
+
 // GetTotalByCondition returns the aggregated money from invoices by customer condition
+// - query param format (json, csv or xml) or header Accept negotiates the format, csv and xml as downloads
 func (h *CustomersDefault) GetTotalByCondition() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// request
-		// ...
+		// - format
+		f, err := response.NegotiateFormat(r)
+		if err != nil {
+			writeError(w, r.Context(), h.lg, err, "error negotiating format")
+			return
+		}
 
 		// process
-		t, err := h.sv.FindTotalByCondition()
+		t, err := h.sv.FindTotalByCondition(r.Context())
 		if err != nil {
-			log.Println(err)
-			response.Error(w, http.StatusInternalServerError, "error getting total by condition")
+			writeError(w, r.Context(), h.lg, err, "error getting total by condition")
 			return
 		}
 
@@ -146,24 +206,33 @@ func (h *CustomersDefault) GetTotalByCondition() http.HandlerFunc {
 				Total:     v.Total,
 			}
 		}
-		response.JSON(w, http.StatusOK, map[string]any{
-			"message": "succesfuly retrieved total by condition",
-			"data":    tJSON,
+		response.Negotiated(w, r, http.StatusOK, f, response.Table{
+			Body: map[string]any{
+				"message": "succesfuly retrieved total by condition",
+				"data":    tJSON,
+			},
+			Rows:     tJSON,
+			Filename: "customers-total-by-condition",
 		})
 	}
 }
 
 // GetTopActive returns the top n active customers by total amount spent.
+// - query param format (json, csv or xml) or header Accept negotiates the format, csv and xml as downloads
 func (h *CustomersDefault) GetTopActive(n int) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// request
-		// ...
+		// - format
+		f, err := response.NegotiateFormat(r)
+		if err != nil {
+			writeError(w, r.Context(), h.lg, err, "error negotiating format")
+			return
+		}
 
 		// process
-		c, err := h.sv.FindTopActive(n)
+		c, err := h.sv.FindTopActive(r.Context(), n)
 		if err != nil {
-			log.Println(err)
-			response.Error(w, http.StatusInternalServerError, "error getting top active")
+			writeError(w, r.Context(), h.lg, err, "error getting top active")
 			return
 		}
 
@@ -177,9 +246,207 @@ func (h *CustomersDefault) GetTopActive(n int) http.HandlerFunc {
 				Amount:    v.Amount,
 			}
 		}
-		response.JSON(w, http.StatusOK, map[string]any{
-			"message": "succesfuly retrieved top active",
-			"data":    cJSON,
+		response.Negotiated(w, r, http.StatusOK, f, response.Table{
+			Body: map[string]any{
+				"message": "succesfuly retrieved top active",
+				"data":    cJSON,
+			},
+			Rows:     cJSON,
+			Filename: "customers-top-active",
 		})
 	}
 }
+
+// StatementEntryJSON is a struct that represents an invoice of a customer statement in JSON format
+type StatementEntryJSON struct {
+	InvoiceDetailJSON
+	Balance float64 `json:"balance"`
+}
+
+// CustomerStatementJSON is a struct that represents a customer statement in JSON format
+type CustomerStatementJSON struct {
+	Customer      CustomerJSON         `json:"customer"`
+	From          string               `json:"from,omitempty"`
+	To            string               `json:"to,omitempty"`
+	Invoices      []StatementEntryJSON `json:"invoices"`
+	TotalSpent    float64              `json:"total_spent"`
+	AverageBasket float64              `json:"average_basket"`
+	FirstPurchase string               `json:"first_purchase,omitempty"`
+	LastPurchase  string               `json:"last_purchase,omitempty"`
+}
+
+// GetInvoices returns a page of the invoices of a customer with their lines
+// - query params limit, cursor, sort and filters paginate the invoices like the lists, sorted by datetime by default
+// - query param format (json, csv or xml) or header Accept negotiates the format, csv and xml as downloads, lines left out of csv
+func (h *CustomersDefault) GetInvoices() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - path parameter: id
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParameter, "invalid id")
+			return
+		}
+		// - query params: limit, cursor, sort and filters
+		q, err := listQuery(r)
+		if err != nil {
+			response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParameter, err.Error())
+			return
+		}
+
+		// - format
+		f, err := response.NegotiateFormat(r)
+		if err != nil {
+			writeError(w, r.Context(), h.lg, err, "error negotiating format")
+			return
+		}
+
+		// process
+		i, p, err := h.sv.FindInvoices(r.Context(), id, q)
+		if err != nil {
+			writeError(w, r.Context(), h.lg, err, "error getting customer invoices", "customer_id", id)
+			return
+		}
+
+		// response
+		// - serialize
+		ivJSON := make([]InvoiceDetailJSON, len(i))
+		for ix, v := range i {
+			ivJSON[ix] = invoiceDetailJSON(v, false, true)
+		}
+		response.Negotiated(w, r, http.StatusOK, f, response.Table{
+			Body: map[string]any{
+				"message": "customer invoices found",
+				"data":    ivJSON,
+				"page":    PageJSON{Limit: q.Limit, NextCursor: p.NextCursor},
+			},
+			Rows:     ivJSON,
+			Filename: "customer-" + strconv.Itoa(id) + "-invoices",
+		})
+	}
+}
+
+// GetStatement returns the statement of a customer
+// - query params from and to (YYYY-MM-DD) bound the period, both optional and inclusive
+// - query param format=text or header Accept: text/plain returns a plain-text statement
+// - query param format=csv or xml, or header Accept: text/csv or application/xml, downloads it, the invoices as rows in csv
+func (h *CustomersDefault) GetStatement() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - path parameter: id
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParameter, "invalid id")
+			return
+		}
+		// - query params: from, to
+		from := r.URL.Query().Get("from")
+		if from != "" {
+			if _, err := time.Parse(time.DateOnly, from); err != nil {
+				response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParameter, "invalid from")
+				return
+			}
+		}
+		to := r.URL.Query().Get("to")
+		if to != "" {
+			if _, err := time.Parse(time.DateOnly, to); err != nil {
+				response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParameter, "invalid to")
+				return
+			}
+		}
+		// dates in YYYY-MM-DD compare as strings
+		if from != "" && to != "" && from > to {
+			response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParameter, "invalid from, must not be after to")
+			return
+		}
+		// - format, text for a plain-text statement
+		text := r.URL.Query().Get("format") == "text" || strings.HasPrefix(r.Header.Get("Accept"), "text/plain")
+		var f response.Format
+		if !text {
+			f, err = response.NegotiateFormat(r)
+			if err != nil {
+				writeError(w, r.Context(), h.lg, err, "error negotiating format")
+				return
+			}
+		}
+
+		// process
+		st, err := h.sv.FindStatement(r.Context(), id, from, to)
+		if err != nil {
+			writeError(w, r.Context(), h.lg, err, "error getting customer statement", "customer_id", id)
+			return
+		}
+
+		// response
+		// - plain text
+		if text {
+			response.Text(w, http.StatusOK, statementText(st))
+			return
+		}
+		// - serialize
+		stJSON := CustomerStatementJSON{
+			Customer: CustomerJSON{
+				Id:        st.Customer.Id,
+				FirstName: st.Customer.FirstName,
+				LastName:  st.Customer.LastName,
+				Condition: st.Customer.Condition,
+			},
+			From:          st.From,
+			To:            st.To,
+			Invoices:      make([]StatementEntryJSON, len(st.Entries)),
+			TotalSpent:    st.TotalSpent,
+			AverageBasket: st.AverageBasket,
+			FirstPurchase: st.FirstPurchase,
+			LastPurchase:  st.LastPurchase,
+		}
+		for ix, v := range st.Entries {
+			stJSON.Invoices[ix] = StatementEntryJSON{
+				InvoiceDetailJSON: invoiceDetailJSON(v.InvoiceDetail, false, true),
+				Balance:           v.Balance,
+			}
+		}
+		response.Negotiated(w, r, http.StatusOK, f, response.Table{
+			Body: map[string]any{
+				"message": "customer statement found",
+				"data":    stJSON,
+			},
+			Rows:     stJSON.Invoices,
+			Filename: "customer-" + strconv.Itoa(id) + "-statement",
+		})
+	}
+}
+
+// statementText renders a customer statement as plain text
+func statementText(st internal.CustomerStatement) string {
+	var b strings.Builder
+
+	// header
+	fmt.Fprintf(&b, "Statement for %s %s (customer %d)\n", st.Customer.FirstName, st.Customer.LastName, st.Customer.Id)
+	from, to := st.From, st.To
+	if from == "" {
+		from = "-"
+	}
+	if to == "" {
+		to = "-"
+	}
+	fmt.Fprintf(&b, "Period: %s to %s\n\n", from, to)
+
+	// invoices
+	for _, e := range st.Entries {
+		fmt.Fprintf(&b, "Invoice %d  %s  total %.2f  balance %.2f\n", e.Id, e.Datetime, e.Total, e.Balance)
+		for _, l := range e.Lines {
+			fmt.Fprintf(&b, "    %4d x %-40s %10.2f %10.2f\n", l.Quantity, l.ProductDescription, l.UnitPrice, l.LineTotal)
+		}
+	}
+
+	// summary
+	fmt.Fprintf(&b, "\nInvoices:       %d\n", len(st.Entries))
+	fmt.Fprintf(&b, "Total spent:    %.2f\n", st.TotalSpent)
+	fmt.Fprintf(&b, "Average basket: %.2f\n", st.AverageBasket)
+	if st.FirstPurchase != "" {
+		fmt.Fprintf(&b, "First purchase: %s\n", st.FirstPurchase)
+		fmt.Fprintf(&b, "Last purchase:  %s\n", st.LastPurchase)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"app/internal"
+	"app/platform/web/request"
+	"app/platform/web/response"
+	"app/platform/web/validate"
+)
+
+// errorMap maps the sentinel errors of the services, of the decoding of the bodies and of the negotiation of the formats to their responses, the same for every handler
+var errorMap = response.ErrorMap{
+	{Err: internal.ErrRepositoryCustomerNotFound, Status: http.StatusNotFound, Code: "customer_not_found", Detail: "customer not found"},
+	{Err: internal.ErrRepositoryInvoiceNotFound, Status: http.StatusNotFound, Code: "invoice_not_found", Detail: "invoice not found"},
+	{Err: internal.ErrRepositoryProductNotFound, Status: http.StatusNotFound, Code: "product_not_found", Detail: "product not found"},
+	{Err: internal.ErrRepositoryReferenceNotFound, Status: http.StatusUnprocessableEntity, Code: "reference_not_found", Detail: "referenced entity not found"},
+	{Err: internal.ErrBulkAborted, Status: http.StatusFailedDependency, Code: "bulk_aborted", Detail: "not saved as another item failed"},
+	{Err: internal.ErrListQueryInvalid, Status: http.StatusBadRequest, Code: response.CodeInvalidParameter},
+	{Err: request.ErrRequestContentTypeNotJSON, Status: http.StatusUnsupportedMediaType, Code: response.CodeUnsupportedMediaType},
+	{Err: request.ErrRequestBodyTooLarge, Status: http.StatusRequestEntityTooLarge, Code: response.CodeBodyTooLarge},
+	{Err: request.ErrRequestJSONInvalid, Status: http.StatusBadRequest, Code: response.CodeInvalidBody},
+	{Err: validate.ErrValidation, Status: http.StatusUnprocessableEntity, Code: response.CodeValidationFailed},
+	{Err: response.ErrFormatUnknown, Status: http.StatusBadRequest, Code: response.CodeInvalidParameter},
+	{Err: response.ErrFormatNotAcceptable, Status: http.StatusNotAcceptable, Code: response.CodeNotAcceptable},
+}
+
+// writeError writes the error response of err
+// - a sentinel error in errorMap gets its mapped response
+// - any other error is logged to lg with msg and args, and answered with 500 and msg, as its cause is not for the client
+func writeError(w http.ResponseWriter, ctx context.Context, lg *slog.Logger, err error, msg string, args ...any) {
+	if errorMap.Write(w, err) {
+		return
+	}
+	lg.ErrorContext(ctx, msg, append([]any{"error", err}, args...)...)
+	response.Error(w, http.StatusInternalServerError, msg)
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+
+	"app/platform/health"
+	"app/platform/web/response"
+)
+
+// NewHealthDefault returns a new HealthDefault
+func NewHealthDefault(ck *health.Checker, lg *slog.Logger) *HealthDefault {
+	return &HealthDefault{ck: ck, lg: lg}
+}
+
+// HealthDefault is a struct that returns the liveness and readiness handlers
+type HealthDefault struct {
+	// ck runs the readiness checks of the dependencies
+	ck *health.Checker
+	// lg is the logger of the failed readiness checks
+	lg *slog.Logger
+}
+
+// HealthCheckJSON is a struct that represents the result of a readiness check in JSON format
+type HealthCheckJSON struct {
+	Status     string  `json:"status"`
+	Error      string  `json:"error,omitempty"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+// Live returns ok while the process is up, it does not check any dependency
+func (h *HealthDefault) Live() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		response.JSON(w, http.StatusOK, map[string]any{
+			"status": "ok",
+		})
+	}
+}
+
+// Ready checks the dependencies and returns the result of each one
+// - 200 if every dependency is ready, 503 otherwise
+func (h *HealthDefault) Ready() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// process
+		rp := h.ck.Run(r.Context())
+
+		// response
+		// - serialize
+		checks := make(map[string]HealthCheckJSON, len(rp.Results))
+		for name, v := range rp.Results {
+			status := "ok"
+			if !v.Ok {
+				status = "unavailable"
+				h.lg.ErrorContext(r.Context(), "readiness check failed", "check", name, "error", v.Err)
+			}
+			checks[name] = HealthCheckJSON{
+				Status:     status,
+				Error:      v.Error,
+				DurationMs: float64(v.Duration.Microseconds()) / 1000,
+			}
+		}
+		code, status := http.StatusOK, "ok"
+		if !rp.Ok {
+			code, status = http.StatusServiceUnavailable, "unavailable"
+		}
+		response.JSON(w, code, map[string]any{
+			"status": status,
+			"checks": checks,
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"app/internal"
+	"app/platform/web/response"
+)
+
+// NewIntegrityDefault returns a new IntegrityDefault
+func NewIntegrityDefault(sv internal.ServiceIntegrity, lg *slog.Logger) *IntegrityDefault {
+	return &IntegrityDefault{sv: sv, lg: lg}
+}
+
+// IntegrityDefault is a struct that returns the integrity handlers
+type IntegrityDefault struct {
+	// sv is the integrity's service
+	sv internal.ServiceIntegrity
+	// lg is the logger of the errors behind 5xx responses
+	lg *slog.Logger
+}
+
+// IntegrityIssueJSON is a struct that represents the result of an integrity check in JSON format
+type IntegrityIssueJSON struct {
+	Check     string `json:"check"`
+	Entity    string `json:"entity"`
+	Count     int    `json:"count"`
+	SampleIds []int  `json:"sample_ids"`
+}
+
+// Check runs the integrity checks over the database
+// - query param rows=true includes the offending rows, keyed by the docs/db/json file they belong to
+func (h *IntegrityDefault) Check() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - query param: rows
+		withRows := false
+		if v := r.URL.Query().Get("rows"); v != "" {
+			var err error
+			withRows, err = strconv.ParseBool(v)
+			if err != nil {
+				response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParameter, "invalid rows")
+				return
+			}
+		}
+
+		// process
+		rp, err := h.sv.Check(r.Context())
+		if err != nil {
+			writeError(w, r.Context(), h.lg, err, "error checking integrity")
+			return
+		}
+
+		// response
+		// - serialize
+		iJSON := make([]IntegrityIssueJSON, len(rp.Issues))
+		for ix, v := range rp.Issues {
+			sampleIds := v.SampleIds
+			if sampleIds == nil {
+				sampleIds = []int{}
+			}
+			iJSON[ix] = IntegrityIssueJSON{
+				Check:     v.Check,
+				Entity:    v.Entity,
+				Count:     v.Count,
+				SampleIds: sampleIds,
+			}
+		}
+		data := map[string]any{
+			"issues": iJSON,
+		}
+		if withRows {
+			data["rows"] = integrityRowsJSON(rp)
+		}
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "integrity checked",
+			"data":    data,
+		})
+	}
+}
+
+// integrityRowsJSON serializes the offending rows of the report, keyed by the docs/db/json file they belong to
+func integrityRowsJSON(rp internal.IntegrityReport) map[string]any {
+	csJSON := make([]CustomerJSON, len(rp.Customers))
+	for ix, v := range rp.Customers {
+		csJSON[ix] = CustomerJSON{
+			Id:        v.Id,
+			FirstName: v.FirstName,
+			LastName:  v.LastName,
+			Condition: v.Condition,
+		}
+	}
+	ivJSON := make([]InvoiceJSON, len(rp.Invoices))
+	for ix, v := range rp.Invoices {
+		ivJSON[ix] = InvoiceJSON{
+			Id:         v.Id,
+			Datetime:   v.Datetime,
+			Total:      v.Total,
+			CustomerId: v.CustomerId,
+		}
+	}
+	pJSON := make([]ProductJSON, len(rp.Products))
+	for ix, v := range rp.Products {
+		pJSON[ix] = ProductJSON{
+			Id:          v.Id,
+			Description: v.Description,
+			Price:       v.Price,
+		}
+	}
+	sJSON := make([]SaleJSON, len(rp.Sales))
+	for ix, v := range rp.Sales {
+		sJSON[ix] = SaleJSON{
+			Id:        v.Id,
+			Quantity:  v.Quantity,
+			ProductId: v.ProductId,
+			InvoiceId: v.InvoiceId,
+		}
+	}
+	return map[string]any{
+		"customers.json": csJSON,
+		"invoices.json":  ivJSON,
+		"products.json":  pJSON,
+		"sales.json":     sJSON,
+	}
+}
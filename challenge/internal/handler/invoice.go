@@ -1,22 +1,30 @@
 package handler
 
 import (
+	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"app/internal"
 	"app/platform/web/request"
 	"app/platform/web/response"
+
+	"github.com/go-chi/chi/v5"
 )
 
 // NewInvoicesDefault returns a new InvoicesDefault
-func NewInvoicesDefault(sv internal.ServiceInvoice) *InvoicesDefault {
-	return &InvoicesDefault{sv: sv}
+func NewInvoicesDefault(sv internal.ServiceInvoice, lg *slog.Logger) *InvoicesDefault {
+	return &InvoicesDefault{sv: sv, lg: lg}
 }
 
 // InvoicesDefault is a struct that returns the invoice handlers
 type InvoicesDefault struct {
 	// sv is the invoice's service
 	sv internal.ServiceInvoice
+	// lg is the logger of the errors behind 5xx responses
+	lg *slog.Logger
 }
 
 // InvoiceJSON is a struct that represents a invoice in JSON format
@@ -27,16 +35,108 @@ type InvoiceJSON struct {
 	CustomerId int     `json:"customer_id"`
 }
 
-// GetAll returns all invoices
+// InvoiceLineJSON is a struct that represents a line of an invoice in JSON format
+type InvoiceLineJSON struct {
+	SaleId             int     `json:"sale_id"`
+	ProductId          int     `json:"product_id"`
+	ProductDescription string  `json:"product_description"`
+	Quantity           int     `json:"quantity"`
+	UnitPrice          float64 `json:"unit_price"`
+	LineTotal          float64 `json:"line_total"`
+}
+
+// InvoiceDetailJSON is a struct that represents an invoice with its customer and lines in JSON format
+// - lines is null if they were not expanded, and an empty array if they were but the invoice has none
+type InvoiceDetailJSON struct {
+	Id         int               `json:"id"`
+	Datetime   string            `json:"datetime"`
+	Total      float64           `json:"total"`
+	CustomerId int               `json:"customer_id"`
+	Customer   *CustomerJSON     `json:"customer,omitempty"`
+	Lines      []InvoiceLineJSON `json:"lines"`
+}
+
+// GetAll returns a page of invoices
+// - query param expand=customer,lines includes the customer and the lines of each invoice
+// - query param format (json, csv or xml) or header Accept negotiates the format, csv and xml as downloads, lines left out of csv
+// - header Accept: application/x-ndjson streams the whole list instead, one item per line
 func (h *InvoicesDefault) GetAll() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// request
-		// ...
+		// - query param: expand
+		var expandCustomer, expandLines bool
+		if v := r.URL.Query().Get("expand"); v != "" {
+			for _, e := range strings.Split(v, ",") {
+				switch strings.TrimSpace(e) {
+				case "customer":
+					expandCustomer = true
+				case "lines":
+					expandLines = true
+				default:
+					response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParameter, "invalid expand: "+e)
+					return
+				}
+			}
+		}
+		// - query params: limit, cursor, sort and filters
+		q, err := listQuery(r, "expand")
+		if err != nil {
+			response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParameter, err.Error())
+			return
+		}
 
 		// process
-		i, err := h.sv.FindAll()
+		// - stream the whole list when newline delimited json is accepted
+		if wantsNDJSON(r) {
+			if expandCustomer || expandLines {
+				response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParameter, "expand is not supported when streaming")
+				return
+			}
+			streamList(w, r.Context(), h.lg, q, h.sv.Stream, func(v internal.Invoice) any {
+				return InvoiceJSON{
+					Id:         v.Id,
+					Datetime:   v.Datetime,
+					Total:      v.Total,
+					CustomerId: v.CustomerId,
+				}
+			})
+			return
+		}
+
+		// - otherwise find a single page, in the negotiated format
+		f, err := response.NegotiateFormat(r)
 		if err != nil {
-			response.Error(w, http.StatusInternalServerError, "error getting invoices")
+			writeError(w, r.Context(), h.lg, err, "error negotiating format")
+			return
+		}
+		if expandCustomer || expandLines {
+			i, p, err := h.sv.FindAllDetail(r.Context(), q, expandLines)
+			if err != nil {
+				writeError(w, r.Context(), h.lg, err, "error getting invoices")
+				return
+			}
+
+			// response
+			// - serialize
+			ivJSON := make([]InvoiceDetailJSON, len(i))
+			for ix, v := range i {
+				ivJSON[ix] = invoiceDetailJSON(v, expandCustomer, expandLines)
+			}
+			response.Negotiated(w, r, http.StatusOK, f, response.Table{
+				Body: map[string]any{
+					"message": "invoices found",
+					"data":    ivJSON,
+					"page":    PageJSON{Limit: q.Limit, NextCursor: p.NextCursor},
+				},
+				Rows:     ivJSON,
+				Filename: "invoices",
+			})
+			return
+		}
+
+		i, p, err := h.sv.FindAll(r.Context(), q)
+		if err != nil {
+			writeError(w, r.Context(), h.lg, err, "error getting invoices")
 			return
 		}
 
@@ -51,18 +151,84 @@ func (h *InvoicesDefault) GetAll() http.HandlerFunc {
 				CustomerId: v.CustomerId,
 			}
 		}
-		response.JSON(w, http.StatusOK, map[string]any{
-			"message": "invoices found",
-			"data":    ivJSON,
+		response.Negotiated(w, r, http.StatusOK, f, response.Table{
+			Body: map[string]any{
+				"message": "invoices found",
+				"data":    ivJSON,
+				"page":    PageJSON{Limit: q.Limit, NextCursor: p.NextCursor},
+			},
+			Rows:     ivJSON,
+			Filename: "invoices",
 		})
 	}
 }
 
+// GetById returns an invoice with its customer and lines
+func (h *InvoicesDefault) GetById() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - path parameter: id
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParameter, "invalid id")
+			return
+		}
+
+		// process
+		i, err := h.sv.FindById(r.Context(), id)
+		if err != nil {
+			writeError(w, r.Context(), h.lg, err, "error getting invoice", "invoice_id", id)
+			return
+		}
+
+		// response
+		// - serialize
+		iv := invoiceDetailJSON(i, true, true)
+		response.JSONConditional(w, r, http.StatusOK, map[string]any{
+			"message": "invoice found",
+			"data":    iv,
+		}, time.Time{})
+	}
+}
+
+// invoiceDetailJSON serializes an invoice detail, including its customer and lines as requested
+func invoiceDetailJSON(i internal.InvoiceDetail, withCustomer, withLines bool) (iv InvoiceDetailJSON) {
+	iv = InvoiceDetailJSON{
+		Id:         i.Id,
+		Datetime:   i.Datetime,
+		Total:      i.Total,
+		CustomerId: i.CustomerId,
+	}
+	if withCustomer && i.Customer.Id != 0 {
+		iv.Customer = &CustomerJSON{
+			Id:        i.Customer.Id,
+			FirstName: i.Customer.FirstName,
+			LastName:  i.Customer.LastName,
+			Condition: i.Customer.Condition,
+		}
+	}
+	if withLines {
+		// never nil, so that an invoice without lines is told from one whose lines were not expanded
+		iv.Lines = make([]InvoiceLineJSON, len(i.Lines))
+		for ix, v := range i.Lines {
+			iv.Lines[ix] = InvoiceLineJSON{
+				SaleId:             v.SaleId,
+				ProductId:          v.ProductId,
+				ProductDescription: v.ProductDescription,
+				Quantity:           v.Quantity,
+				UnitPrice:          v.UnitPrice,
+				LineTotal:          v.LineTotal,
+			}
+		}
+	}
+	return
+}
+
 // RequestBodyInvoice is a struct that represents the request body for a invoice
 type RequestBodyInvoice struct {
-	Datetime   string  `json:"datetime"`
-	Total      float64 `json:"total"`
-	CustomerId int     `json:"customer_id"`
+	Datetime   string                 `json:"datetime" validate:"required,date"`
+	Total      request.Field[float64] `json:"total" validate:"required,min=0"`
+	CustomerId int                    `json:"customer_id" validate:"required,min=1"`
 }
 
 // Create creates a new invoice
@@ -73,7 +239,7 @@ func (h *InvoicesDefault) Create() http.HandlerFunc {
 		var reqBody RequestBodyInvoice
 		err := request.JSON(r, &reqBody)
 		if err != nil {
-			response.Error(w, http.StatusBadRequest, "error parsing request body")
+			writeError(w, r.Context(), h.lg, err, "error parsing request body")
 			return
 		}
 
@@ -82,14 +248,14 @@ func (h *InvoicesDefault) Create() http.HandlerFunc {
 		i := internal.Invoice{
 			InvoiceAttributes: internal.InvoiceAttributes{
 				Datetime:   reqBody.Datetime,
-				Total:      reqBody.Total,
+				Total:      reqBody.Total.Value,
 				CustomerId: reqBody.CustomerId,
 			},
 		}
 		// - save
-		err = h.sv.Save(&i)
+		err = h.sv.Save(r.Context(), &i)
 		if err != nil {
-			response.Error(w, http.StatusInternalServerError, "error saving invoice")
+			writeError(w, r.Context(), h.lg, err, "error saving invoice")
 			return
 		}
 
@@ -108,6 +274,23 @@ func (h *InvoicesDefault) Create() http.HandlerFunc {
 	}
 }
 
+// CreateBulk creates the invoices of a json array, each validated like in Create, see createBulk
+func (h *InvoicesDefault) CreateBulk() http.HandlerFunc {
+	deserialize := func(b RequestBodyInvoice) internal.Invoice {
+		return internal.Invoice{
+			InvoiceAttributes: internal.InvoiceAttributes{
+				Datetime:   b.Datetime,
+				Total:      b.Total.Value,
+				CustomerId: b.CustomerId,
+			},
+		}
+	}
+	id := func(i internal.Invoice) int { return i.Id }
+	return func(w http.ResponseWriter, r *http.Request) {
+		createBulk(w, r, h.lg, "invoices", h.sv.SaveBulk, deserialize, id)
+	}
+}
+
 // UpdateTotal updates the total of all invoices
 func (h *InvoicesDefault) UpdateTotal() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -115,9 +298,9 @@ func (h *InvoicesDefault) UpdateTotal() http.HandlerFunc {
 		// ...
 
 		// process
-		updated, err := h.sv.UpdateTotal()
+		updated, err := h.sv.UpdateTotal(r.Context())
 		if err != nil {
-			response.Error(w, http.StatusInternalServerError, "error updating invoices total")
+			writeError(w, r.Context(), h.lg, err, "error updating invoices total")
 			return
 		}
 
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"log/slog"
 	"net/http"
 
 	"app/internal"
@@ -9,14 +10,16 @@ import (
 )
 
 // NewProductsDefault returns a new ProductsDefault
-func NewProductsDefault(sv internal.ServiceProduct) *ProductsDefault {
-	return &ProductsDefault{sv: sv}
+func NewProductsDefault(sv internal.ServiceProduct, lg *slog.Logger) *ProductsDefault {
+	return &ProductsDefault{sv: sv, lg: lg}
 }
 
 // ProductsDefault is a struct that returns the product handlers
 type ProductsDefault struct {
 	// sv is the product's service
 	sv internal.ServiceProduct
+	// lg is the logger of the errors behind 5xx responses
+	lg *slog.Logger
 }
 
 // ProductJSON is a struct that represents a product in JSON format
@@ -25,16 +28,42 @@ type ProductJSON struct {
 	Description string  `json:"description"`
 	Price       float64 `json:"price"`
 }
-// GetAll returns all products
+
+// GetAll returns a page of products
+// - query param format (json, csv or xml) or header Accept negotiates the format, csv and xml as downloads
+// - header Accept: application/x-ndjson streams the whole list instead, one item per line
 func (h *ProductsDefault) GetAll() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// request
-		// ...
+		// - query params: limit, cursor, sort and filters
+		q, err := listQuery(r)
+		if err != nil {
+			response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParameter, err.Error())
+			return
+		}
 
 		// process
-		p, err := h.sv.FindAll()
+		// - stream the whole list when newline delimited json is accepted
+		if wantsNDJSON(r) {
+			streamList(w, r.Context(), h.lg, q, h.sv.Stream, func(v internal.Product) any {
+				return ProductJSON{
+					Id:          v.Id,
+					Description: v.Description,
+					Price:       v.Price,
+				}
+			})
+			return
+		}
+
+		// - otherwise find a single page, in the negotiated format
+		f, err := response.NegotiateFormat(r)
 		if err != nil {
-			response.Error(w, http.StatusInternalServerError, "error getting products")
+			writeError(w, r.Context(), h.lg, err, "error negotiating format")
+			return
+		}
+		p, pg, err := h.sv.FindAll(r.Context(), q)
+		if err != nil {
+			writeError(w, r.Context(), h.lg, err, "error getting products")
 			return
 		}
 
@@ -48,18 +77,24 @@ func (h *ProductsDefault) GetAll() http.HandlerFunc {
 				Price:       v.Price,
 			}
 		}
-		response.JSON(w, http.StatusOK, map[string]any{
-			"message": "products found",
-			"data":    pJSON,
+		response.Negotiated(w, r, http.StatusOK, f, response.Table{
+			Body: map[string]any{
+				"message": "products found",
+				"data":    pJSON,
+				"page":    PageJSON{Limit: q.Limit, NextCursor: pg.NextCursor},
+			},
+			Rows:     pJSON,
+			Filename: "products",
 		})
 	}
 }
 
 // RequestBodyProduct is a struct that represents the request body for a product
 type RequestBodyProduct struct {
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
+	Description string                 `json:"description" validate:"required,max=100"`
+	Price       request.Field[float64] `json:"price" validate:"required,min=0"`
 }
+
 // Create creates a new product
 func (h *ProductsDefault) Create() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -68,7 +103,7 @@ func (h *ProductsDefault) Create() http.HandlerFunc {
 		var reqBody RequestBodyProduct
 		err := request.JSON(r, &reqBody)
 		if err != nil {
-			response.Error(w, http.StatusBadRequest, "error parsing request body")
+			writeError(w, r.Context(), h.lg, err, "error parsing request body")
 			return
 		}
 
@@ -77,13 +112,13 @@ func (h *ProductsDefault) Create() http.HandlerFunc {
 		p := internal.Product{
 			ProductAttributes: internal.ProductAttributes{
 				Description: reqBody.Description,
-				Price:       reqBody.Price,
+				Price:       reqBody.Price.Value,
 			},
 		}
 		// - save
-		err = h.sv.Save(&p)
+		err = h.sv.Save(r.Context(), &p)
 		if err != nil {
-			response.Error(w, http.StatusInternalServerError, "error creating product")
+			writeError(w, r.Context(), h.lg, err, "error creating product")
 			return
 		}
 
@@ -100,3 +135,19 @@ func (h *ProductsDefault) Create() http.HandlerFunc {
 		})
 	}
 }
+
+// CreateBulk creates the products of a json array, each validated like in Create, see createBulk
+func (h *ProductsDefault) CreateBulk() http.HandlerFunc {
+	deserialize := func(b RequestBodyProduct) internal.Product {
+		return internal.Product{
+			ProductAttributes: internal.ProductAttributes{
+				Description: b.Description,
+				Price:       b.Price.Value,
+			},
+		}
+	}
+	id := func(p internal.Product) int { return p.Id }
+	return func(w http.ResponseWriter, r *http.Request) {
+		createBulk(w, r, h.lg, "products", h.sv.SaveBulk, deserialize, id)
+	}
+}
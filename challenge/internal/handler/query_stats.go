@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"app/platform/sqlstats"
+	"app/platform/web/response"
+)
+
+// NewQueryStatsDefault returns a new QueryStatsDefault
+func NewQueryStatsDefault(st *sqlstats.Stats) *QueryStatsDefault {
+	return &QueryStatsDefault{st: st}
+}
+
+// QueryStatsDefault is a struct that returns the query statistics handlers
+type QueryStatsDefault struct {
+	// st records the statements run by the repositories
+	st *sqlstats.Stats
+}
+
+// QueryStatsJSON is a struct that represents the statistics of a statement in JSON format
+type QueryStatsJSON struct {
+	Query   string  `json:"query"`
+	Calls   int64   `json:"calls"`
+	Errors  int64   `json:"errors"`
+	Rows    int64   `json:"rows"`
+	TotalMs float64 `json:"total_ms"`
+	MeanMs  float64 `json:"mean_ms"`
+	MaxMs   float64 `json:"max_ms"`
+}
+
+// GetTop returns the statements with the most total time since start up or the last reset
+// - query param limit (default 10) bounds the number of statements
+func (h *QueryStatsDefault) GetTop() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - query param: limit
+		limit := 10
+		if v := r.URL.Query().Get("limit"); v != "" {
+			var err error
+			limit, err = strconv.Atoi(v)
+			if err != nil || limit < 1 {
+				response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParameter, "invalid limit")
+				return
+			}
+		}
+
+		// process
+		q := h.st.Top(limit)
+
+		// response
+		// - serialize
+		qJSON := make([]QueryStatsJSON, len(q))
+		for ix, v := range q {
+			qJSON[ix] = QueryStatsJSON{
+				Query:   v.Query,
+				Calls:   v.Calls,
+				Errors:  v.Errors,
+				Rows:    v.Rows,
+				TotalMs: float64(v.Total.Microseconds()) / 1000,
+				MeanMs:  float64(v.Mean().Microseconds()) / 1000,
+				MaxMs:   float64(v.Max.Microseconds()) / 1000,
+			}
+		}
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "top queries found",
+			"data":    qJSON,
+		})
+	}
+}
+
+// Reset forgets the recorded statistics
+func (h *QueryStatsDefault) Reset() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// process
+		h.st.Reset()
+
+		// response
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -1,22 +1,28 @@
 package handler
 
 import (
+	"log/slog"
 	"net/http"
+	"strconv"
 
 	"app/internal"
 	"app/platform/web/request"
 	"app/platform/web/response"
+
+	"github.com/go-chi/chi/v5"
 )
 
 // NewSalesDefault returns a new SalesDefault
-func NewSalesDefault(sv internal.ServiceSale) *SalesDefault {
-	return &SalesDefault{sv: sv}
+func NewSalesDefault(sv internal.ServiceSale, lg *slog.Logger) *SalesDefault {
+	return &SalesDefault{sv: sv, lg: lg}
 }
 
 // SalesDefault is a struct that returns the sale handlers
 type SalesDefault struct {
 	// sv is the sale's service
 	sv internal.ServiceSale
+	// lg is the logger of the errors behind 5xx responses
+	lg *slog.Logger
 }
 
 // SaleJSON is a struct that represents a sale in JSON format
@@ -33,16 +39,42 @@ type ProductSalesJSON struct {
 	Sales              int    `json:"sales"`
 }
 
-// GetAll returns all sales
+// GetAll returns a page of sales
+// - query param format (json, csv or xml) or header Accept negotiates the format, csv and xml as downloads
+// - header Accept: application/x-ndjson streams the whole list instead, one item per line
 func (h *SalesDefault) GetAll() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// request
-		// ...
+		// - query params: limit, cursor, sort and filters
+		q, err := listQuery(r)
+		if err != nil {
+			response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParameter, err.Error())
+			return
+		}
 
 		// process
-		s, err := h.sv.FindAll()
+		// - stream the whole list when newline delimited json is accepted
+		if wantsNDJSON(r) {
+			streamList(w, r.Context(), h.lg, q, h.sv.Stream, func(v internal.Sale) any {
+				return SaleJSON{
+					Id:        v.Id,
+					Quantity:  v.Quantity,
+					ProductId: v.ProductId,
+					InvoiceId: v.InvoiceId,
+				}
+			})
+			return
+		}
+
+		// - otherwise find a single page, in the negotiated format
+		f, err := response.NegotiateFormat(r)
 		if err != nil {
-			response.Error(w, http.StatusInternalServerError, "error getting sales")
+			writeError(w, r.Context(), h.lg, err, "error negotiating format")
+			return
+		}
+		s, p, err := h.sv.FindAll(r.Context(), q)
+		if err != nil {
+			writeError(w, r.Context(), h.lg, err, "error getting sales")
 			return
 		}
 
@@ -57,18 +89,23 @@ func (h *SalesDefault) GetAll() http.HandlerFunc {
 				InvoiceId: v.InvoiceId,
 			}
 		}
-		response.JSON(w, http.StatusOK, map[string]any{
-			"message": "sales found",
-			"data":    sJSON,
+		response.Negotiated(w, r, http.StatusOK, f, response.Table{
+			Body: map[string]any{
+				"message": "sales found",
+				"data":    sJSON,
+				"page":    PageJSON{Limit: q.Limit, NextCursor: p.NextCursor},
+			},
+			Rows:     sJSON,
+			Filename: "sales",
 		})
 	}
 }
 
 // RequestBodySale is a struct that represents the request body for a sale
 type RequestBodySale struct {
-	Quantity  int `json:"quantity"`
-	ProductId int `json:"product_id"`
-	InvoiceId int `json:"invoice_id"`
+	Quantity  int `json:"quantity" validate:"required,min=1"`
+	ProductId int `json:"product_id" validate:"required,min=1"`
+	InvoiceId int `json:"invoice_id" validate:"required,min=1"`
 }
 
 // Create creates a new sale
@@ -79,7 +116,7 @@ func (h *SalesDefault) Create() http.HandlerFunc {
 		var reqBody RequestBodySale
 		err := request.JSON(r, &reqBody)
 		if err != nil {
-			response.Error(w, http.StatusBadRequest, "error parsing request body")
+			writeError(w, r.Context(), h.lg, err, "error parsing request body")
 			return
 		}
 
@@ -93,9 +130,9 @@ func (h *SalesDefault) Create() http.HandlerFunc {
 			},
 		}
 		// - save
-		err = h.sv.Save(&s)
+		err = h.sv.Save(r.Context(), &s)
 		if err != nil {
-			response.Error(w, http.StatusInternalServerError, "error saving sale")
+			writeError(w, r.Context(), h.lg, err, "error saving sale")
 			return
 		}
 
@@ -114,16 +151,39 @@ func (h *SalesDefault) Create() http.HandlerFunc {
 	}
 }
 
+// CreateBulk creates the sales of a json array, each validated like in Create, see createBulk
+func (h *SalesDefault) CreateBulk() http.HandlerFunc {
+	deserialize := func(b RequestBodySale) internal.Sale {
+		return internal.Sale{
+			SaleAttributes: internal.SaleAttributes{
+				Quantity:  b.Quantity,
+				ProductId: b.ProductId,
+				InvoiceId: b.InvoiceId,
+			},
+		}
+	}
+	id := func(s internal.Sale) int { return s.Id }
+	return func(w http.ResponseWriter, r *http.Request) {
+		createBulk(w, r, h.lg, "sales", h.sv.SaveBulk, deserialize, id)
+	}
+}
+
 // GetTopProductSales returns the top n product sales
+// - query param format (json, csv or xml) or header Accept negotiates the format, csv and xml as downloads
 func (h *SalesDefault) GetTopProductSales(n int) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// request
-		// ...
+		// - format
+		f, err := response.NegotiateFormat(r)
+		if err != nil {
+			writeError(w, r.Context(), h.lg, err, "error negotiating format")
+			return
+		}
 
 		// process
-		p, err := h.sv.FindTopSold(n)
+		p, err := h.sv.FindTopSold(r.Context(), n)
 		if err != nil {
-			response.Error(w, http.StatusInternalServerError, "error getting top product sales")
+			writeError(w, r.Context(), h.lg, err, "error getting top product sales")
 			return
 		}
 
@@ -136,9 +196,113 @@ func (h *SalesDefault) GetTopProductSales(n int) http.HandlerFunc {
 				Sales:              v.Sales,
 			}
 		}
-		response.JSON(w, http.StatusOK, map[string]any{
-			"message": "top product sales found",
-			"data":    pJSON,
+		response.Negotiated(w, r, http.StatusOK, f, response.Table{
+			Body: map[string]any{
+				"message": "top product sales found",
+				"data":    pJSON,
+			},
+			Rows:     pJSON,
+			Filename: "sales-top-products",
+		})
+	}
+}
+
+// ProductSaleJSON is a struct that represents a sale of a product in JSON format
+type ProductSaleJSON struct {
+	SaleId            int     `json:"sale_id"`
+	Quantity          int     `json:"quantity"`
+	Revenue           float64 `json:"revenue"`
+	InvoiceId         int     `json:"invoice_id"`
+	InvoiceDatetime   string  `json:"invoice_datetime"`
+	CustomerId        int     `json:"customer_id"`
+	CustomerFirstName string  `json:"customer_first_name"`
+	CustomerLastName  string  `json:"customer_last_name"`
+}
+
+// ProductSalesPeriodJSON is a struct that represents the aggregated sales of a product in a period in JSON format
+type ProductSalesPeriodJSON struct {
+	Period  string  `json:"period"`
+	Units   int     `json:"units"`
+	Revenue float64 `json:"revenue"`
+}
+
+// GetByProductId returns a page of the sales history of a product
+// - query params limit, cursor, sort and filters paginate the sales like the lists, sorted by datetime by default
+// - query param period (day or month, default day) aggregates units and revenue
+// - query param format (json, csv or xml) or header Accept negotiates the format, csv and xml as downloads, the sales as rows in csv
+func (h *SalesDefault) GetByProductId() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - path parameter: id
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParameter, "invalid id")
+			return
+		}
+		// - query params: limit, cursor, sort and filters
+		q, err := listQuery(r, "period")
+		if err != nil {
+			response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParameter, err.Error())
+			return
+		}
+		// - query param: period
+		period := internal.PeriodDay
+		if v := r.URL.Query().Get("period"); v != "" {
+			period = v
+		}
+		if period != internal.PeriodDay && period != internal.PeriodMonth {
+			response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParameter, "invalid period")
+			return
+		}
+
+		// - format
+		f, err := response.NegotiateFormat(r)
+		if err != nil {
+			writeError(w, r.Context(), h.lg, err, "error negotiating format")
+			return
+		}
+
+		// process
+		s, pg, p, err := h.sv.FindByProductId(r.Context(), id, q, period)
+		if err != nil {
+			writeError(w, r.Context(), h.lg, err, "error getting product sales", "product_id", id)
+			return
+		}
+
+		// response
+		// - serialize
+		sJSON := make([]ProductSaleJSON, len(s))
+		for ix, v := range s {
+			sJSON[ix] = ProductSaleJSON{
+				SaleId:            v.SaleId,
+				Quantity:          v.Quantity,
+				Revenue:           v.Revenue,
+				InvoiceId:         v.InvoiceId,
+				InvoiceDatetime:   v.InvoiceDatetime,
+				CustomerId:        v.CustomerId,
+				CustomerFirstName: v.CustomerFirstName,
+				CustomerLastName:  v.CustomerLastName,
+			}
+		}
+		pJSON := make([]ProductSalesPeriodJSON, len(p))
+		for ix, v := range p {
+			pJSON[ix] = ProductSalesPeriodJSON{
+				Period:  v.Period,
+				Units:   v.Units,
+				Revenue: v.Revenue,
+			}
+		}
+		response.Negotiated(w, r, http.StatusOK, f, response.Table{
+			Body: map[string]any{
+				"message": "product sales found",
+				"data": map[string]any{
+					"sales":      sJSON,
+					"aggregates": pJSON,
+				},
+				"page": PageJSON{Limit: q.Limit, NextCursor: pg.NextCursor},
+			},
+			Rows:     sJSON,
+			Filename: "product-" + strconv.Itoa(id) + "-sales",
 		})
 	}
 }
@@ -0,0 +1,27 @@
+package internal
+
+// IntegrityIssue is the struct that represents the result of a single integrity check.
+type IntegrityIssue struct {
+	// Check is the name of the check.
+	Check string
+	// Entity is the name of the table the offending rows belong to.
+	Entity string
+	// Count is the number of offending rows.
+	Count int
+	// SampleIds are the ids of the first offending rows.
+	SampleIds []int
+}
+
+// IntegrityReport is the struct that represents the result of checking the database.
+type IntegrityReport struct {
+	// Issues are the results of each check, in the order they were run.
+	Issues []IntegrityIssue
+	// Customers are the offending customers.
+	Customers []Customer
+	// Invoices are the offending invoices.
+	Invoices []Invoice
+	// Products are the offending products.
+	Products []Product
+	// Sales are the offending sales.
+	Sales []Sale
+}
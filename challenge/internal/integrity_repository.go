@@ -0,0 +1,21 @@
+package internal
+
+import "context"
+
+// RepositoryIntegrity is the interface that wraps the queries used to check the integrity of the database.
+type RepositoryIntegrity interface {
+	// FindOrphanSales returns the sales whose invoice or product does not exist.
+	FindOrphanSales(ctx context.Context) (s []Sale, err error)
+	// FindInvoicesWithoutSales returns the invoices that have no sales.
+	FindInvoicesWithoutSales(ctx context.Context) (i []Invoice, err error)
+	// FindCustomersWithoutInvoices returns the customers that have no invoices.
+	FindCustomersWithoutInvoices(ctx context.Context) (c []Customer, err error)
+	// FindProductsNeverSold returns the products that have no sales.
+	FindProductsNeverSold(ctx context.Context) (p []Product, err error)
+	// FindDuplicateCustomers returns the customers that share first and last name with another customer.
+	FindDuplicateCustomers(ctx context.Context) (c []Customer, err error)
+	// FindSalesNonPositiveQuantity returns the sales with a zero or negative quantity.
+	FindSalesNonPositiveQuantity(ctx context.Context) (s []Sale, err error)
+	// FindProductsNonPositivePrice returns the products with a zero or negative price.
+	FindProductsNonPositivePrice(ctx context.Context) (p []Product, err error)
+}
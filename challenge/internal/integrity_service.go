@@ -0,0 +1,9 @@
+package internal
+
+import "context"
+
+// ServiceIntegrity is the interface that wraps the basic methods that an integrity service should implement.
+type ServiceIntegrity interface {
+	// Check runs every integrity check and returns the report.
+	Check(ctx context.Context) (r IntegrityReport, err error)
+}
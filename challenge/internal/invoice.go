@@ -1,5 +1,7 @@
 package internal
 
+import "errors"
+
 // InvoiceAttributes is the struct that represents the attributes of an invoice.
 type InvoiceAttributes struct {
 	// Datetime is the datetime of the invoice.
@@ -16,4 +18,35 @@ type Invoice struct {
 	Id int
 	// InvoiceAttributes is the attributes of the invoice.
 	InvoiceAttributes
-}
\ No newline at end of file
+}
+
+// InvoiceLine is the struct that represents a sale of an invoice with its product.
+type InvoiceLine struct {
+	// SaleId is the id of the sale.
+	SaleId int
+	// ProductId is the id of the product sold.
+	ProductId int
+	// ProductDescription is the description of the product sold.
+	ProductDescription string
+	// Quantity is the quantity sold.
+	Quantity int
+	// UnitPrice is the price of the product.
+	UnitPrice float64
+	// LineTotal is the quantity multiplied by the unit price.
+	LineTotal float64
+}
+
+// InvoiceDetail is the struct that represents an invoice with its customer and lines.
+type InvoiceDetail struct {
+	// Invoice is the invoice.
+	Invoice
+	// Customer is the customer of the invoice.
+	Customer Customer
+	// Lines are the sales of the invoice.
+	Lines []InvoiceLine
+}
+
+var (
+	// ErrRepositoryInvoiceNotFound is returned when an invoice is not found.
+	ErrRepositoryInvoiceNotFound = errors.New("repository: invoice not found")
+)
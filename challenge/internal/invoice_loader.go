@@ -1,6 +1,9 @@
 package internal
 
+import "context"
+
 type InvoiceLoader interface {
 	Load() (c []Invoice, err error)
-	Migrate() (err error)
+	Migrate(ctx context.Context) (err error)
+	Dump(c []Invoice) (err error)
 }
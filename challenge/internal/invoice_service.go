@@ -1,11 +1,21 @@
 package internal
 
+import "context"
+
 // ServiceInvoice is the interface that wraps the basic methods that an invoice service should implement.
 type ServiceInvoice interface {
-	// FindAll returns all invoices
-	FindAll() (i []Invoice, err error)
+	// FindAll returns a page of invoices
+	FindAll(ctx context.Context, q ListQuery) (i []Invoice, p Page, err error)
+	// Stream calls fn with each invoice of the list, stopping at the first error
+	Stream(ctx context.Context, q ListQuery, fn func(iv Invoice) error) (err error)
+	// FindById returns the invoice with its customer and lines
+	FindById(ctx context.Context, id int) (i InvoiceDetail, err error)
+	// FindAllDetail returns a page of invoices with their customer and, if lines, their lines
+	FindAllDetail(ctx context.Context, q ListQuery, lines bool) (i []InvoiceDetail, p Page, err error)
 	// Save saves an invoice
-	Save(i *Invoice) (err error)
+	Save(ctx context.Context, i *Invoice) (err error)
+	// SaveBulk saves invoices in mode, setting their ids, and returns the error of each one, nil if it was saved
+	SaveBulk(ctx context.Context, i []Invoice, mode BulkMode) (errs []error)
 	// UpdateInvoicesTotal updates the total of all invoices
-	UpdateTotal() (totalUpdated int, err error)
+	UpdateTotal(ctx context.Context) (totalUpdated int, err error)
 }
@@ -0,0 +1,38 @@
+package internal
+
+import "errors"
+
+// ListQuery is the struct that represents the pagination, filters and sorting of a list.
+type ListQuery struct {
+	// Limit is the maximum number of items to return, zero returns all of them.
+	Limit int
+	// Cursor is the opaque position returned by the previous page, empty for the first page.
+	Cursor string
+	// Sort is the field to sort by, prefixed with "-" for descending order. Empty sorts by id.
+	Sort string
+	// Filters are the filters to apply, by name.
+	Filters map[string]string
+}
+
+// WithFilter returns a copy of q also filtered by name, replacing the filter of the same name if any.
+// It scopes a list to a parent entity, such as the sales of a product, whatever the filters of the request.
+func (q ListQuery) WithFilter(name, value string) ListQuery {
+	f := make(map[string]string, len(q.Filters)+1)
+	for k, v := range q.Filters {
+		f[k] = v
+	}
+	f[name] = value
+	q.Filters = f
+	return q
+}
+
+// Page is the struct that represents the pagination metadata of a list.
+type Page struct {
+	// NextCursor is the cursor of the next page, empty if this is the last page.
+	NextCursor string
+}
+
+var (
+	// ErrListQueryInvalid is returned when the limit, cursor, sort or filters of a list query are not supported.
+	ErrListQueryInvalid = errors.New("list query invalid")
+)
@@ -2,6 +2,7 @@ package loader
 
 import (
 	"app/internal"
+	"context"
 	"encoding/json"
 	"os"
 )
@@ -54,7 +55,7 @@ func (l *CustomerLoaderJSON) Load() (c []internal.Customer, err error) {
 }
 
 // Migrate customers to the repository.
-func (l *CustomerLoaderJSON) Migrate() (err error) {
+func (l *CustomerLoaderJSON) Migrate(ctx context.Context) (err error) {
 	// load the customers
 	c, err := l.Load()
 	if err != nil {
@@ -63,11 +64,31 @@ func (l *CustomerLoaderJSON) Migrate() (err error) {
 
 	// iterate over the customers and save them to the repository
 	for _, v := range c {
-		err = l.rp.Save(&v)
+		err = l.rp.Save(ctx, &v)
 		if err != nil {
+			ImportErrors.Inc("customers")
 			return err
 		}
+		Imported.Inc("customers")
 	}
 
 	return
 }
+
+// Dump writes the customers to the JSON file, in the same format read by Load.
+func (l *CustomerLoaderJSON) Dump(c []internal.Customer) (err error) {
+	// serialize the customers
+	cs := make([]CustomerJSON, len(c))
+	for ix, v := range c {
+		cs[ix] = CustomerJSON{
+			Id:        v.Id,
+			FirstName: v.FirstName,
+			LastName:  v.LastName,
+			Condition: v.Condition,
+		}
+	}
+
+	// write the file
+	err = dump(l.filepath, cs)
+	return
+}
@@ -2,6 +2,7 @@ package loader
 
 import (
 	"app/internal"
+	"context"
 	"encoding/json"
 	"os"
 )
@@ -54,7 +55,7 @@ func (l *InvoiceLoaderJSON) Load() (c []internal.Invoice, err error) {
 }
 
 // Migrate invoices to the repository.
-func (l *InvoiceLoaderJSON) Migrate() (err error) {
+func (l *InvoiceLoaderJSON) Migrate(ctx context.Context) (err error) {
 	// load the invoices
 	c, err := l.Load()
 	if err != nil {
@@ -63,11 +64,31 @@ func (l *InvoiceLoaderJSON) Migrate() (err error) {
 
 	// iterate over the slice and append the invoices to the repository
 	for _, v := range c {
-		err = l.rp.Save(&v)
+		err = l.rp.Save(ctx, &v)
 		if err != nil {
+			ImportErrors.Inc("invoices")
 			return err
 		}
+		Imported.Inc("invoices")
 	}
 
 	return
 }
+
+// Dump writes the invoices to the JSON file, in the same format read by Load.
+func (l *InvoiceLoaderJSON) Dump(c []internal.Invoice) (err error) {
+	// serialize the invoices
+	cs := make([]InvoiceJSON, len(c))
+	for ix, v := range c {
+		cs[ix] = InvoiceJSON{
+			Id:         v.Id,
+			Datetime:   v.Datetime,
+			Total:      v.Total,
+			CustomerId: v.CustomerId,
+		}
+	}
+
+	// write the file
+	err = dump(l.filepath, cs)
+	return
+}
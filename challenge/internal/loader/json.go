@@ -0,0 +1,58 @@
+package loader
+
+import (
+	"app/platform/metrics"
+	"bufio"
+	"encoding/json"
+	"os"
+)
+
+var (
+	// Imported counts the rows saved by Migrate, by entity.
+	Imported = metrics.NewCounterVec("loader_imported_total", "Number of rows imported by the loaders.", "entity")
+	// ImportErrors counts the rows Migrate failed to save, by entity.
+	ImportErrors = metrics.NewCounterVec("loader_import_errors_total", "Number of rows the loaders failed to import.", "entity")
+)
+
+// dump writes the items to the file as a JSON array with one item per line,
+// the same layout as the files under docs/db/json.
+func dump[T any](filepath string, items []T) (err error) {
+	// open the file
+	// - create if not exists / write only / truncate
+	f, err := os.OpenFile(filepath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// write the items
+	w := bufio.NewWriter(f)
+	_, err = w.WriteString("[")
+	if err != nil {
+		return err
+	}
+	for ix, v := range items {
+		if ix > 0 {
+			_, err = w.WriteString(",\n")
+			if err != nil {
+				return err
+			}
+		}
+		var b []byte
+		b, err = json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = w.WriteString("]")
+	if err != nil {
+		return err
+	}
+
+	err = w.Flush()
+	return
+}
@@ -2,6 +2,7 @@ package loader
 
 import (
 	"app/internal"
+	"context"
 	"encoding/json"
 	"os"
 )
@@ -52,7 +53,7 @@ func (l *ProductLoaderJSON) Load() (c []internal.Product, err error) {
 }
 
 // Migrate products to the repository.
-func (l *ProductLoaderJSON) Migrate() (err error) {
+func (l *ProductLoaderJSON) Migrate(ctx context.Context) (err error) {
 	// load the products
 	c, err := l.Load()
 	if err != nil {
@@ -61,11 +62,30 @@ func (l *ProductLoaderJSON) Migrate() (err error) {
 
 	// migrate the products to the repository
 	for _, v := range c {
-		err = l.rp.Save(&v)
+		err = l.rp.Save(ctx, &v)
 		if err != nil {
+			ImportErrors.Inc("products")
 			return err
 		}
+		Imported.Inc("products")
 	}
 
 	return
 }
+
+// Dump writes the products to the JSON file, in the same format read by Load.
+func (l *ProductLoaderJSON) Dump(c []internal.Product) (err error) {
+	// serialize the products
+	cs := make([]ProductJSON, len(c))
+	for ix, v := range c {
+		cs[ix] = ProductJSON{
+			Id:          v.Id,
+			Description: v.Description,
+			Price:       v.Price,
+		}
+	}
+
+	// write the file
+	err = dump(l.filepath, cs)
+	return
+}
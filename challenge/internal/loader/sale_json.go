@@ -2,6 +2,7 @@ package loader
 
 import (
 	"app/internal"
+	"context"
 	"encoding/json"
 	"os"
 )
@@ -54,7 +55,7 @@ func (l *SaleLoaderJSON) Load() (c []internal.Sale, err error) {
 }
 
 // Migrate sales to the repository.
-func (l *SaleLoaderJSON) Migrate() (err error) {
+func (l *SaleLoaderJSON) Migrate(ctx context.Context) (err error) {
 	// load the sales
 	c, err := l.Load()
 	if err != nil {
@@ -63,11 +64,31 @@ func (l *SaleLoaderJSON) Migrate() (err error) {
 
 	// migrate the sales
 	for _, v := range c {
-		err = l.rp.Save(&v)
+		err = l.rp.Save(ctx, &v)
 		if err != nil {
+			ImportErrors.Inc("sales")
 			return err
 		}
+		Imported.Inc("sales")
 	}
 
 	return
 }
+
+// Dump writes the sales to the JSON file, in the same format read by Load.
+func (l *SaleLoaderJSON) Dump(c []internal.Sale) (err error) {
+	// serialize the sales
+	cs := make([]SaleJSON, len(c))
+	for ix, v := range c {
+		cs[ix] = SaleJSON{
+			Id:        v.Id,
+			Quantity:  v.Quantity,
+			ProductId: v.ProductId,
+			InvoiceId: v.InvoiceId,
+		}
+	}
+
+	// write the file
+	err = dump(l.filepath, cs)
+	return
+}
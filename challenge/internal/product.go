@@ -1,5 +1,7 @@
 package internal
 
+import "errors"
+
 // ProductAttributes is the struct that represents the attributes of a product.
 type ProductAttributes struct {
 	// Description is the description of the product.
@@ -14,4 +16,9 @@ type Product struct {
 	Id int
 	// ProductAttributes is the attributes of the product.
 	ProductAttributes
-}
\ No newline at end of file
+}
+
+var (
+	// ErrRepositoryProductNotFound is returned when a product is not found.
+	ErrRepositoryProductNotFound = errors.New("repository: product not found")
+)
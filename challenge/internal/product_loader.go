@@ -1,6 +1,9 @@
 package internal
 
+import "context"
+
 type ProductLoader interface {
 	Load() (c []Product, err error)
-	Migrate() (err error)
+	Migrate(ctx context.Context) (err error)
+	Dump(c []Product) (err error)
 }
@@ -1,9 +1,17 @@
 package internal
 
+import "context"
+
 // RepositoryProduct is the interface that wraps the basic methods that a product repository must have.
 type RepositoryProduct interface {
-	// FindAll returns all products saved in the database.
-	FindAll() (p []Product, err error)
+	// FindAll returns a page of the products saved in the database.
+	FindAll(ctx context.Context, q ListQuery) (p []Product, pg Page, err error)
+	// Stream calls fn with each product of the list, stopping at the first error.
+	Stream(ctx context.Context, q ListQuery, fn func(pr Product) error) (err error)
+	// FindById returns the product with the given id.
+	FindById(ctx context.Context, id int) (p Product, err error)
 	// Save saves a product into the database.
-	Save(p *Product) (err error)
+	Save(ctx context.Context, p *Product) (err error)
+	// SaveBulk saves products into the database in mode, setting their ids, and returns the error of each one, nil if it was saved.
+	SaveBulk(ctx context.Context, p []Product, mode BulkMode) (errs []error)
 }
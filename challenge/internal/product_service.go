@@ -1,9 +1,15 @@
 package internal
 
+import "context"
+
 // ServiceProduct is the interface that wraps the basic Product methods.
 type ServiceProduct interface {
-	// FindAll returns all products.
-	FindAll() (p []Product, err error)
+	// FindAll returns a page of products.
+	FindAll(ctx context.Context, q ListQuery) (p []Product, pg Page, err error)
+	// Stream calls fn with each product of the list, stopping at the first error.
+	Stream(ctx context.Context, q ListQuery, fn func(pr Product) error) (err error)
 	// Save saves a product.
-	Save(p *Product) (err error)
+	Save(ctx context.Context, p *Product) (err error)
+	// SaveBulk saves products in mode, setting their ids, and returns the error of each one, nil if it was saved.
+	SaveBulk(ctx context.Context, p []Product, mode BulkMode) (errs []error)
 }
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"app/platform/metrics"
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// QueryDuration observes the latency of the repository methods, by repository and method.
+// Stream is not observed, its duration depends on how fast the caller consumes the rows.
+var QueryDuration = metrics.NewHistogramVec("repository_query_duration_seconds", "Latency of the repository methods.", metrics.DefBuckets, "repository", "method")
+
+// queryContext returns the context of a single query, bounded by timeout.
+// A zero timeout leaves the query bounded only by ctx, e.g. by the request being cancelled.
+// Cancelling the context observes the latency of the query under repository and method,
+// and logs the query to lg if it ran out of its own timeout.
+func queryContext(ctx context.Context, timeout time.Duration, lg *slog.Logger, repository, method string) (context.Context, context.CancelFunc) {
+	start := time.Now()
+
+	var qctx context.Context
+	var cancel context.CancelFunc
+	if timeout <= 0 {
+		qctx, cancel = context.WithCancel(ctx)
+	} else {
+		qctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	return qctx, func() {
+		// the parent being done means the caller gave up, not that the query was slow
+		if errors.Is(qctx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+			lg.WarnContext(ctx, "query timed out", "repository", repository, "method", method, "timeout_ms", timeout.Milliseconds())
+		}
+		cancel()
+		QueryDuration.Observe(time.Since(start).Seconds(), repository, method)
+	}
+}
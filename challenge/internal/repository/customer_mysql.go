@@ -1,29 +1,107 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"log/slog"
 	"math"
+	"time"
 
 	"app/internal"
 )
 
 // NewCustomersMySQL creates new mysql repository for customer entity.
-func NewCustomersMySQL(db *sql.DB) *CustomersMySQL {
-	return &CustomersMySQL{db}
+func NewCustomersMySQL(db *sql.DB, timeout time.Duration, lg *slog.Logger) *CustomersMySQL {
+	return &CustomersMySQL{db, timeout, lg}
 }
 
 // CustomersMySQL is the MySQL repository implementation for customer entity.
 type CustomersMySQL struct {
 	// db is the database connection.
 	db *sql.DB
+	// timeout bounds each query, zero means no timeout.
+	timeout time.Duration
+	// lg is the logger of the queries that time out.
+	lg *slog.Logger
 }
 
-// FindAll returns all customers from the database.
-func (r *CustomersMySQL) FindAll() (c []internal.Customer, err error) {
+// listSpecCustomers is the list spec of the customers.
+var listSpecCustomers = listSpec[internal.Customer]{
+	id:      "`id`",
+	idValue: func(c internal.Customer) int { return c.Id },
+	sorts: map[string]listSort[internal.Customer]{
+		"id":         {"`id`", func(c internal.Customer) any { return c.Id }},
+		"first_name": {"`first_name`", func(c internal.Customer) any { return c.FirstName }},
+		"last_name":  {"`last_name`", func(c internal.Customer) any { return c.LastName }},
+		"condition":  {"`condition`", func(c internal.Customer) any { return c.Condition }},
+	},
+	filters: map[string]string{
+		"first_name": "`first_name` = ?",
+		"last_name":  "`last_name` = ?",
+		"condition":  "`condition` = ?",
+	},
+	values: map[string]func(v string) (any, error){
+		"condition": parseListInt,
+	},
+}
+
+// bulkSpecCustomers is the bulk spec of the customers.
+var bulkSpecCustomers = bulkSpec[internal.Customer]{
+	repository: "customers",
+	query:      "INSERT INTO customers (`first_name`, `last_name`, `condition`) VALUES (?, ?, ?)",
+	args:       func(c internal.Customer) []any { return []any{c.FirstName, c.LastName, c.Condition} },
+	setId:      func(c *internal.Customer, id int) { c.Id = id },
+}
+
+// FindAll returns a page of customers from the database.
+func (r *CustomersMySQL) FindAll(ctx context.Context, q internal.ListQuery) (c []internal.Customer, p internal.Page, err error) {
+	// bound the query
+	ctx, cancel := queryContext(ctx, r.timeout, r.lg, "customers", "FindAll")
+	defer cancel()
+
+	// build the query
+	cl, err := listSpecCustomers.build(q)
+	if err != nil {
+		return
+	}
+
 	// execute the query
-	rows, err := r.db.Query("SELECT `id`, `first_name`, `last_name`, `condition` FROM customers")
+	err = r.each(ctx, cl, func(cs internal.Customer) error {
+		c = append(c, cs)
+		return nil
+	})
 	if err != nil {
-		return nil, err
+		return nil, p, err
+	}
+
+	// page
+	c, p, err = listSpecCustomers.page(q, c)
+	return
+}
+
+// Stream calls fn with each customer of the list as it is read from the database, without holding the list in memory.
+// The limit is the total number of customers rather than the page size, zero streams all of them.
+// It is not bounded by the query timeout, only by ctx, since streaming a whole table may take longer.
+func (r *CustomersMySQL) Stream(ctx context.Context, q internal.ListQuery, fn func(cs internal.Customer) error) (err error) {
+	// build the query
+	cl, err := listSpecCustomers.build(q)
+	if err != nil {
+		return
+	}
+	cl.limit = q.Limit
+
+	// execute the query
+	err = r.each(ctx, cl, fn)
+	return
+}
+
+// each executes the list query built in cl and calls fn with each customer.
+func (r *CustomersMySQL) each(ctx context.Context, cl listClause, fn func(cs internal.Customer) error) (err error) {
+	// execute the query
+	rows, err := r.db.QueryContext(ctx, "SELECT `id`, `first_name`, `last_name`, `condition` FROM customers"+cl.sql(""), cl.args...)
+	if err != nil {
+		return
 	}
 	defer rows.Close()
 
@@ -31,15 +109,34 @@ func (r *CustomersMySQL) FindAll() (c []internal.Customer, err error) {
 	for rows.Next() {
 		var cs internal.Customer
 		// scan the row into the customer
-		err := rows.Scan(&cs.Id, &cs.FirstName, &cs.LastName, &cs.Condition)
+		err = rows.Scan(&cs.Id, &cs.FirstName, &cs.LastName, &cs.Condition)
 		if err != nil {
-			return nil, err
+			return
+		}
+		err = fn(cs)
+		if err != nil {
+			return
 		}
-		// append the customer to the slice
-		c = append(c, cs)
 	}
 	err = rows.Err()
+	return
+}
+
+// FindById returns the customer with the given id from the database.
+func (r *CustomersMySQL) FindById(ctx context.Context, id int) (c internal.Customer, err error) {
+	// bound the query
+	ctx, cancel := queryContext(ctx, r.timeout, r.lg, "customers", "FindById")
+	defer cancel()
+
+	// execute the query
+	row := r.db.QueryRowContext(ctx, "SELECT `id`, `first_name`, `last_name`, `condition` FROM customers WHERE `id` = ?", id)
+
+	// scan the row into the customer
+	err = row.Scan(&c.Id, &c.FirstName, &c.LastName, &c.Condition)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = internal.ErrRepositoryCustomerNotFound
+		}
 		return
 	}
 
@@ -47,9 +144,13 @@ func (r *CustomersMySQL) FindAll() (c []internal.Customer, err error) {
 }
 
 // Save saves the customer into the database.
-func (r *CustomersMySQL) Save(c *internal.Customer) (err error) {
+func (r *CustomersMySQL) Save(ctx context.Context, c *internal.Customer) (err error) {
+	// bound the query
+	ctx, cancel := queryContext(ctx, r.timeout, r.lg, "customers", "Save")
+	defer cancel()
+
 	// execute the query
-	res, err := r.db.Exec(
+	res, err := r.db.ExecContext(ctx,
 		"INSERT INTO customers (`first_name`, `last_name`, `condition`) VALUES (?, ?, ?)",
 		(*c).FirstName, (*c).LastName, (*c).Condition,
 	)
@@ -69,11 +170,21 @@ func (r *CustomersMySQL) Save(c *internal.Customer) (err error) {
 	return
 }
 
+// SaveBulk saves customers into the database in mode, setting their ids, and returns the error of each one, nil if it was saved.
+func (r *CustomersMySQL) SaveBulk(ctx context.Context, c []internal.Customer, mode internal.BulkMode) (errs []error) {
+	errs = bulkSpecCustomers.save(ctx, r.db, r.timeout, r.lg, c, mode)
+	return
+}
+
 // FindTotalByCondition returns the aggregated money from invoices by customer condition.
 // values rounded to the second decimal place.
-func (r *CustomersMySQL) FindTotalByCondition() (t []internal.TotalByCondition, err error) {
+func (r *CustomersMySQL) FindTotalByCondition(ctx context.Context) (t []internal.TotalByCondition, err error) {
+	// bound the query
+	ctx, cancel := queryContext(ctx, r.timeout, r.lg, "customers", "FindTotalByCondition")
+	defer cancel()
+
 	// execute the query
-	rows, err := r.db.Query("SELECT `condition`, SUM(`total`) FROM customers INNER JOIN invoices ON customers.id = invoices.customer_id GROUP BY `condition`")
+	rows, err := r.db.QueryContext(ctx, "SELECT `condition`, SUM(`total`) FROM customers INNER JOIN invoices ON customers.id = invoices.customer_id GROUP BY `condition`")
 	if err != nil {
 		return nil, err
 	}
@@ -103,9 +214,13 @@ func (r *CustomersMySQL) FindTotalByCondition() (t []internal.TotalByCondition,
 
 // FindTopActive returns the top n active customers in the database by total spent
 // total is rounded to the second decimal place.
-func (r *CustomersMySQL) FindTopActive(n int) (c []internal.CustomerAmount, err error) {
+func (r *CustomersMySQL) FindTopActive(ctx context.Context, n int) (c []internal.CustomerAmount, err error) {
+	// bound the query
+	ctx, cancel := queryContext(ctx, r.timeout, r.lg, "customers", "FindTopActive")
+	defer cancel()
+
 	// execute the query
-	rows, err := r.db.Query(`
+	rows, err := r.db.QueryContext(ctx, `
         SELECT 
             customers.first_name, 
             customers.last_name, 
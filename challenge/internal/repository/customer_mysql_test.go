@@ -3,7 +3,9 @@ package repository_test
 import (
 	"app/internal"
 	"app/internal/repository"
+	"context"
 	"database/sql"
+	"log/slog"
 	"testing"
 
 	"github.com/DATA-DOG/go-txdb"
@@ -42,7 +44,7 @@ func TestFindTotalByCondition(t *testing.T) {
 		}()
 
 		// repository
-		rp := repository.NewCustomersMySQL(db)
+		rp := repository.NewCustomersMySQL(db, 0, slog.Default())
 
 		// populate customers
 		_, err = db.Exec("INSERT INTO customers (`first_name`, `last_name`, `condition`) VALUES (?, ?, ?)", "customer", "1", 1)
@@ -72,7 +74,7 @@ func TestFindTotalByCondition(t *testing.T) {
 		}
 
 		// ACT
-		result, err := rp.FindTotalByCondition()
+		result, err := rp.FindTotalByCondition(context.Background())
 
 		// ASSERT
 		require.NoError(t, err)
@@ -98,7 +100,7 @@ func TestFindTopActiveCustomers(t *testing.T) {
 		}()
 
 		// repository
-		rp := repository.NewCustomersMySQL(db)
+		rp := repository.NewCustomersMySQL(db, 0, slog.Default())
 
 		// populate customers
 		_, err = db.Exec("INSERT INTO customers (`first_name`, `last_name`, `condition`) VALUES (?, ?, ?)", "customer", "1", 1)
@@ -131,7 +133,7 @@ func TestFindTopActiveCustomers(t *testing.T) {
 		}
 
 		// ACT
-		result, err := rp.FindTopActive(2)
+		result, err := rp.FindTopActive(context.Background(), 2)
 
 		// ASSERT
 		require.NoError(t, err)
@@ -154,7 +156,7 @@ func TestFindTopActiveCustomers(t *testing.T) {
 		}()
 
 		// repository
-		rp := repository.NewCustomersMySQL(db)
+		rp := repository.NewCustomersMySQL(db, 0, slog.Default())
 
 		// populate customers
 		_, err = db.Exec("INSERT INTO customers (`first_name`, `last_name`, `condition`) VALUES (?, ?, ?)", "customer", "1", 1)
@@ -192,7 +194,7 @@ func TestFindTopActiveCustomers(t *testing.T) {
 		}
 
 		// ACT
-		result, err := rp.FindTopActive(4)
+		result, err := rp.FindTopActive(context.Background(), 4)
 
 		// ASSERT
 		require.NoError(t, err)
@@ -0,0 +1,289 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"app/internal"
+)
+
+// NewIntegrityMySQL creates new mysql repository for the integrity checks.
+func NewIntegrityMySQL(db *sql.DB, timeout time.Duration, lg *slog.Logger) *IntegrityMySQL {
+	return &IntegrityMySQL{db, timeout, lg}
+}
+
+// IntegrityMySQL is the MySQL repository implementation for the integrity checks.
+// Nullable columns are coalesced so that rows with missing values are reported instead of failing the scan.
+type IntegrityMySQL struct {
+	// db is the database connection.
+	db *sql.DB
+	// timeout bounds each query, zero means no timeout.
+	timeout time.Duration
+	// lg is the logger of the queries that time out.
+	lg *slog.Logger
+}
+
+// FindOrphanSales returns the sales whose invoice or product does not exist.
+func (r *IntegrityMySQL) FindOrphanSales(ctx context.Context) (s []internal.Sale, err error) {
+	s, err = r.findSales(ctx, "FindOrphanSales", `
+        SELECT
+            sales.id,
+            COALESCE(sales.quantity, 0),
+            COALESCE(sales.product_id, 0),
+            COALESCE(sales.invoice_id, 0)
+        FROM
+            sales
+        LEFT JOIN
+            invoices ON sales.invoice_id = invoices.id
+        LEFT JOIN
+            products ON sales.product_id = products.id
+        WHERE
+            invoices.id IS NULL OR products.id IS NULL
+        ORDER BY
+            sales.id`,
+	)
+	return
+}
+
+// FindInvoicesWithoutSales returns the invoices that have no sales.
+func (r *IntegrityMySQL) FindInvoicesWithoutSales(ctx context.Context) (i []internal.Invoice, err error) {
+	// bound the query
+	ctx, cancel := queryContext(ctx, r.timeout, r.lg, "integrity", "FindInvoicesWithoutSales")
+	defer cancel()
+
+	// execute the query
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT
+            invoices.id,
+            COALESCE(invoices.datetime, ''),
+            COALESCE(invoices.total, 0),
+            COALESCE(invoices.customer_id, 0)
+        FROM
+            invoices
+        LEFT JOIN
+            sales ON invoices.id = sales.invoice_id
+        WHERE
+            sales.id IS NULL
+        ORDER BY
+            invoices.id`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// iterate over the rows
+	for rows.Next() {
+		var iv internal.Invoice
+		// scan the row into the invoice
+		err := rows.Scan(&iv.Id, &iv.Datetime, &iv.Total, &iv.CustomerId)
+		if err != nil {
+			return nil, err
+		}
+		// append the invoice to the slice
+		i = append(i, iv)
+	}
+	err = rows.Err()
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+// FindCustomersWithoutInvoices returns the customers that have no invoices.
+func (r *IntegrityMySQL) FindCustomersWithoutInvoices(ctx context.Context) (c []internal.Customer, err error) {
+	c, err = r.findCustomers(ctx, "FindCustomersWithoutInvoices", `
+        SELECT
+            customers.id,
+            COALESCE(customers.first_name, ''),
+            COALESCE(customers.last_name, ''),
+            COALESCE(customers.condition, 0)
+        FROM
+            customers
+        LEFT JOIN
+            invoices ON customers.id = invoices.customer_id
+        WHERE
+            invoices.id IS NULL
+        ORDER BY
+            customers.id`,
+	)
+	return
+}
+
+// FindProductsNeverSold returns the products that have no sales.
+func (r *IntegrityMySQL) FindProductsNeverSold(ctx context.Context) (p []internal.Product, err error) {
+	p, err = r.findProducts(ctx, "FindProductsNeverSold", `
+        SELECT
+            products.id,
+            COALESCE(products.description, ''),
+            COALESCE(products.price, 0)
+        FROM
+            products
+        LEFT JOIN
+            sales ON products.id = sales.product_id
+        WHERE
+            sales.id IS NULL
+        ORDER BY
+            products.id`,
+	)
+	return
+}
+
+// FindDuplicateCustomers returns the customers that share first and last name with another customer.
+func (r *IntegrityMySQL) FindDuplicateCustomers(ctx context.Context) (c []internal.Customer, err error) {
+	c, err = r.findCustomers(ctx, "FindDuplicateCustomers", `
+        SELECT
+            customers.id,
+            COALESCE(customers.first_name, ''),
+            COALESCE(customers.last_name, ''),
+            COALESCE(customers.condition, 0)
+        FROM
+            customers
+        INNER JOIN (
+            SELECT first_name, last_name
+            FROM customers
+            GROUP BY first_name, last_name
+            HAVING COUNT(*) > 1
+        ) AS duplicates ON customers.first_name = duplicates.first_name AND customers.last_name = duplicates.last_name
+        ORDER BY
+            customers.id`,
+	)
+	return
+}
+
+// FindSalesNonPositiveQuantity returns the sales with a zero or negative quantity.
+func (r *IntegrityMySQL) FindSalesNonPositiveQuantity(ctx context.Context) (s []internal.Sale, err error) {
+	s, err = r.findSales(ctx, "FindSalesNonPositiveQuantity", `
+        SELECT
+            sales.id,
+            COALESCE(sales.quantity, 0),
+            COALESCE(sales.product_id, 0),
+            COALESCE(sales.invoice_id, 0)
+        FROM
+            sales
+        WHERE
+            COALESCE(sales.quantity, 0) <= 0
+        ORDER BY
+            sales.id`,
+	)
+	return
+}
+
+// FindProductsNonPositivePrice returns the products with a zero or negative price.
+func (r *IntegrityMySQL) FindProductsNonPositivePrice(ctx context.Context) (p []internal.Product, err error) {
+	p, err = r.findProducts(ctx, "FindProductsNonPositivePrice", `
+        SELECT
+            products.id,
+            COALESCE(products.description, ''),
+            COALESCE(products.price, 0)
+        FROM
+            products
+        WHERE
+            COALESCE(products.price, 0) <= 0
+        ORDER BY
+            products.id`,
+	)
+	return
+}
+
+// findCustomers executes a query that selects id, first_name, last_name and condition from customers,
+// method is the name of the caller its latency is observed under.
+func (r *IntegrityMySQL) findCustomers(ctx context.Context, method, query string) (c []internal.Customer, err error) {
+	// bound the query
+	ctx, cancel := queryContext(ctx, r.timeout, r.lg, "integrity", method)
+	defer cancel()
+
+	// execute the query
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// iterate over the rows
+	for rows.Next() {
+		var cs internal.Customer
+		// scan the row into the customer
+		err := rows.Scan(&cs.Id, &cs.FirstName, &cs.LastName, &cs.Condition)
+		if err != nil {
+			return nil, err
+		}
+		// append the customer to the slice
+		c = append(c, cs)
+	}
+	err = rows.Err()
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+// findProducts executes a query that selects id, description and price from products,
+// method is the name of the caller its latency is observed under.
+func (r *IntegrityMySQL) findProducts(ctx context.Context, method, query string) (p []internal.Product, err error) {
+	// bound the query
+	ctx, cancel := queryContext(ctx, r.timeout, r.lg, "integrity", method)
+	defer cancel()
+
+	// execute the query
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// iterate over the rows
+	for rows.Next() {
+		var pr internal.Product
+		// scan the row into the product
+		err := rows.Scan(&pr.Id, &pr.Description, &pr.Price)
+		if err != nil {
+			return nil, err
+		}
+		// append the product to the slice
+		p = append(p, pr)
+	}
+	err = rows.Err()
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+// findSales executes a query that selects id, quantity, product_id and invoice_id from sales,
+// method is the name of the caller its latency is observed under.
+func (r *IntegrityMySQL) findSales(ctx context.Context, method, query string) (s []internal.Sale, err error) {
+	// bound the query
+	ctx, cancel := queryContext(ctx, r.timeout, r.lg, "integrity", method)
+	defer cancel()
+
+	// execute the query
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// iterate over the rows
+	for rows.Next() {
+		var sa internal.Sale
+		// scan the row into the sale
+		err := rows.Scan(&sa.Id, &sa.Quantity, &sa.ProductId, &sa.InvoiceId)
+		if err != nil {
+			return nil, err
+		}
+		// append the sale to the slice
+		s = append(s, sa)
+	}
+	err = rows.Err()
+	if err != nil {
+		return
+	}
+
+	return
+}
@@ -0,0 +1,141 @@
+package repository_test
+
+import (
+	"app/internal"
+	"app/internal/repository"
+	"context"
+	"database/sql"
+	"log/slog"
+	"testing"
+
+	"github.com/DATA-DOG/go-txdb"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	cfg := mysql.Config{
+		User:      "root",
+		Passwd:    "",
+		Net:       "tcp",
+		Addr:      "localhost:3306",
+		DBName:    "fantasy_products_test",
+		ParseTime: true,
+	}
+
+	txdb.Register("txdb_integrity_repository", "mysql", cfg.FormatDSN())
+}
+
+func TestFindOrphanSales(t *testing.T) {
+	t.Run("should return the sales without invoice or product", func(t *testing.T) {
+		// ARRANGE
+		db, err := sql.Open("txdb_integrity_repository", "fantasy_products_test")
+		require.NoError(t, err)
+		defer db.Close()
+
+		// reset database on close
+		defer func() error {
+			_, err = db.Exec("DELETE FROM sales")
+			_, err = db.Exec("ALTER TABLE sales AUTO_INCREMENT = 1")
+			_, err = db.Exec("DELETE FROM products")
+			_, err = db.Exec("ALTER TABLE products AUTO_INCREMENT = 1")
+			_, err = db.Exec("DELETE FROM invoices")
+			_, err = db.Exec("ALTER TABLE invoices AUTO_INCREMENT = 1")
+			_, err = db.Exec("DELETE FROM customers")
+			_, err = db.Exec("ALTER TABLE customers AUTO_INCREMENT = 1")
+			require.NoError(t, err)
+			return err
+		}()
+
+		// repository
+		rp := repository.NewIntegrityMySQL(db, 0, slog.Default())
+
+		// populate customers, invoices and products
+		_, err = db.Exec("INSERT INTO customers (`first_name`, `last_name`, `condition`) VALUES (?, ?, ?)", "customer", "1", 1)
+		require.NoError(t, err)
+		_, err = db.Exec("INSERT INTO invoices (`customer_id`, `datetime`, `total`) VALUES (?, ?, ?)", 1, "2021-01-01 00:00:00", 100)
+		require.NoError(t, err)
+		_, err = db.Exec("INSERT INTO products (`description`, `price`) VALUES (?, ?)", "A", 100)
+		require.NoError(t, err)
+
+		// populate sales
+		_, err = db.Exec("INSERT INTO sales (`quantity`, `product_id`, `invoice_id`) VALUES (?, ?, ?)", 1, 1, 1)
+		require.NoError(t, err)
+		_, err = db.Exec("INSERT INTO sales (`quantity`, `product_id`) VALUES (?, ?)", 2, 1)
+		require.NoError(t, err)
+
+		// expected output
+		expected := []internal.Sale{
+			{
+				Id: 2,
+				SaleAttributes: internal.SaleAttributes{
+					Quantity:  2,
+					ProductId: 1,
+					InvoiceId: 0,
+				},
+			},
+		}
+
+		// ACT
+		result, err := rp.FindOrphanSales(context.Background())
+
+		// ASSERT
+		require.NoError(t, err)
+		require.Equal(t, expected, result)
+	})
+}
+
+func TestFindDuplicateCustomers(t *testing.T) {
+	t.Run("should return the customers sharing first and last name", func(t *testing.T) {
+		// ARRANGE
+		db, err := sql.Open("txdb_integrity_repository", "fantasy_products_test")
+		require.NoError(t, err)
+		defer db.Close()
+
+		// reset database on close
+		defer func() error {
+			_, err = db.Exec("DELETE FROM customers")
+			_, err = db.Exec("ALTER TABLE customers AUTO_INCREMENT = 1")
+			require.NoError(t, err)
+			return err
+		}()
+
+		// repository
+		rp := repository.NewIntegrityMySQL(db, 0, slog.Default())
+
+		// populate customers
+		_, err = db.Exec("INSERT INTO customers (`first_name`, `last_name`, `condition`) VALUES (?, ?, ?)", "John", "Doe", 1)
+		require.NoError(t, err)
+		_, err = db.Exec("INSERT INTO customers (`first_name`, `last_name`, `condition`) VALUES (?, ?, ?)", "Jane", "Doe", 1)
+		require.NoError(t, err)
+		_, err = db.Exec("INSERT INTO customers (`first_name`, `last_name`, `condition`) VALUES (?, ?, ?)", "John", "Doe", 0)
+		require.NoError(t, err)
+
+		// expected output
+		expected := []internal.Customer{
+			{
+				Id: 1,
+				CustomerAttributes: internal.CustomerAttributes{
+					FirstName: "John",
+					LastName:  "Doe",
+					Condition: 1,
+				},
+			},
+			{
+				Id: 3,
+				CustomerAttributes: internal.CustomerAttributes{
+					FirstName: "John",
+					LastName:  "Doe",
+					Condition: 0,
+				},
+			},
+		}
+
+		// ACT
+		result, err := rp.FindDuplicateCustomers(context.Background())
+
+		// ASSERT
+		require.NoError(t, err)
+		require.Equal(t, expected, result)
+	})
+}
@@ -1,28 +1,111 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"log/slog"
+	"math"
+	"time"
 
 	"app/internal"
 )
 
 // NewInvoicesMySQL creates new mysql repository for invoice entity.
-func NewInvoicesMySQL(db *sql.DB) *InvoicesMySQL {
-	return &InvoicesMySQL{db}
+func NewInvoicesMySQL(db *sql.DB, timeout time.Duration, lg *slog.Logger) *InvoicesMySQL {
+	return &InvoicesMySQL{db, timeout, lg}
 }
 
 // InvoicesMySQL is the MySQL repository implementation for invoice entity.
 type InvoicesMySQL struct {
 	// db is the database connection.
 	db *sql.DB
+	// timeout bounds each query, zero means no timeout.
+	timeout time.Duration
+	// lg is the logger of the queries that time out.
+	lg *slog.Logger
 }
 
-// FindAll returns all invoices from the database.
-func (r *InvoicesMySQL) FindAll() (i []internal.Invoice, err error) {
+// listSpecInvoices is the list spec of the invoices.
+var listSpecInvoices = listSpec[internal.Invoice]{
+	id:      "`id`",
+	idValue: func(i internal.Invoice) int { return i.Id },
+	sorts: map[string]listSort[internal.Invoice]{
+		"id":       {"`id`", func(i internal.Invoice) any { return i.Id }},
+		"datetime": {"`datetime`", func(i internal.Invoice) any { return i.Datetime }},
+		"total":    {"`total`", func(i internal.Invoice) any { return i.Total }},
+	},
+	filters: map[string]string{
+		"customer_id":   "`customer_id` = ?",
+		"datetime_from": "`datetime` >= ?",
+		"datetime_to":   "`datetime` < DATE_ADD(?, INTERVAL 1 DAY)",
+		"total_min":     "`total` >= ?",
+		"total_max":     "`total` <= ?",
+	},
+	values: map[string]func(v string) (any, error){
+		"customer_id":   parseListInt,
+		"datetime_from": parseListDate,
+		"datetime_to":   parseListDate,
+		"total_min":     parseListFloat,
+		"total_max":     parseListFloat,
+	},
+}
+
+// bulkSpecInvoices is the bulk spec of the invoices.
+var bulkSpecInvoices = bulkSpec[internal.Invoice]{
+	repository: "invoices",
+	query:      "INSERT INTO invoices (`datetime`, `total`, `customer_id`) VALUES (?, ?, ?)",
+	args:       func(i internal.Invoice) []any { return []any{i.Datetime, i.Total, i.CustomerId} },
+	setId:      func(i *internal.Invoice, id int) { i.Id = id },
+}
+
+// FindAll returns a page of invoices from the database.
+func (r *InvoicesMySQL) FindAll(ctx context.Context, q internal.ListQuery) (i []internal.Invoice, p internal.Page, err error) {
+	// bound the query
+	ctx, cancel := queryContext(ctx, r.timeout, r.lg, "invoices", "FindAll")
+	defer cancel()
+
+	// build the query
+	cl, err := listSpecInvoices.build(q)
+	if err != nil {
+		return
+	}
+
 	// execute the query
-	rows, err := r.db.Query("SELECT `id`, `datetime`, `total`, `customer_id` FROM invoices")
+	err = r.each(ctx, cl, func(iv internal.Invoice) error {
+		i = append(i, iv)
+		return nil
+	})
 	if err != nil {
-		return nil, err
+		return nil, p, err
+	}
+
+	// page
+	i, p, err = listSpecInvoices.page(q, i)
+	return
+}
+
+// Stream calls fn with each invoice of the list as it is read from the database, without holding the list in memory.
+// The limit is the total number of invoices rather than the page size, zero streams all of them.
+// It is not bounded by the query timeout, only by ctx, since streaming a whole table may take longer.
+func (r *InvoicesMySQL) Stream(ctx context.Context, q internal.ListQuery, fn func(iv internal.Invoice) error) (err error) {
+	// build the query
+	cl, err := listSpecInvoices.build(q)
+	if err != nil {
+		return
+	}
+	cl.limit = q.Limit
+
+	// execute the query
+	err = r.each(ctx, cl, fn)
+	return
+}
+
+// each executes the list query built in cl and calls fn with each invoice.
+func (r *InvoicesMySQL) each(ctx context.Context, cl listClause, fn func(iv internal.Invoice) error) (err error) {
+	// execute the query
+	rows, err := r.db.QueryContext(ctx, "SELECT `id`, `datetime`, `total`, `customer_id` FROM invoices"+cl.sql(""), cl.args...)
+	if err != nil {
+		return
 	}
 	defer rows.Close()
 
@@ -30,12 +113,205 @@ func (r *InvoicesMySQL) FindAll() (i []internal.Invoice, err error) {
 	for rows.Next() {
 		var iv internal.Invoice
 		// scan the row into the invoice
-		err := rows.Scan(&iv.Id, &iv.Datetime, &iv.Total, &iv.CustomerId)
+		err = rows.Scan(&iv.Id, &iv.Datetime, &iv.Total, &iv.CustomerId)
+		if err != nil {
+			return
+		}
+		err = fn(iv)
+		if err != nil {
+			return
+		}
+	}
+	err = rows.Err()
+	return
+}
+
+// queryInvoiceDetail returns the query selecting the invoices joined with their customer and, if lines, their sales with the product sold.
+// invoices is the invoices table, or a derived table of a single page of invoices.
+// Without lines the sales and products are not joined and their columns are NULL, so the rows scan as invoices without lines.
+// Rows must be ordered by invoice so that the lines of an invoice are consecutive.
+func queryInvoiceDetail(invoices string, lines bool) string {
+	columns, joins := "NULL, NULL, NULL, NULL, NULL", ""
+	if lines {
+		columns = "sales.id, sales.quantity, products.id, products.description, products.price"
+		joins = `
+    LEFT JOIN
+        sales ON invoices.id = sales.invoice_id
+    LEFT JOIN
+        products ON sales.product_id = products.id`
+	}
+	return `
+    SELECT
+        invoices.id,
+        invoices.datetime,
+        invoices.total,
+        invoices.customer_id,
+        customers.id,
+        customers.first_name,
+        customers.last_name,
+        customers.condition,
+        ` + columns + `
+    FROM
+        ` + invoices + ` AS invoices
+    LEFT JOIN
+        customers ON invoices.customer_id = customers.id` + joins
+}
+
+// FindById returns the invoice with its customer and lines from the database.
+func (r *InvoicesMySQL) FindById(ctx context.Context, id int) (i internal.InvoiceDetail, err error) {
+	// bound the query
+	ctx, cancel := queryContext(ctx, r.timeout, r.lg, "invoices", "FindById")
+	defer cancel()
+
+	// execute the query
+	rows, err := r.db.QueryContext(ctx, queryInvoiceDetail("invoices", true)+" WHERE invoices.id = ? ORDER BY sales.id", id)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	// scan the rows into the invoice
+	iv, err := scanInvoiceDetails(rows)
+	if err != nil {
+		return
+	}
+	if len(iv) == 0 {
+		err = internal.ErrRepositoryInvoiceNotFound
+		return
+	}
+
+	i = iv[0]
+	return
+}
+
+// FindAllDetail returns a page of invoices with their customer and, if lines, their lines from the database.
+// The page of invoices is selected in a derived table, so the limit applies to invoices rather than lines.
+func (r *InvoicesMySQL) FindAllDetail(ctx context.Context, q internal.ListQuery, lines bool) (i []internal.InvoiceDetail, p internal.Page, err error) {
+	// bound the query
+	ctx, cancel := queryContext(ctx, r.timeout, r.lg, "invoices", "FindAllDetail")
+	defer cancel()
+
+	// build the query
+	cl, err := listSpecInvoices.build(q)
+	if err != nil {
+		return
+	}
+	invoices := "(SELECT `id`, `datetime`, `total`, `customer_id` FROM invoices" + cl.sql("") + ")"
+	order := cl
+	order.where, order.limit = "", 0
+	query := queryInvoiceDetail(invoices, lines) + order.sql("invoices")
+	if lines {
+		query += ", sales.id"
+	}
+
+	// execute the query
+	rows, err := r.db.QueryContext(ctx, query, cl.args...)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	// scan the rows into the invoices
+	i, err = scanInvoiceDetails(rows)
+	if err != nil {
+		return
+	}
+
+	// page
+	iv := make([]internal.Invoice, len(i))
+	for ix, v := range i {
+		iv[ix] = v.Invoice
+	}
+	_, p, err = listSpecInvoices.page(q, iv)
+	if err != nil {
+		return
+	}
+	if q.Limit > 0 && len(i) > q.Limit {
+		i = i[:q.Limit]
+	}
+	return
+}
+
+// FindByCustomerId returns the invoices of a customer with their lines from the database, ordered by date.
+// from and to are dates (YYYY-MM-DD), both optional and inclusive.
+func (r *InvoicesMySQL) FindByCustomerId(ctx context.Context, customerId int, from, to string) (i []internal.InvoiceDetail, err error) {
+	// bound the query
+	ctx, cancel := queryContext(ctx, r.timeout, r.lg, "invoices", "FindByCustomerId")
+	defer cancel()
+
+	// build the query
+	query := queryInvoiceDetail("invoices", true) + " WHERE invoices.customer_id = ?"
+	args := []any{customerId}
+	if from != "" {
+		query += " AND invoices.datetime >= ?"
+		args = append(args, from)
+	}
+	if to != "" {
+		query += " AND invoices.datetime < DATE_ADD(?, INTERVAL 1 DAY)"
+		args = append(args, to)
+	}
+	query += " ORDER BY invoices.datetime, invoices.id, sales.id"
+
+	// execute the query
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	// scan the rows into the invoices
+	i, err = scanInvoiceDetails(rows)
+	return
+}
+
+// scanInvoiceDetails scans the rows of queryInvoiceDetail, grouping the lines by invoice.
+// unit price and line total are rounded to the second decimal place.
+func scanInvoiceDetails(rows *sql.Rows) (i []internal.InvoiceDetail, err error) {
+	// iterate over the rows
+	for rows.Next() {
+		var iv internal.InvoiceDetail
+		var customerId, customerCondition, saleId, saleQuantity, productId sql.NullInt64
+		var customerFirstName, customerLastName, productDescription sql.NullString
+		var productPrice sql.NullFloat64
+		// scan the row into the invoice, its customer and its line
+		err = rows.Scan(
+			&iv.Id, &iv.Datetime, &iv.Total, &iv.CustomerId,
+			&customerId, &customerFirstName, &customerLastName, &customerCondition,
+			&saleId, &saleQuantity,
+			&productId, &productDescription, &productPrice,
+		)
 		if err != nil {
 			return nil, err
 		}
-		// append the invoice to the slice
-		i = append(i, iv)
+
+		// append the invoice to the slice if it is the first row of the invoice
+		if len(i) == 0 || i[len(i)-1].Id != iv.Id {
+			if customerId.Valid {
+				iv.Customer = internal.Customer{
+					Id: int(customerId.Int64),
+					CustomerAttributes: internal.CustomerAttributes{
+						FirstName: customerFirstName.String,
+						LastName:  customerLastName.String,
+						Condition: int(customerCondition.Int64),
+					},
+				}
+			}
+			i = append(i, iv)
+		}
+
+		// append the line to the invoice, invoices without sales have no lines
+		if !saleId.Valid {
+			continue
+		}
+		ln := internal.InvoiceLine{
+			SaleId:             int(saleId.Int64),
+			ProductId:          int(productId.Int64),
+			ProductDescription: productDescription.String,
+			Quantity:           int(saleQuantity.Int64),
+			UnitPrice:          math.Round(productPrice.Float64*100) / 100,
+		}
+		ln.LineTotal = math.Round(float64(ln.Quantity)*ln.UnitPrice*100) / 100
+		i[len(i)-1].Lines = append(i[len(i)-1].Lines, ln)
 	}
 	err = rows.Err()
 	if err != nil {
@@ -46,14 +322,18 @@ func (r *InvoicesMySQL) FindAll() (i []internal.Invoice, err error) {
 }
 
 // Save saves the invoice into the database.
-func (r *InvoicesMySQL) Save(i *internal.Invoice) (err error) {
+func (r *InvoicesMySQL) Save(ctx context.Context, i *internal.Invoice) (err error) {
+	// bound the query
+	ctx, cancel := queryContext(ctx, r.timeout, r.lg, "invoices", "Save")
+	defer cancel()
+
 	// execute the query
-	res, err := r.db.Exec(
+	res, err := r.db.ExecContext(ctx,
 		"INSERT INTO invoices (`datetime`, `total`, `customer_id`) VALUES (?, ?, ?)",
 		(*i).Datetime, (*i).Total, (*i).CustomerId,
 	)
 	if err != nil {
-		return err
+		return insertError(err)
 	}
 
 	// get the last inserted id
@@ -68,10 +348,20 @@ func (r *InvoicesMySQL) Save(i *internal.Invoice) (err error) {
 	return
 }
 
+// SaveBulk saves invoices into the database in mode, setting their ids, and returns the error of each one, nil if it was saved.
+func (r *InvoicesMySQL) SaveBulk(ctx context.Context, i []internal.Invoice, mode internal.BulkMode) (errs []error) {
+	errs = bulkSpecInvoices.save(ctx, r.db, r.timeout, r.lg, i, mode)
+	return
+}
+
 // UpdateTotal updates the total of all invoices in the database.
-func (r *InvoicesMySQL) UpdateTotal() (totalUpdated int, err error) {
+func (r *InvoicesMySQL) UpdateTotal(ctx context.Context) (totalUpdated int, err error) {
+	// bound the query
+	ctx, cancel := queryContext(ctx, r.timeout, r.lg, "invoices", "UpdateTotal")
+	defer cancel()
+
 	// execute the query
-	result, err := r.db.Exec(
+	result, err := r.db.ExecContext(ctx,
 		"UPDATE invoices i SET total = (SELECT SUM(s.quantity * p.price) FROM sales s JOIN products p ON s.product_id = p.id WHERE s.invoice_id = i.id)",
 	)
 
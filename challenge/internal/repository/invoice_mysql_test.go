@@ -0,0 +1,238 @@
+package repository_test
+
+import (
+	"app/internal"
+	"app/internal/repository"
+	"context"
+	"database/sql"
+	"log/slog"
+	"testing"
+
+	"github.com/DATA-DOG/go-txdb"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	cfg := mysql.Config{
+		User:   "root",
+		Passwd: "",
+		Net:    "tcp",
+		Addr:   "localhost:3306",
+		DBName: "fantasy_products_test",
+	}
+
+	txdb.Register("txdb_invoice_repository", "mysql", cfg.FormatDSN())
+}
+
+func TestFindInvoiceById(t *testing.T) {
+	t.Run("should return the invoice with its customer and lines", func(t *testing.T) {
+		// ARRANGE
+		db, err := sql.Open("txdb_invoice_repository", "fantasy_products_test")
+		require.NoError(t, err)
+		defer db.Close()
+
+		// reset database on close
+		defer func() error {
+			_, err = db.Exec("DELETE FROM sales")
+			_, err = db.Exec("ALTER TABLE sales AUTO_INCREMENT = 1")
+			_, err = db.Exec("DELETE FROM products")
+			_, err = db.Exec("ALTER TABLE products AUTO_INCREMENT = 1")
+			_, err = db.Exec("DELETE FROM invoices")
+			_, err = db.Exec("ALTER TABLE invoices AUTO_INCREMENT = 1")
+			_, err = db.Exec("DELETE FROM customers")
+			_, err = db.Exec("ALTER TABLE customers AUTO_INCREMENT = 1")
+			require.NoError(t, err)
+			return err
+		}()
+
+		// repository
+		rp := repository.NewInvoicesMySQL(db, 0, slog.Default())
+
+		// populate customers, invoices and products
+		_, err = db.Exec("INSERT INTO customers (`first_name`, `last_name`, `condition`) VALUES (?, ?, ?)", "customer", "1", 1)
+		require.NoError(t, err)
+		_, err = db.Exec("INSERT INTO invoices (`customer_id`, `datetime`, `total`) VALUES (?, ?, ?)", 1, "2021-01-01 00:00:00", 250)
+		require.NoError(t, err)
+		_, err = db.Exec("INSERT INTO products (`description`, `price`) VALUES (?, ?)", "A", 100)
+		require.NoError(t, err)
+		_, err = db.Exec("INSERT INTO products (`description`, `price`) VALUES (?, ?)", "B", 50)
+		require.NoError(t, err)
+
+		// populate sales
+		_, err = db.Exec("INSERT INTO sales (`quantity`, `product_id`, `invoice_id`) VALUES (?, ?, ?)", 2, 1, 1)
+		require.NoError(t, err)
+		_, err = db.Exec("INSERT INTO sales (`quantity`, `product_id`, `invoice_id`) VALUES (?, ?, ?)", 1, 2, 1)
+		require.NoError(t, err)
+
+		// expected output
+		expected := internal.InvoiceDetail{
+			Invoice: internal.Invoice{
+				Id: 1,
+				InvoiceAttributes: internal.InvoiceAttributes{
+					Datetime:   "2021-01-01 00:00:00",
+					Total:      250,
+					CustomerId: 1,
+				},
+			},
+			Customer: internal.Customer{
+				Id: 1,
+				CustomerAttributes: internal.CustomerAttributes{
+					FirstName: "customer",
+					LastName:  "1",
+					Condition: 1,
+				},
+			},
+			Lines: []internal.InvoiceLine{
+				{SaleId: 1, ProductId: 1, ProductDescription: "A", Quantity: 2, UnitPrice: 100, LineTotal: 200},
+				{SaleId: 2, ProductId: 2, ProductDescription: "B", Quantity: 1, UnitPrice: 50, LineTotal: 50},
+			},
+		}
+
+		// ACT
+		result, err := rp.FindById(context.Background(), 1)
+
+		// ASSERT
+		require.NoError(t, err)
+		require.Equal(t, expected, result)
+	})
+
+	t.Run("should return not found if the invoice does not exist", func(t *testing.T) {
+		// ARRANGE
+		db, err := sql.Open("txdb_invoice_repository", "fantasy_products_test")
+		require.NoError(t, err)
+		defer db.Close()
+
+		// repository
+		rp := repository.NewInvoicesMySQL(db, 0, slog.Default())
+
+		// ACT
+		_, err = rp.FindById(context.Background(), 1)
+
+		// ASSERT
+		require.ErrorIs(t, err, internal.ErrRepositoryInvoiceNotFound)
+	})
+}
+
+func TestFindInvoicesByCustomerId(t *testing.T) {
+	t.Run("should return the invoices of the customer dated between from and to, both inclusive", func(t *testing.T) {
+		// ARRANGE
+		db, err := sql.Open("txdb_invoice_repository", "fantasy_products_test")
+		require.NoError(t, err)
+		defer db.Close()
+
+		// repository
+		rp := repository.NewInvoicesMySQL(db, 0, slog.Default())
+
+		// populate customers
+		_, err = db.Exec("INSERT INTO customers (`id`, `first_name`, `last_name`, `condition`) VALUES (?, ?, ?, ?)", 1, "customer", "1", 1)
+		require.NoError(t, err)
+		_, err = db.Exec("INSERT INTO customers (`id`, `first_name`, `last_name`, `condition`) VALUES (?, ?, ?, ?)", 2, "customer", "2", 1)
+		require.NoError(t, err)
+
+		// populate invoices, around the bounds of january
+		_, err = db.Exec("INSERT INTO invoices (`id`, `customer_id`, `datetime`, `total`) VALUES (?, ?, ?, ?)", 1, 1, "2020-12-31 23:59:59", 10)
+		require.NoError(t, err)
+		_, err = db.Exec("INSERT INTO invoices (`id`, `customer_id`, `datetime`, `total`) VALUES (?, ?, ?, ?)", 2, 1, "2021-01-31 23:59:59", 20)
+		require.NoError(t, err)
+		_, err = db.Exec("INSERT INTO invoices (`id`, `customer_id`, `datetime`, `total`) VALUES (?, ?, ?, ?)", 3, 1, "2021-01-01 00:00:00", 30)
+		require.NoError(t, err)
+		_, err = db.Exec("INSERT INTO invoices (`id`, `customer_id`, `datetime`, `total`) VALUES (?, ?, ?, ?)", 4, 1, "2021-02-01 00:00:00", 40)
+		require.NoError(t, err)
+		_, err = db.Exec("INSERT INTO invoices (`id`, `customer_id`, `datetime`, `total`) VALUES (?, ?, ?, ?)", 5, 2, "2021-01-15 00:00:00", 50)
+		require.NoError(t, err)
+
+		// ACT
+		i, err := rp.FindByCustomerId(context.Background(), 1, "2021-01-01", "2021-01-31")
+
+		// ASSERT
+		require.NoError(t, err)
+		require.Len(t, i, 2)
+		require.Equal(t, 3, i[0].Id)
+		require.Equal(t, "2021-01-01 00:00:00", i[0].Datetime)
+		require.Equal(t, 2, i[1].Id)
+		require.Equal(t, "2021-01-31 23:59:59", i[1].Datetime)
+		require.Empty(t, i[1].Lines)
+	})
+
+	t.Run("should return every invoice of the customer without bounds", func(t *testing.T) {
+		// ARRANGE
+		db, err := sql.Open("txdb_invoice_repository", "fantasy_products_test")
+		require.NoError(t, err)
+		defer db.Close()
+
+		// repository
+		rp := repository.NewInvoicesMySQL(db, 0, slog.Default())
+
+		// populate customers and invoices
+		_, err = db.Exec("INSERT INTO customers (`id`, `first_name`, `last_name`, `condition`) VALUES (?, ?, ?, ?)", 1, "customer", "1", 1)
+		require.NoError(t, err)
+		_, err = db.Exec("INSERT INTO invoices (`id`, `customer_id`, `datetime`, `total`) VALUES (?, ?, ?, ?)", 1, 1, "2020-12-31 23:59:59", 10)
+		require.NoError(t, err)
+		_, err = db.Exec("INSERT INTO invoices (`id`, `customer_id`, `datetime`, `total`) VALUES (?, ?, ?, ?)", 2, 1, "2021-02-01 00:00:00", 40)
+		require.NoError(t, err)
+
+		// ACT
+		i, err := rp.FindByCustomerId(context.Background(), 1, "", "")
+
+		// ASSERT
+		require.NoError(t, err)
+		require.Len(t, i, 2)
+	})
+}
+
+func TestFindAllInvoicesDetail(t *testing.T) {
+	// populate inserts a customer with an invoice of two sales
+	populate := func(t *testing.T, db *sql.DB) {
+		_, err := db.Exec("INSERT INTO customers (`id`, `first_name`, `last_name`, `condition`) VALUES (?, ?, ?, ?)", 1, "customer", "1", 1)
+		require.NoError(t, err)
+		_, err = db.Exec("INSERT INTO invoices (`id`, `customer_id`, `datetime`, `total`) VALUES (?, ?, ?, ?)", 1, 1, "2021-01-01 00:00:00", 250)
+		require.NoError(t, err)
+		_, err = db.Exec("INSERT INTO products (`id`, `description`, `price`) VALUES (?, ?, ?)", 1, "A", 100)
+		require.NoError(t, err)
+		_, err = db.Exec("INSERT INTO sales (`id`, `quantity`, `product_id`, `invoice_id`) VALUES (?, ?, ?, ?)", 1, 2, 1, 1)
+		require.NoError(t, err)
+		_, err = db.Exec("INSERT INTO sales (`id`, `quantity`, `product_id`, `invoice_id`) VALUES (?, ?, ?, ?)", 2, 1, 1, 1)
+		require.NoError(t, err)
+	}
+
+	t.Run("should return the invoices with their customer and lines", func(t *testing.T) {
+		// ARRANGE
+		db, err := sql.Open("txdb_invoice_repository", "fantasy_products_test")
+		require.NoError(t, err)
+		defer db.Close()
+		populate(t, db)
+
+		// repository
+		rp := repository.NewInvoicesMySQL(db, 0, slog.Default())
+
+		// ACT
+		i, _, err := rp.FindAllDetail(context.Background(), internal.ListQuery{Limit: 10}, true)
+
+		// ASSERT
+		require.NoError(t, err)
+		require.Len(t, i, 1)
+		require.Equal(t, "customer", i[0].Customer.FirstName)
+		require.Len(t, i[0].Lines, 2)
+	})
+
+	t.Run("should return the invoices with their customer only, without lines", func(t *testing.T) {
+		// ARRANGE
+		db, err := sql.Open("txdb_invoice_repository", "fantasy_products_test")
+		require.NoError(t, err)
+		defer db.Close()
+		populate(t, db)
+
+		// repository
+		rp := repository.NewInvoicesMySQL(db, 0, slog.Default())
+
+		// ACT
+		i, _, err := rp.FindAllDetail(context.Background(), internal.ListQuery{Limit: 10}, false)
+
+		// ASSERT
+		require.NoError(t, err)
+		require.Len(t, i, 1)
+		require.Equal(t, "customer", i[0].Customer.FirstName)
+		require.Empty(t, i[0].Lines)
+	})
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"app/internal"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for listSpec
+func TestListSpec(t *testing.T) {
+	t.Run("default sort by id with limit", func(t *testing.T) {
+		// arrange
+		q := internal.ListQuery{Limit: 2}
+
+		// act
+		cl, err := listSpecCustomers.build(q)
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, " ORDER BY `id` ASC LIMIT 3", cl.sql(""))
+		require.Empty(t, cl.args)
+	})
+
+	t.Run("filter and descending sort", func(t *testing.T) {
+		// arrange
+		q := internal.ListQuery{Sort: "-price", Filters: map[string]string{"price_min": "10"}}
+
+		// act
+		cl, err := listSpecProducts.build(q)
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, " WHERE `price` >= ? ORDER BY `price` DESC, `id` DESC", cl.sql(""))
+		require.Equal(t, []any{10.0}, cl.args)
+	})
+
+	t.Run("next page starts after the cursor of the previous page", func(t *testing.T) {
+		// arrange
+		q := internal.ListQuery{Limit: 2, Sort: "last_name"}
+		items := []internal.Customer{
+			{Id: 3, CustomerAttributes: internal.CustomerAttributes{LastName: "a"}},
+			{Id: 1, CustomerAttributes: internal.CustomerAttributes{LastName: "b"}},
+			{Id: 2, CustomerAttributes: internal.CustomerAttributes{LastName: "c"}},
+		}
+
+		// act
+		p, pg, err := listSpecCustomers.page(q, items)
+		require.NoError(t, err)
+		q.Cursor = pg.NextCursor
+		cl, err := listSpecCustomers.build(q)
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, items[:2], p)
+		require.NotEmpty(t, pg.NextCursor)
+		require.Equal(t, " WHERE (`last_name` > ? OR (`last_name` = ? AND `id` > ?)) ORDER BY `last_name` ASC, `id` ASC LIMIT 3", cl.sql(""))
+		require.Equal(t, []any{"b", "b", 1}, cl.args)
+	})
+
+	t.Run("last page has no cursor", func(t *testing.T) {
+		// arrange
+		q := internal.ListQuery{Limit: 2}
+		items := []internal.Customer{{Id: 1}, {Id: 2}}
+
+		// act
+		p, pg, err := listSpecCustomers.page(q, items)
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, items, p)
+		require.Empty(t, pg.NextCursor)
+	})
+
+	t.Run("filter values are bound with the type of their column", func(t *testing.T) {
+		// arrange
+		q := internal.ListQuery{Filters: map[string]string{"datetime_to": "2021-01-31"}}
+
+		// act
+		cl, err := listSpecInvoices.build(q)
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, " WHERE `datetime` < DATE_ADD(?, INTERVAL 1 DAY)", cl.where)
+		require.Equal(t, []any{"2021-01-31"}, cl.args)
+	})
+
+	t.Run("error - invalid filter value", func(t *testing.T) {
+		// arrange
+		cases := []struct {
+			spec func(q internal.ListQuery) (listClause, error)
+			q    internal.ListQuery
+		}{
+			{listSpecProducts.build, internal.ListQuery{Filters: map[string]string{"price_min": "abc"}}},
+			{listSpecCustomers.build, internal.ListQuery{Filters: map[string]string{"condition": "yes"}}},
+			{listSpecInvoices.build, internal.ListQuery{Filters: map[string]string{"datetime_from": "yesterday"}}},
+			{listSpecSales.build, internal.ListQuery{Filters: map[string]string{"quantity_min": "1.5"}}},
+		}
+
+		for _, c := range cases {
+			// act
+			_, err := c.spec(c.q)
+
+			// assert
+			require.ErrorIs(t, err, internal.ErrListQueryInvalid)
+		}
+	})
+
+	t.Run("error - unknown sort, filter or cursor", func(t *testing.T) {
+		// arrange
+		queries := []internal.ListQuery{
+			{Sort: "password"},
+			{Filters: map[string]string{"password": "x"}},
+			{Cursor: "not a cursor"},
+		}
+
+		for _, q := range queries {
+			// act
+			_, err := listSpecCustomers.build(q)
+
+			// assert
+			require.ErrorIs(t, err, internal.ErrListQueryInvalid)
+		}
+	})
+}
@@ -1,28 +1,106 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
 
 	"app/internal"
 )
 
 // NewProductsMySQL creates new mysql repository for product entity.
-func NewProductsMySQL(db *sql.DB) *ProductsMySQL {
-	return &ProductsMySQL{db}
+func NewProductsMySQL(db *sql.DB, timeout time.Duration, lg *slog.Logger) *ProductsMySQL {
+	return &ProductsMySQL{db, timeout, lg}
 }
 
 // ProductsMySQL is the MySQL repository implementation for product entity.
 type ProductsMySQL struct {
 	// db is the database connection.
 	db *sql.DB
+	// timeout bounds each query, zero means no timeout.
+	timeout time.Duration
+	// lg is the logger of the queries that time out.
+	lg *slog.Logger
 }
 
-// FindAll returns all products from the database.
-func (r *ProductsMySQL) FindAll() (p []internal.Product, err error) {
+// listSpecProducts is the list spec of the products.
+var listSpecProducts = listSpec[internal.Product]{
+	id:      "`id`",
+	idValue: func(p internal.Product) int { return p.Id },
+	sorts: map[string]listSort[internal.Product]{
+		"id":          {"`id`", func(p internal.Product) any { return p.Id }},
+		"description": {"`description`", func(p internal.Product) any { return p.Description }},
+		"price":       {"`price`", func(p internal.Product) any { return p.Price }},
+	},
+	filters: map[string]string{
+		"description": "`description` LIKE CONCAT('%', ?, '%')",
+		"price_min":   "`price` >= ?",
+		"price_max":   "`price` <= ?",
+	},
+	values: map[string]func(v string) (any, error){
+		"price_min": parseListFloat,
+		"price_max": parseListFloat,
+	},
+}
+
+// bulkSpecProducts is the bulk spec of the products.
+var bulkSpecProducts = bulkSpec[internal.Product]{
+	repository: "products",
+	query:      "INSERT INTO products (`description`, `price`) VALUES (?, ?)",
+	args:       func(p internal.Product) []any { return []any{p.Description, p.Price} },
+	setId:      func(p *internal.Product, id int) { p.Id = id },
+}
+
+// FindAll returns a page of products from the database.
+func (r *ProductsMySQL) FindAll(ctx context.Context, q internal.ListQuery) (p []internal.Product, pg internal.Page, err error) {
+	// bound the query
+	ctx, cancel := queryContext(ctx, r.timeout, r.lg, "products", "FindAll")
+	defer cancel()
+
+	// build the query
+	cl, err := listSpecProducts.build(q)
+	if err != nil {
+		return
+	}
+
+	// execute the query
+	err = r.each(ctx, cl, func(pr internal.Product) error {
+		p = append(p, pr)
+		return nil
+	})
+	if err != nil {
+		return nil, pg, err
+	}
+
+	// page
+	p, pg, err = listSpecProducts.page(q, p)
+	return
+}
+
+// Stream calls fn with each product of the list as it is read from the database, without holding the list in memory.
+// The limit is the total number of products rather than the page size, zero streams all of them.
+// It is not bounded by the query timeout, only by ctx, since streaming a whole table may take longer.
+func (r *ProductsMySQL) Stream(ctx context.Context, q internal.ListQuery, fn func(pr internal.Product) error) (err error) {
+	// build the query
+	cl, err := listSpecProducts.build(q)
+	if err != nil {
+		return
+	}
+	cl.limit = q.Limit
+
+	// execute the query
+	err = r.each(ctx, cl, fn)
+	return
+}
+
+// each executes the list query built in cl and calls fn with each product.
+func (r *ProductsMySQL) each(ctx context.Context, cl listClause, fn func(pr internal.Product) error) (err error) {
 	// execute the query
-	rows, err := r.db.Query("SELECT `id`, `description`, `price` FROM products")
+	rows, err := r.db.QueryContext(ctx, "SELECT `id`, `description`, `price` FROM products"+cl.sql(""), cl.args...)
 	if err != nil {
-		return nil, err
+		return
 	}
 	defer rows.Close()
 
@@ -30,15 +108,34 @@ func (r *ProductsMySQL) FindAll() (p []internal.Product, err error) {
 	for rows.Next() {
 		var pr internal.Product
 		// scan the row into the product
-		err := rows.Scan(&pr.Id, &pr.Description, &pr.Price)
+		err = rows.Scan(&pr.Id, &pr.Description, &pr.Price)
 		if err != nil {
-			return nil, err
+			return
+		}
+		err = fn(pr)
+		if err != nil {
+			return
 		}
-		// append the product to the slice
-		p = append(p, pr)
 	}
 	err = rows.Err()
+	return
+}
+
+// FindById returns the product with the given id from the database.
+func (r *ProductsMySQL) FindById(ctx context.Context, id int) (p internal.Product, err error) {
+	// bound the query
+	ctx, cancel := queryContext(ctx, r.timeout, r.lg, "products", "FindById")
+	defer cancel()
+
+	// execute the query
+	row := r.db.QueryRowContext(ctx, "SELECT `id`, `description`, `price` FROM products WHERE `id` = ?", id)
+
+	// scan the row into the product
+	err = row.Scan(&p.Id, &p.Description, &p.Price)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = internal.ErrRepositoryProductNotFound
+		}
 		return
 	}
 
@@ -46,9 +143,13 @@ func (r *ProductsMySQL) FindAll() (p []internal.Product, err error) {
 }
 
 // Save saves the product into the database.
-func (r *ProductsMySQL) Save(p *internal.Product) (err error) {
+func (r *ProductsMySQL) Save(ctx context.Context, p *internal.Product) (err error) {
+	// bound the query
+	ctx, cancel := queryContext(ctx, r.timeout, r.lg, "products", "Save")
+	defer cancel()
+
 	// execute the query
-	res, err := r.db.Exec(
+	res, err := r.db.ExecContext(ctx,
 		"INSERT INTO products (`description`, `price`) VALUES (?, ?)",
 		(*p).Description, (*p).Price,
 	)
@@ -67,3 +168,9 @@ func (r *ProductsMySQL) Save(p *internal.Product) (err error) {
 
 	return
 }
+
+// SaveBulk saves products into the database in mode, setting their ids, and returns the error of each one, nil if it was saved.
+func (r *ProductsMySQL) SaveBulk(ctx context.Context, p []internal.Product, mode internal.BulkMode) (errs []error) {
+	errs = bulkSpecProducts.save(ctx, r.db, r.timeout, r.lg, p, mode)
+	return
+}
@@ -1,28 +1,136 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
 
 	"app/internal"
 )
 
 // NewSalesMySQL creates new mysql repository for sale entity.
-func NewSalesMySQL(db *sql.DB) *SalesMySQL {
-	return &SalesMySQL{db}
+func NewSalesMySQL(db *sql.DB, timeout time.Duration, lg *slog.Logger) *SalesMySQL {
+	return &SalesMySQL{db, timeout, lg}
 }
 
 // SalesMySQL is the MySQL repository implementation for sale entity.
 type SalesMySQL struct {
 	// db is the database connection.
 	db *sql.DB
+	// timeout bounds each query, zero means no timeout.
+	timeout time.Duration
+	// lg is the logger of the queries that time out.
+	lg *slog.Logger
 }
 
-// FindAll returns all sales from the database.
-func (r *SalesMySQL) FindAll() (s []internal.Sale, err error) {
+// listSpecSales is the list spec of the sales.
+var listSpecSales = listSpec[internal.Sale]{
+	id:      "`id`",
+	idValue: func(s internal.Sale) int { return s.Id },
+	sorts: map[string]listSort[internal.Sale]{
+		"id":       {"`id`", func(s internal.Sale) any { return s.Id }},
+		"quantity": {"`quantity`", func(s internal.Sale) any { return s.Quantity }},
+	},
+	filters: map[string]string{
+		"product_id":   "`product_id` = ?",
+		"invoice_id":   "`invoice_id` = ?",
+		"quantity_min": "`quantity` >= ?",
+		"quantity_max": "`quantity` <= ?",
+	},
+	values: map[string]func(v string) (any, error){
+		"product_id":   parseListInt,
+		"invoice_id":   parseListInt,
+		"quantity_min": parseListInt,
+		"quantity_max": parseListInt,
+	},
+}
+
+// listSpecProductSales is the list spec of the sales of a product, joined with their invoice.
+// The product_id filter is set by FindByProductId.
+var listSpecProductSales = listSpec[internal.ProductSale]{
+	id:      "sales.id",
+	idValue: func(s internal.ProductSale) int { return s.SaleId },
+	sorts: map[string]listSort[internal.ProductSale]{
+		"id":       {"sales.id", func(s internal.ProductSale) any { return s.SaleId }},
+		"datetime": {"invoices.datetime", func(s internal.ProductSale) any { return s.InvoiceDatetime }},
+		"quantity": {"sales.quantity", func(s internal.ProductSale) any { return s.Quantity }},
+	},
+	filters: map[string]string{
+		"product_id":    "sales.product_id = ?",
+		"datetime_from": "invoices.datetime >= ?",
+		"datetime_to":   "invoices.datetime < DATE_ADD(?, INTERVAL 1 DAY)",
+		"quantity_min":  "sales.quantity >= ?",
+		"quantity_max":  "sales.quantity <= ?",
+	},
+	values: map[string]func(v string) (any, error){
+		"product_id":    parseListInt,
+		"datetime_from": parseListDate,
+		"datetime_to":   parseListDate,
+		"quantity_min":  parseListInt,
+		"quantity_max":  parseListInt,
+	},
+}
+
+// bulkSpecSales is the bulk spec of the sales.
+var bulkSpecSales = bulkSpec[internal.Sale]{
+	repository: "sales",
+	query:      "INSERT INTO sales (`quantity`, `product_id`, `invoice_id`) VALUES (?, ?, ?)",
+	args:       func(s internal.Sale) []any { return []any{s.Quantity, s.ProductId, s.InvoiceId} },
+	setId:      func(s *internal.Sale, id int) { s.Id = id },
+}
+
+// FindAll returns a page of sales from the database.
+func (r *SalesMySQL) FindAll(ctx context.Context, q internal.ListQuery) (s []internal.Sale, p internal.Page, err error) {
+	// bound the query
+	ctx, cancel := queryContext(ctx, r.timeout, r.lg, "sales", "FindAll")
+	defer cancel()
+
+	// build the query
+	cl, err := listSpecSales.build(q)
+	if err != nil {
+		return
+	}
+
 	// execute the query
-	rows, err := r.db.Query("SELECT `id`, `quantity`, `product_id`, `invoice_id` FROM sales")
+	err = r.each(ctx, cl, func(sa internal.Sale) error {
+		s = append(s, sa)
+		return nil
+	})
 	if err != nil {
-		return nil, err
+		return nil, p, err
+	}
+
+	// page
+	s, p, err = listSpecSales.page(q, s)
+	return
+}
+
+// Stream calls fn with each sale of the list as it is read from the database, without holding the list in memory.
+// The limit is the total number of sales rather than the page size, zero streams all of them.
+// It is not bounded by the query timeout, only by ctx, since streaming a whole table may take longer.
+func (r *SalesMySQL) Stream(ctx context.Context, q internal.ListQuery, fn func(sa internal.Sale) error) (err error) {
+	// build the query
+	cl, err := listSpecSales.build(q)
+	if err != nil {
+		return
+	}
+	cl.limit = q.Limit
+
+	// execute the query
+	err = r.each(ctx, cl, fn)
+	return
+}
+
+// each executes the list query built in cl and calls fn with each sale.
+func (r *SalesMySQL) each(ctx context.Context, cl listClause, fn func(sa internal.Sale) error) (err error) {
+	// execute the query
+	rows, err := r.db.QueryContext(ctx, "SELECT `id`, `quantity`, `product_id`, `invoice_id` FROM sales"+cl.sql(""), cl.args...)
+	if err != nil {
+		return
 	}
 	defer rows.Close()
 
@@ -30,30 +138,32 @@ func (r *SalesMySQL) FindAll() (s []internal.Sale, err error) {
 	for rows.Next() {
 		var sa internal.Sale
 		// scan the row into the sale
-		err := rows.Scan(&sa.Id, &sa.Quantity, &sa.ProductId, &sa.InvoiceId)
+		err = rows.Scan(&sa.Id, &sa.Quantity, &sa.ProductId, &sa.InvoiceId)
 		if err != nil {
-			return nil, err
+			return
+		}
+		err = fn(sa)
+		if err != nil {
+			return
 		}
-		// append the sale to the slice
-		s = append(s, sa)
 	}
 	err = rows.Err()
-	if err != nil {
-		return
-	}
-
 	return
 }
 
 // Save saves the sale into the database.
-func (r *SalesMySQL) Save(s *internal.Sale) (err error) {
+func (r *SalesMySQL) Save(ctx context.Context, s *internal.Sale) (err error) {
+	// bound the query
+	ctx, cancel := queryContext(ctx, r.timeout, r.lg, "sales", "Save")
+	defer cancel()
+
 	// execute the query
-	res, err := r.db.Exec(
+	res, err := r.db.ExecContext(ctx,
 		"INSERT INTO sales (`quantity`, `product_id`, `invoice_id`) VALUES (?, ?, ?)",
 		(*s).Quantity, (*s).ProductId, (*s).InvoiceId,
 	)
 	if err != nil {
-		return err
+		return insertError(err)
 	}
 
 	// get the last inserted id
@@ -68,12 +178,22 @@ func (r *SalesMySQL) Save(s *internal.Sale) (err error) {
 	return
 }
 
+// SaveBulk saves sales into the database in mode, setting their ids, and returns the error of each one, nil if it was saved.
+func (r *SalesMySQL) SaveBulk(ctx context.Context, s []internal.Sale, mode internal.BulkMode) (errs []error) {
+	errs = bulkSpecSales.save(ctx, r.db, r.timeout, r.lg, s, mode)
+	return
+}
+
 // FindTopSold returns the top n products sold in the database.
 // a sale has one product and a quantity
 // a product has a name
-func (r *SalesMySQL) FindTopSold(n int) (p []internal.ProductSales, err error) {
+func (r *SalesMySQL) FindTopSold(ctx context.Context, n int) (p []internal.ProductSales, err error) {
+	// bound the query
+	ctx, cancel := queryContext(ctx, r.timeout, r.lg, "sales", "FindTopSold")
+	defer cancel()
+
 	// execute the query
-	rows, err := r.db.Query(
+	rows, err := r.db.QueryContext(ctx,
 		"SELECT `products`.`description`, SUM(`sales`.`quantity`) AS `total` FROM `sales` INNER JOIN `products` ON `sales`.`product_id` = `products`.`id` GROUP BY `sales`.`product_id` ORDER BY `total` DESC LIMIT ?",
 		n,
 	)
@@ -101,3 +221,133 @@ func (r *SalesMySQL) FindTopSold(n int) (p []internal.ProductSales, err error) {
 
 	return
 }
+
+// FindByProductId returns a page of the sales of a product from the database, with their invoice date and customer.
+// sales are ordered by invoice date unless sorted otherwise, revenue is rounded to the second decimal place.
+func (r *SalesMySQL) FindByProductId(ctx context.Context, productId int, q internal.ListQuery) (s []internal.ProductSale, p internal.Page, err error) {
+	// bound the query
+	ctx, cancel := queryContext(ctx, r.timeout, r.lg, "sales", "FindByProductId")
+	defer cancel()
+
+	// build the query
+	if q.Sort == "" {
+		q.Sort = "datetime"
+	}
+	q = q.WithFilter("product_id", strconv.Itoa(productId))
+	cl, err := listSpecProductSales.build(q)
+	if err != nil {
+		return
+	}
+
+	// execute the query
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT
+            sales.id,
+            sales.quantity,
+            sales.quantity * products.price AS revenue,
+            invoices.id,
+            invoices.datetime,
+            COALESCE(customers.id, 0),
+            COALESCE(customers.first_name, ''),
+            COALESCE(customers.last_name, '')
+        FROM
+            sales
+        INNER JOIN
+            products ON sales.product_id = products.id
+        INNER JOIN
+            invoices ON sales.invoice_id = invoices.id
+        LEFT JOIN
+            customers ON invoices.customer_id = customers.id`+cl.sql(""),
+		cl.args...,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	// iterate over the rows
+	for rows.Next() {
+		var ps internal.ProductSale
+		// scan the row into the product sale
+		err = rows.Scan(&ps.SaleId, &ps.Quantity, &ps.Revenue, &ps.InvoiceId, &ps.InvoiceDatetime, &ps.CustomerId, &ps.CustomerFirstName, &ps.CustomerLastName)
+		if err != nil {
+			return
+		}
+		// round the revenue to the second decimal place
+		ps.Revenue = math.Round(ps.Revenue*100) / 100
+		// append the product sale to the slice
+		s = append(s, ps)
+	}
+	err = rows.Err()
+	if err != nil {
+		return
+	}
+
+	// page
+	s, p, err = listSpecProductSales.page(q, s)
+	return
+}
+
+// FindByProductIdPerPeriod returns the units and revenue of a product from the database, aggregated by day or month.
+// revenue is rounded to the second decimal place.
+func (r *SalesMySQL) FindByProductIdPerPeriod(ctx context.Context, productId int, period string) (p []internal.ProductSalesPeriod, err error) {
+	// bound the query
+	ctx, cancel := queryContext(ctx, r.timeout, r.lg, "sales", "FindByProductIdPerPeriod")
+	defer cancel()
+
+	// format of the period
+	var format string
+	switch period {
+	case internal.PeriodDay:
+		format = "%Y-%m-%d"
+	case internal.PeriodMonth:
+		format = "%Y-%m"
+	default:
+		return nil, fmt.Errorf("invalid period: %s", period)
+	}
+
+	// execute the query
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT
+            DATE_FORMAT(invoices.datetime, ?) AS period,
+            SUM(sales.quantity) AS units,
+            SUM(sales.quantity * products.price) AS revenue
+        FROM
+            sales
+        INNER JOIN
+            products ON sales.product_id = products.id
+        INNER JOIN
+            invoices ON sales.invoice_id = invoices.id
+        WHERE
+            sales.product_id = ?
+        GROUP BY
+            period
+        ORDER BY
+            period`,
+		format, productId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// iterate over the rows
+	for rows.Next() {
+		var pp internal.ProductSalesPeriod
+		// scan the row into the period
+		err := rows.Scan(&pp.Period, &pp.Units, &pp.Revenue)
+		if err != nil {
+			return nil, err
+		}
+		// round the revenue to the second decimal place
+		pp.Revenue = math.Round(pp.Revenue*100) / 100
+		// append the period to the slice
+		p = append(p, pp)
+	}
+	err = rows.Err()
+	if err != nil {
+		return
+	}
+
+	return
+}
@@ -3,7 +3,9 @@ package repository_test
 import (
 	"app/internal"
 	"app/internal/repository"
+	"context"
 	"database/sql"
+	"log/slog"
 	"testing"
 
 	"github.com/DATA-DOG/go-txdb"
@@ -42,7 +44,7 @@ func TestFindTopSold(t *testing.T) {
 		}()
 
 		// repository
-		rp := repository.NewSalesMySQL(db)
+		rp := repository.NewSalesMySQL(db, 0, slog.Default())
 
 		// populate products
 		_, err = db.Exec("INSERT INTO products (`description`, `price`) VALUES (?, ?)", "A", 100)
@@ -75,10 +77,237 @@ func TestFindTopSold(t *testing.T) {
 		}
 
 		// ACT
-		s, err := rp.FindTopSold(2)
+		s, err := rp.FindTopSold(context.Background(), 2)
 
 		// ASSERT
 		require.NoError(t, err)
 		require.Equal(t, expected, s)
 	})
 }
+
+func TestSaveBulkSales(t *testing.T) {
+	// populate populates a customer, an invoice and a product for the sales to reference
+	populate := func(t *testing.T, db *sql.DB) {
+		_, err := db.Exec("INSERT INTO customers (`first_name`, `last_name`, `condition`) VALUES (?, ?, ?)", "customer", "1", 1)
+		require.NoError(t, err)
+		_, err = db.Exec("INSERT INTO invoices (`customer_id`, `datetime`, `total`) VALUES (?, ?, ?)", 1, "2021-01-01 00:00:00", 100)
+		require.NoError(t, err)
+		_, err = db.Exec("INSERT INTO products (`description`, `price`) VALUES (?, ?)", "A", 100)
+		require.NoError(t, err)
+	}
+
+	t.Run("should save the sales that can be saved in best effort mode", func(t *testing.T) {
+		// ARRANGE
+		db, err := sql.Open("txdb_sale_repository", "fantasy_products_test")
+		require.NoError(t, err)
+		defer db.Close()
+		populate(t, db)
+
+		// repository
+		rp := repository.NewSalesMySQL(db, 0, slog.Default())
+
+		// sales, the second one referencing a product that does not exist
+		s := []internal.Sale{
+			{SaleAttributes: internal.SaleAttributes{Quantity: 1, ProductId: 1, InvoiceId: 1}},
+			{SaleAttributes: internal.SaleAttributes{Quantity: 2, ProductId: 99, InvoiceId: 1}},
+			{SaleAttributes: internal.SaleAttributes{Quantity: 3, ProductId: 1, InvoiceId: 1}},
+		}
+
+		// ACT
+		errs := rp.SaveBulk(context.Background(), s, internal.BulkBestEffort)
+
+		// ASSERT
+		require.Len(t, errs, 3)
+		require.NoError(t, errs[0])
+		require.ErrorIs(t, errs[1], internal.ErrRepositoryReferenceNotFound)
+		require.NoError(t, errs[2])
+		require.NotZero(t, s[0].Id)
+		require.Zero(t, s[1].Id)
+		require.NotZero(t, s[2].Id)
+		var count int
+		require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM sales").Scan(&count))
+		require.Equal(t, 2, count)
+	})
+
+	t.Run("should abort the other sales in atomic mode", func(t *testing.T) {
+		// ARRANGE
+		db, err := sql.Open("txdb_sale_repository", "fantasy_products_test")
+		require.NoError(t, err)
+		defer db.Close()
+		populate(t, db)
+
+		// repository
+		rp := repository.NewSalesMySQL(db, 0, slog.Default())
+
+		// sales, the second one referencing an invoice that does not exist
+		s := []internal.Sale{
+			{SaleAttributes: internal.SaleAttributes{Quantity: 1, ProductId: 1, InvoiceId: 1}},
+			{SaleAttributes: internal.SaleAttributes{Quantity: 2, ProductId: 1, InvoiceId: 99}},
+			{SaleAttributes: internal.SaleAttributes{Quantity: 3, ProductId: 1, InvoiceId: 1}},
+		}
+
+		// ACT
+		errs := rp.SaveBulk(context.Background(), s, internal.BulkAtomic)
+
+		// ASSERT
+		require.Len(t, errs, 3)
+		require.ErrorIs(t, errs[0], internal.ErrBulkAborted)
+		require.ErrorIs(t, errs[1], internal.ErrRepositoryReferenceNotFound)
+		require.ErrorIs(t, errs[2], internal.ErrBulkAborted)
+		require.Zero(t, s[0].Id)
+		require.Zero(t, s[2].Id)
+	})
+}
+
+func TestFindSalesByProductId(t *testing.T) {
+	// populate populates two customers, invoices on different days and months and sales of two products
+	populate := func(t *testing.T, db *sql.DB) {
+		_, err := db.Exec("INSERT INTO customers (`id`, `first_name`, `last_name`, `condition`) VALUES (?, ?, ?, ?)", 1, "John", "Doe", 1)
+		require.NoError(t, err)
+		_, err = db.Exec("INSERT INTO invoices (`id`, `customer_id`, `datetime`, `total`) VALUES (?, ?, ?, ?)", 1, 1, "2021-01-01 10:00:00", 0)
+		require.NoError(t, err)
+		_, err = db.Exec("INSERT INTO invoices (`id`, `customer_id`, `datetime`, `total`) VALUES (?, ?, ?, ?)", 2, 1, "2021-01-01 18:00:00", 0)
+		require.NoError(t, err)
+		_, err = db.Exec("INSERT INTO invoices (`id`, `customer_id`, `datetime`, `total`) VALUES (?, ?, ?, ?)", 3, 1, "2021-01-15 10:00:00", 0)
+		require.NoError(t, err)
+		_, err = db.Exec("INSERT INTO invoices (`id`, `customer_id`, `datetime`, `total`) VALUES (?, ?, ?, ?)", 4, 1, "2021-02-01 10:00:00", 0)
+		require.NoError(t, err)
+		_, err = db.Exec("INSERT INTO products (`id`, `description`, `price`) VALUES (?, ?, ?)", 1, "A", 1.5)
+		require.NoError(t, err)
+		_, err = db.Exec("INSERT INTO products (`id`, `description`, `price`) VALUES (?, ?, ?)", 2, "B", 10)
+		require.NoError(t, err)
+		// product A: 1 + 2 units on 2021-01-01, 3 units on 2021-01-15 and 4 units on 2021-02-01
+		_, err = db.Exec("INSERT INTO sales (`id`, `quantity`, `product_id`, `invoice_id`) VALUES (?, ?, ?, ?)", 1, 4, 1, 4)
+		require.NoError(t, err)
+		_, err = db.Exec("INSERT INTO sales (`id`, `quantity`, `product_id`, `invoice_id`) VALUES (?, ?, ?, ?)", 2, 2, 1, 2)
+		require.NoError(t, err)
+		_, err = db.Exec("INSERT INTO sales (`id`, `quantity`, `product_id`, `invoice_id`) VALUES (?, ?, ?, ?)", 3, 3, 1, 3)
+		require.NoError(t, err)
+		_, err = db.Exec("INSERT INTO sales (`id`, `quantity`, `product_id`, `invoice_id`) VALUES (?, ?, ?, ?)", 4, 1, 1, 1)
+		require.NoError(t, err)
+		// product B
+		_, err = db.Exec("INSERT INTO sales (`id`, `quantity`, `product_id`, `invoice_id`) VALUES (?, ?, ?, ?)", 5, 5, 2, 1)
+		require.NoError(t, err)
+	}
+
+	t.Run("should return the sales of the product ordered by invoice date", func(t *testing.T) {
+		// ARRANGE
+		db, err := sql.Open("txdb_sale_repository", "fantasy_products_test")
+		require.NoError(t, err)
+		defer db.Close()
+		populate(t, db)
+
+		// repository
+		rp := repository.NewSalesMySQL(db, 0, slog.Default())
+
+		// ACT
+		s, p, err := rp.FindByProductId(context.Background(), 1, internal.ListQuery{Limit: 10})
+
+		// ASSERT
+		require.NoError(t, err)
+		require.Empty(t, p.NextCursor)
+		require.Len(t, s, 4)
+		require.Equal(t, []int{4, 2, 3, 1}, []int{s[0].SaleId, s[1].SaleId, s[2].SaleId, s[3].SaleId})
+		require.Equal(t, 1, s[0].Quantity)
+		require.Equal(t, 1.5, s[0].Revenue)
+		require.Equal(t, 1, s[0].InvoiceId)
+		require.Equal(t, 1, s[0].CustomerId)
+		require.Equal(t, "John", s[0].CustomerFirstName)
+		require.Equal(t, "Doe", s[0].CustomerLastName)
+	})
+
+	t.Run("should return the next page after the cursor of the previous one", func(t *testing.T) {
+		// ARRANGE
+		db, err := sql.Open("txdb_sale_repository", "fantasy_products_test")
+		require.NoError(t, err)
+		defer db.Close()
+		populate(t, db)
+
+		// repository
+		rp := repository.NewSalesMySQL(db, 0, slog.Default())
+
+		// ACT
+		first, p, err := rp.FindByProductId(context.Background(), 1, internal.ListQuery{Limit: 2})
+		require.NoError(t, err)
+		s, next, err := rp.FindByProductId(context.Background(), 1, internal.ListQuery{Limit: 2, Cursor: p.NextCursor})
+
+		// ASSERT
+		require.NoError(t, err)
+		require.Equal(t, []int{4, 2}, []int{first[0].SaleId, first[1].SaleId})
+		require.NotEmpty(t, p.NextCursor)
+		require.Len(t, s, 2)
+		require.Equal(t, 3, s[0].SaleId)
+		require.Equal(t, 1, s[1].SaleId)
+		require.Empty(t, next.NextCursor)
+	})
+
+	t.Run("should aggregate the sales of the product by day", func(t *testing.T) {
+		// ARRANGE
+		db, err := sql.Open("txdb_sale_repository", "fantasy_products_test")
+		require.NoError(t, err)
+		defer db.Close()
+		populate(t, db)
+
+		// repository
+		rp := repository.NewSalesMySQL(db, 0, slog.Default())
+
+		// expected result
+		expected := []internal.ProductSalesPeriod{
+			{Period: "2021-01-01", Units: 3, Revenue: 4.5},
+			{Period: "2021-01-15", Units: 3, Revenue: 4.5},
+			{Period: "2021-02-01", Units: 4, Revenue: 6},
+		}
+
+		// ACT
+		p, err := rp.FindByProductIdPerPeriod(context.Background(), 1, internal.PeriodDay)
+
+		// ASSERT
+		require.NoError(t, err)
+		require.Equal(t, expected, p)
+	})
+
+	t.Run("should aggregate the sales of the product by month", func(t *testing.T) {
+		// ARRANGE
+		db, err := sql.Open("txdb_sale_repository", "fantasy_products_test")
+		require.NoError(t, err)
+		defer db.Close()
+		populate(t, db)
+
+		// repository
+		rp := repository.NewSalesMySQL(db, 0, slog.Default())
+
+		// expected result
+		expected := []internal.ProductSalesPeriod{
+			{Period: "2021-01", Units: 6, Revenue: 9},
+			{Period: "2021-02", Units: 4, Revenue: 6},
+		}
+
+		// ACT
+		p, err := rp.FindByProductIdPerPeriod(context.Background(), 1, internal.PeriodMonth)
+
+		// ASSERT
+		require.NoError(t, err)
+		require.Equal(t, expected, p)
+	})
+
+	t.Run("should return no sales for a product that does not exist", func(t *testing.T) {
+		// ARRANGE
+		db, err := sql.Open("txdb_sale_repository", "fantasy_products_test")
+		require.NoError(t, err)
+		defer db.Close()
+		populate(t, db)
+
+		// repository
+		rp := repository.NewSalesMySQL(db, 0, slog.Default())
+
+		// ACT
+		s, _, err := rp.FindByProductId(context.Background(), 99, internal.ListQuery{Limit: 10})
+		require.NoError(t, err)
+		p, err := rp.FindByProductIdPerPeriod(context.Background(), 99, internal.PeriodDay)
+
+		// ASSERT
+		require.NoError(t, err)
+		require.Empty(t, s)
+		require.Empty(t, p)
+	})
+}
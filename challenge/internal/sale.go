@@ -25,3 +25,40 @@ type ProductSales struct {
 	// Sales is the sales of the product.
 	Sales int
 }
+
+// ProductSale is the struct that represents a sale of a product with its invoice date and customer.
+type ProductSale struct {
+	// SaleId is the id of the sale.
+	SaleId int
+	// Quantity is the quantity sold.
+	Quantity int
+	// Revenue is the quantity multiplied by the product price.
+	Revenue float64
+	// InvoiceId is the id of the invoice of the sale.
+	InvoiceId int
+	// InvoiceDatetime is the datetime of the invoice of the sale.
+	InvoiceDatetime string
+	// CustomerId is the id of the customer of the invoice.
+	CustomerId int
+	// CustomerFirstName is the first name of the customer of the invoice.
+	CustomerFirstName string
+	// CustomerLastName is the last name of the customer of the invoice.
+	CustomerLastName string
+}
+
+// ProductSalesPeriod is the struct that represents the aggregated sales of a product in a period.
+type ProductSalesPeriod struct {
+	// Period is the day (YYYY-MM-DD) or month (YYYY-MM) of the sales.
+	Period string
+	// Units is the quantity sold in the period.
+	Units int
+	// Revenue is the money made in the period.
+	Revenue float64
+}
+
+const (
+	// PeriodDay aggregates sales by day.
+	PeriodDay = "day"
+	// PeriodMonth aggregates sales by month.
+	PeriodMonth = "month"
+)
@@ -1,6 +1,9 @@
 package internal
 
+import "context"
+
 type SaleLoader interface {
 	Load() (c []Sale, err error)
-	Migrate() (err error)
+	Migrate(ctx context.Context) (err error)
+	Dump(c []Sale) (err error)
 }
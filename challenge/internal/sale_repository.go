@@ -1,11 +1,21 @@
 package internal
 
+import "context"
+
 // RepositorySale is the interface that wraps the basic Sale methods.
 type RepositorySale interface {
-	// FindAll returns all sales.
-	FindAll() (s []Sale, err error)
+	// FindAll returns a page of sales.
+	FindAll(ctx context.Context, q ListQuery) (s []Sale, p Page, err error)
+	// Stream calls fn with each sale of the list, stopping at the first error.
+	Stream(ctx context.Context, q ListQuery, fn func(sa Sale) error) (err error)
 	// Save saves a sale.
-	Save(s *Sale) (err error)
+	Save(ctx context.Context, s *Sale) (err error)
+	// SaveBulk saves sales in mode, setting their ids, and returns the error of each one, nil if it was saved.
+	SaveBulk(ctx context.Context, s []Sale, mode BulkMode) (errs []error)
 	// FindTopSold returns the top n products sold in the database.
-	FindTopSold(n int) (p []ProductSales, err error)
+	FindTopSold(ctx context.Context, n int) (p []ProductSales, err error)
+	// FindByProductId returns a page of the sales of a product, ordered by date unless sorted otherwise.
+	FindByProductId(ctx context.Context, productId int, q ListQuery) (s []ProductSale, p Page, err error)
+	// FindByProductIdPerPeriod returns the units and revenue of a product aggregated by PeriodDay or PeriodMonth.
+	FindByProductIdPerPeriod(ctx context.Context, productId int, period string) (p []ProductSalesPeriod, err error)
 }
@@ -1,11 +1,20 @@
 package internal
 
+import "context"
+
 // ServiceSale is the interface that wraps the basic ServiceSale methods.
 type ServiceSale interface {
-	// FindAll returns all sales.
-	FindAll() (s []Sale, err error)
+	// FindAll returns a page of sales.
+	FindAll(ctx context.Context, q ListQuery) (s []Sale, p Page, err error)
+	// Stream calls fn with each sale of the list, stopping at the first error.
+	Stream(ctx context.Context, q ListQuery, fn func(sa Sale) error) (err error)
 	// Save saves a sale.
-	Save(s *Sale) (err error)
+	Save(ctx context.Context, s *Sale) (err error)
+	// SaveBulk saves sales in mode, setting their ids, and returns the error of each one, nil if it was saved.
+	SaveBulk(ctx context.Context, s []Sale, mode BulkMode) (errs []error)
 	// FindTopSold returns the top n products sold in the database.
-	FindTopSold(n int) (p []ProductSales, err error)
+	FindTopSold(ctx context.Context, n int) (p []ProductSales, err error)
+	// FindByProductId returns a page of the sales of a product, ordered by date unless sorted otherwise,
+	// and its units and revenue aggregated by PeriodDay or PeriodMonth.
+	FindByProductId(ctx context.Context, productId int, q ListQuery, period string) (s []ProductSale, pg Page, p []ProductSalesPeriod, err error)
 }
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"app/internal"
+	"context"
+)
+
+// NewCustomersCached creates a new customer service caching the reports of sv in rc.
+func NewCustomersCached(sv internal.ServiceCustomer, rc *ReportCache) *CustomersCached {
+	return &CustomersCached{sv, rc}
+}
+
+// CustomersCached is a customer service decorator that memoises the reports and purges them on writes.
+type CustomersCached struct {
+	// ServiceCustomer is the decorated service, the methods not overridden are passed through.
+	internal.ServiceCustomer
+	// rc is the cache of the reports.
+	rc *ReportCache
+}
+
+// Save saves the customer and purges the reports that depend on customers.
+func (s *CustomersCached) Save(ctx context.Context, c *internal.Customer) (err error) {
+	defer s.rc.purgeCustomers()
+
+	err = s.ServiceCustomer.Save(ctx, c)
+	return
+}
+
+// SaveBulk saves the customers in mode and purges the reports that depend on customers.
+func (s *CustomersCached) SaveBulk(ctx context.Context, c []internal.Customer, mode internal.BulkMode) (errs []error) {
+	defer s.rc.purgeCustomers()
+
+	errs = s.ServiceCustomer.SaveBulk(ctx, c, mode)
+	return
+}
+
+// FindTotalByCondition returns the aggregated money from invoices by customer condition, cached.
+func (s *CustomersCached) FindTotalByCondition(ctx context.Context) (t []internal.TotalByCondition, err error) {
+	t, hit, err := s.rc.totalByCondition.Load(struct{}{}, func() ([]internal.TotalByCondition, error) {
+		return s.ServiceCustomer.FindTotalByCondition(ctx)
+	})
+	observe("customers_total_by_condition", hit)
+	return
+}
+
+// FindTopActive returns the top n active customers by total spent, cached by n.
+func (s *CustomersCached) FindTopActive(ctx context.Context, n int) (c []internal.CustomerAmount, err error) {
+	c, hit, err := s.rc.topActive.Load(n, func() ([]internal.CustomerAmount, error) {
+		return s.ServiceCustomer.FindTopActive(ctx, n)
+	})
+	observe("customers_top_active", hit)
+	return
+}
@@ -0,0 +1,114 @@
+package service_test
+
+import (
+	"app/internal"
+	"app/internal/service"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// cacheRequests returns the number of lookups of report with result, hit or miss, counted by service.CacheRequests.
+func cacheRequests(t *testing.T, report, result string) (n int) {
+	var b strings.Builder
+	require.NoError(t, service.CacheRequests.Write(&b))
+	prefix := fmt.Sprintf(`report_cache_requests_total{report="%s",result="%s"} `, report, result)
+	for _, l := range strings.Split(b.String(), "\n") {
+		if v, ok := strings.CutPrefix(l, prefix); ok {
+			n, _ = strconv.Atoi(v)
+		}
+	}
+	return
+}
+
+// customerServiceStub is a customer service counting the reports it computes.
+type customerServiceStub struct {
+	internal.ServiceCustomer
+	totals, tops int
+}
+
+func (s *customerServiceStub) Save(ctx context.Context, c *internal.Customer) (err error) {
+	return
+}
+
+func (s *customerServiceStub) SaveBulk(ctx context.Context, c []internal.Customer, mode internal.BulkMode) (errs []error) {
+	errs = make([]error, len(c))
+	return
+}
+
+func (s *customerServiceStub) FindTotalByCondition(ctx context.Context) (t []internal.TotalByCondition, err error) {
+	s.totals++
+	t = []internal.TotalByCondition{{Condition: 1, Total: float64(s.totals)}}
+	return
+}
+
+func (s *customerServiceStub) FindTopActive(ctx context.Context, n int) (c []internal.CustomerAmount, err error) {
+	s.tops++
+	c = make([]internal.CustomerAmount, n)
+	return
+}
+
+// Tests for CustomersCached
+func TestCustomersCached(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("reports are computed once and their lookups counted", func(t *testing.T) {
+		// arrange
+		st := &customerServiceStub{}
+		sv := service.NewCustomersCached(st, service.NewReportCache(10, time.Hour))
+		hits, misses := cacheRequests(t, "customers_total_by_condition", "hit"), cacheRequests(t, "customers_total_by_condition", "miss")
+
+		// act
+		t1, err1 := sv.FindTotalByCondition(ctx)
+		t2, err2 := sv.FindTotalByCondition(ctx)
+		_, err3 := sv.FindTopActive(ctx, 3)
+		_, err4 := sv.FindTopActive(ctx, 3)
+		c, err5 := sv.FindTopActive(ctx, 5)
+
+		// assert
+		require.NoError(t, err1)
+		require.NoError(t, err2)
+		require.NoError(t, err3)
+		require.NoError(t, err4)
+		require.NoError(t, err5)
+		require.Equal(t, t1, t2)
+		require.Len(t, c, 5)
+		require.Equal(t, 1, st.totals)
+		require.Equal(t, 2, st.tops)
+		require.Equal(t, hits+1, cacheRequests(t, "customers_total_by_condition", "hit"))
+		require.Equal(t, misses+1, cacheRequests(t, "customers_total_by_condition", "miss"))
+	})
+
+	writes := map[string]func(sv *service.CustomersCached) error{
+		"Save": func(sv *service.CustomersCached) error {
+			return sv.Save(ctx, &internal.Customer{})
+		},
+		"SaveBulk": func(sv *service.CustomersCached) error {
+			return sv.SaveBulk(ctx, []internal.Customer{{}}, internal.BulkAtomic)[0]
+		},
+	}
+	for name, write := range writes {
+		t.Run(name+" purges the reports", func(t *testing.T) {
+			// arrange
+			st := &customerServiceStub{}
+			sv := service.NewCustomersCached(st, service.NewReportCache(10, time.Hour))
+			sv.FindTotalByCondition(ctx)
+			sv.FindTopActive(ctx, 3)
+
+			// act
+			err := write(sv)
+			sv.FindTotalByCondition(ctx)
+			sv.FindTopActive(ctx, 3)
+
+			// assert
+			require.NoError(t, err)
+			require.Equal(t, 2, st.totals)
+			require.Equal(t, 2, st.tops)
+		})
+	}
+}
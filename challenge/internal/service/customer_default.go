@@ -1,38 +1,149 @@
 package service
 
-import "app/internal"
+import (
+	"context"
+	"math"
+	"strconv"
+
+	"app/internal"
+	"app/platform/tracing"
+)
 
 // NewCustomersDefault creates new default service for customer entity.
-func NewCustomersDefault(rp internal.RepositoryCustomer) *CustomersDefault {
-	return &CustomersDefault{rp}
+func NewCustomersDefault(rp internal.RepositoryCustomer, rpInvoice internal.RepositoryInvoice) *CustomersDefault {
+	return &CustomersDefault{rp, rpInvoice}
 }
 
 // CustomersDefault is the default service implementation for customer entity.
 type CustomersDefault struct {
 	// rp is the repository for customer entity.
 	rp internal.RepositoryCustomer
+	// rpInvoice is the repository for invoice entity, used for the customer purchase history.
+	rpInvoice internal.RepositoryInvoice
 }
 
-// FindAll returns all customers.
-func (s *CustomersDefault) FindAll() (c []internal.Customer, err error) {
-	c, err = s.rp.FindAll()
+// FindAll returns a page of customers.
+func (s *CustomersDefault) FindAll(ctx context.Context, q internal.ListQuery) (c []internal.Customer, p internal.Page, err error) {
+	// trace
+	ctx, sp := tracing.Start(ctx, "CustomersDefault.FindAll")
+	defer sp.EndError(&err)
+
+	c, p, err = s.rp.FindAll(ctx, q)
+	return
+}
+
+// Stream calls fn with each customer of the list.
+func (s *CustomersDefault) Stream(ctx context.Context, q internal.ListQuery, fn func(cs internal.Customer) error) (err error) {
+	// trace
+	ctx, sp := tracing.Start(ctx, "CustomersDefault.Stream")
+	defer sp.EndError(&err)
+
+	err = s.rp.Stream(ctx, q, fn)
 	return
 }
 
 // Save saves the customer.
-func (s *CustomersDefault) Save(c *internal.Customer) (err error) {
-	err = s.rp.Save(c)
+func (s *CustomersDefault) Save(ctx context.Context, c *internal.Customer) (err error) {
+	// trace
+	ctx, sp := tracing.Start(ctx, "CustomersDefault.Save")
+	defer sp.EndError(&err)
+
+	err = s.rp.Save(ctx, c)
+	return
+}
+
+// SaveBulk saves the customers in mode.
+func (s *CustomersDefault) SaveBulk(ctx context.Context, c []internal.Customer, mode internal.BulkMode) (errs []error) {
+	// trace
+	ctx, sp := tracing.Start(ctx, "CustomersDefault.SaveBulk")
+	defer sp.End()
+	sp.SetAttribute("bulk.mode", string(mode))
+	sp.SetAttribute("bulk.items", len(c))
+
+	errs = s.rp.SaveBulk(ctx, c, mode)
 	return
 }
 
 // FindTotalByCondition returns the aggregated money from invoices by customer condition.
-func (s *CustomersDefault) FindTotalByCondition() (t []internal.TotalByCondition, err error) {
-	t, err = s.rp.FindTotalByCondition()
+func (s *CustomersDefault) FindTotalByCondition(ctx context.Context) (t []internal.TotalByCondition, err error) {
+	// trace
+	ctx, sp := tracing.Start(ctx, "CustomersDefault.FindTotalByCondition")
+	defer sp.EndError(&err)
+
+	t, err = s.rp.FindTotalByCondition(ctx)
 	return
 }
 
 // FindTopActive returns the top n active customers in the database by total spent.
-func (s *CustomersDefault) FindTopActive(n int) (c []internal.CustomerAmount, err error) {
-	c, err = s.rp.FindTopActive(n)
+func (s *CustomersDefault) FindTopActive(ctx context.Context, n int) (c []internal.CustomerAmount, err error) {
+	// trace
+	ctx, sp := tracing.Start(ctx, "CustomersDefault.FindTopActive")
+	defer sp.EndError(&err)
+
+	c, err = s.rp.FindTopActive(ctx, n)
+	return
+}
+
+// FindInvoices returns a page of the invoices of a customer with their lines, ordered by date unless sorted otherwise.
+func (s *CustomersDefault) FindInvoices(ctx context.Context, id int, q internal.ListQuery) (i []internal.InvoiceDetail, p internal.Page, err error) {
+	// trace
+	ctx, sp := tracing.Start(ctx, "CustomersDefault.FindInvoices")
+	defer sp.EndError(&err)
+
+	// check the customer exists
+	_, err = s.rp.FindById(ctx, id)
+	if err != nil {
+		return
+	}
+
+	// the page of the invoices filtered by the customer
+	if q.Sort == "" {
+		q.Sort = "datetime"
+	}
+	i, p, err = s.rpInvoice.FindAllDetail(ctx, q.WithFilter("customer_id", strconv.Itoa(id)), true)
+	return
+}
+
+// FindStatement returns the statement of a customer between from and to, both optional and inclusive.
+// amounts are rounded to the second decimal place.
+func (s *CustomersDefault) FindStatement(ctx context.Context, id int, from, to string) (st internal.CustomerStatement, err error) {
+	// trace
+	ctx, sp := tracing.Start(ctx, "CustomersDefault.FindStatement")
+	defer sp.EndError(&err)
+
+	// customer
+	c, err := s.rp.FindById(ctx, id)
+	if err != nil {
+		return
+	}
+
+	// invoices, ordered by date
+	i, err := s.rpInvoice.FindByCustomerId(ctx, id, from, to)
+	if err != nil {
+		return
+	}
+
+	// statement
+	st = internal.CustomerStatement{
+		Customer: c,
+		From:     from,
+		To:       to,
+		Entries:  make([]internal.StatementEntry, len(i)),
+	}
+	var balance float64
+	for ix, v := range i {
+		balance += v.Total
+		st.Entries[ix] = internal.StatementEntry{
+			InvoiceDetail: v,
+			Balance:       math.Round(balance*100) / 100,
+		}
+	}
+	st.TotalSpent = math.Round(balance*100) / 100
+	if len(i) > 0 {
+		st.AverageBasket = math.Round(balance/float64(len(i))*100) / 100
+		st.FirstPurchase = i[0].Datetime
+		st.LastPurchase = i[len(i)-1].Datetime
+	}
+
 	return
 }
@@ -0,0 +1,145 @@
+package service_test
+
+import (
+	"app/internal"
+	"app/internal/service"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// customersStub is a customer repository finding the customers in c.
+type customersStub struct {
+	internal.RepositoryCustomer
+	c map[int]internal.Customer
+}
+
+func (r *customersStub) FindById(ctx context.Context, id int) (c internal.Customer, err error) {
+	c, ok := r.c[id]
+	if !ok {
+		err = internal.ErrRepositoryCustomerNotFound
+	}
+	return
+}
+
+// invoicesStub is an invoice repository returning i as the invoices of any customer, recording the period and query asked for.
+type invoicesStub struct {
+	internal.RepositoryInvoice
+	i        []internal.InvoiceDetail
+	from, to string
+	q        internal.ListQuery
+}
+
+func (r *invoicesStub) FindAllDetail(ctx context.Context, q internal.ListQuery, lines bool) (i []internal.InvoiceDetail, p internal.Page, err error) {
+	r.q = q
+	i = r.i
+	return
+}
+
+func (r *invoicesStub) FindByCustomerId(ctx context.Context, customerId int, from, to string) (i []internal.InvoiceDetail, err error) {
+	r.from, r.to = from, to
+	i = r.i
+	return
+}
+
+// invoiceDetail returns an invoice detail of the tests.
+func invoiceDetail(id int, datetime string, total float64) internal.InvoiceDetail {
+	return internal.InvoiceDetail{Invoice: internal.Invoice{Id: id, InvoiceAttributes: internal.InvoiceAttributes{Datetime: datetime, Total: total, CustomerId: 1}}}
+}
+
+// Tests for CustomersDefault.FindStatement
+func TestCustomersDefaultFindStatement(t *testing.T) {
+	customer := internal.Customer{Id: 1, CustomerAttributes: internal.CustomerAttributes{FirstName: "John", LastName: "Doe", Condition: 1}}
+
+	t.Run("running balance, average basket and first and last purchase", func(t *testing.T) {
+		// arrange
+		rpInvoice := &invoicesStub{i: []internal.InvoiceDetail{
+			invoiceDetail(3, "2021-01-01 10:00:00", 10.105),
+			invoiceDetail(1, "2021-01-15 10:00:00", 20.2),
+			invoiceDetail(2, "2021-01-31 10:00:00", 0.1),
+		}}
+		sv := service.NewCustomersDefault(&customersStub{c: map[int]internal.Customer{1: customer}}, rpInvoice)
+
+		// act
+		st, err := sv.FindStatement(context.Background(), 1, "2021-01-01", "2021-01-31")
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, "2021-01-01", rpInvoice.from)
+		require.Equal(t, "2021-01-31", rpInvoice.to)
+		require.Equal(t, customer, st.Customer)
+		require.Equal(t, "2021-01-01", st.From)
+		require.Equal(t, "2021-01-31", st.To)
+		require.Len(t, st.Entries, 3)
+		require.Equal(t, 10.11, st.Entries[0].Balance)
+		require.Equal(t, 30.31, st.Entries[1].Balance)
+		require.Equal(t, 30.41, st.Entries[2].Balance)
+		require.Equal(t, 30.41, st.TotalSpent)
+		require.Equal(t, 10.14, st.AverageBasket)
+		require.Equal(t, "2021-01-01 10:00:00", st.FirstPurchase)
+		require.Equal(t, "2021-01-31 10:00:00", st.LastPurchase)
+	})
+
+	t.Run("a customer without invoices", func(t *testing.T) {
+		// arrange
+		sv := service.NewCustomersDefault(&customersStub{c: map[int]internal.Customer{1: customer}}, &invoicesStub{})
+
+		// act
+		st, err := sv.FindStatement(context.Background(), 1, "", "")
+
+		// assert
+		require.NoError(t, err)
+		require.Empty(t, st.Entries)
+		require.NotNil(t, st.Entries)
+		require.Zero(t, st.TotalSpent)
+		require.Zero(t, st.AverageBasket)
+		require.Empty(t, st.FirstPurchase)
+		require.Empty(t, st.LastPurchase)
+	})
+
+	t.Run("a customer that does not exist", func(t *testing.T) {
+		// arrange
+		sv := service.NewCustomersDefault(&customersStub{}, &invoicesStub{})
+
+		// act
+		_, err := sv.FindStatement(context.Background(), 1, "", "")
+
+		// assert
+		require.ErrorIs(t, err, internal.ErrRepositoryCustomerNotFound)
+	})
+}
+
+// Tests for CustomersDefault.FindInvoices
+func TestCustomersDefaultFindInvoices(t *testing.T) {
+	customer := internal.Customer{Id: 1, CustomerAttributes: internal.CustomerAttributes{FirstName: "John", LastName: "Doe", Condition: 1}}
+
+	t.Run("a page of the invoices of the customer, sorted by date by default", func(t *testing.T) {
+		// arrange
+		rpInvoice := &invoicesStub{i: []internal.InvoiceDetail{invoiceDetail(1, "2021-01-01 10:00:00", 10)}}
+		sv := service.NewCustomersDefault(&customersStub{c: map[int]internal.Customer{1: customer}}, rpInvoice)
+		q := internal.ListQuery{Limit: 10, Filters: map[string]string{"customer_id": "2", "total_min": "5"}}
+
+		// act
+		i, _, err := sv.FindInvoices(context.Background(), 1, q)
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, rpInvoice.i, i)
+		require.Equal(t, "datetime", rpInvoice.q.Sort)
+		require.Equal(t, 10, rpInvoice.q.Limit)
+		require.Equal(t, map[string]string{"customer_id": "1", "total_min": "5"}, rpInvoice.q.Filters)
+		require.Equal(t, "2", q.Filters["customer_id"])
+	})
+
+	t.Run("a customer that does not exist", func(t *testing.T) {
+		// arrange
+		sv := service.NewCustomersDefault(&customersStub{}, &invoicesStub{})
+
+		// act
+		_, _, err := sv.FindInvoices(context.Background(), 1, internal.ListQuery{})
+
+		// assert
+		require.ErrorIs(t, err, internal.ErrRepositoryCustomerNotFound)
+	})
+}
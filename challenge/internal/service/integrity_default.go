@@ -0,0 +1,141 @@
+package service
+
+import (
+	"app/internal"
+	"app/platform/tracing"
+	"context"
+)
+
+// NewIntegrityDefault creates new default service for the integrity checks.
+// sampleSize is the maximum number of ids reported per check.
+func NewIntegrityDefault(rp internal.RepositoryIntegrity, sampleSize int) *IntegrityDefault {
+	return &IntegrityDefault{rp, sampleSize}
+}
+
+// IntegrityDefault is the default service implementation for the integrity checks.
+type IntegrityDefault struct {
+	// rp is the repository for the integrity checks.
+	rp internal.RepositoryIntegrity
+	// sampleSize is the maximum number of ids reported per check.
+	sampleSize int
+}
+
+// Check runs every integrity check and returns the report.
+// Offending rows found by more than one check are only included once in the report.
+func (s *IntegrityDefault) Check(ctx context.Context) (r internal.IntegrityReport, err error) {
+	// trace
+	ctx, sp := tracing.Start(ctx, "IntegrityDefault.Check")
+	defer sp.EndError(&err)
+
+	// offending rows by id, to avoid duplicates between checks
+	customers := make(map[int]bool)
+	invoices := make(map[int]bool)
+	products := make(map[int]bool)
+	sales := make(map[int]bool)
+
+	// - sales
+	salesChecks := []struct {
+		name string
+		find func(context.Context) ([]internal.Sale, error)
+	}{
+		{"orphan_sales", s.rp.FindOrphanSales},
+		{"sales_non_positive_quantity", s.rp.FindSalesNonPositiveQuantity},
+	}
+	for _, c := range salesChecks {
+		var sa []internal.Sale
+		sa, err = c.find(ctx)
+		if err != nil {
+			return
+		}
+		ids := make([]int, len(sa))
+		for ix, v := range sa {
+			ids[ix] = v.Id
+			if !sales[v.Id] {
+				sales[v.Id] = true
+				r.Sales = append(r.Sales, v)
+			}
+		}
+		r.Issues = append(r.Issues, s.issue(c.name, "sales", ids))
+	}
+
+	// - invoices
+	iv, err := s.rp.FindInvoicesWithoutSales(ctx)
+	if err != nil {
+		return
+	}
+	ids := make([]int, len(iv))
+	for ix, v := range iv {
+		ids[ix] = v.Id
+		if !invoices[v.Id] {
+			invoices[v.Id] = true
+			r.Invoices = append(r.Invoices, v)
+		}
+	}
+	r.Issues = append(r.Issues, s.issue("invoices_without_sales", "invoices", ids))
+
+	// - customers
+	customersChecks := []struct {
+		name string
+		find func(context.Context) ([]internal.Customer, error)
+	}{
+		{"customers_without_invoices", s.rp.FindCustomersWithoutInvoices},
+		{"duplicate_customers", s.rp.FindDuplicateCustomers},
+	}
+	for _, c := range customersChecks {
+		var cs []internal.Customer
+		cs, err = c.find(ctx)
+		if err != nil {
+			return
+		}
+		ids := make([]int, len(cs))
+		for ix, v := range cs {
+			ids[ix] = v.Id
+			if !customers[v.Id] {
+				customers[v.Id] = true
+				r.Customers = append(r.Customers, v)
+			}
+		}
+		r.Issues = append(r.Issues, s.issue(c.name, "customers", ids))
+	}
+
+	// - products
+	productsChecks := []struct {
+		name string
+		find func(context.Context) ([]internal.Product, error)
+	}{
+		{"products_never_sold", s.rp.FindProductsNeverSold},
+		{"products_non_positive_price", s.rp.FindProductsNonPositivePrice},
+	}
+	for _, c := range productsChecks {
+		var pr []internal.Product
+		pr, err = c.find(ctx)
+		if err != nil {
+			return
+		}
+		ids := make([]int, len(pr))
+		for ix, v := range pr {
+			ids[ix] = v.Id
+			if !products[v.Id] {
+				products[v.Id] = true
+				r.Products = append(r.Products, v)
+			}
+		}
+		r.Issues = append(r.Issues, s.issue(c.name, "products", ids))
+	}
+
+	return
+}
+
+// issue builds the result of a check, keeping at most sampleSize ids.
+func (s *IntegrityDefault) issue(check, entity string, ids []int) (i internal.IntegrityIssue) {
+	i = internal.IntegrityIssue{
+		Check:     check,
+		Entity:    entity,
+		Count:     len(ids),
+		SampleIds: ids,
+	}
+	if s.sampleSize >= 0 && len(ids) > s.sampleSize {
+		i.SampleIds = ids[:s.sampleSize]
+	}
+	return
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"app/internal"
+	"context"
+)
+
+// NewInvoicesCached creates a new invoice service purging the reports of rc that sv writes affect.
+func NewInvoicesCached(sv internal.ServiceInvoice, rc *ReportCache) *InvoicesCached {
+	return &InvoicesCached{sv, rc}
+}
+
+// InvoicesCached is an invoice service decorator that purges the customer reports on writes, as they aggregate invoices.
+type InvoicesCached struct {
+	// ServiceInvoice is the decorated service, the methods not overridden are passed through.
+	internal.ServiceInvoice
+	// rc is the cache of the reports.
+	rc *ReportCache
+}
+
+// Save saves the invoice and purges the reports that depend on invoices.
+func (s *InvoicesCached) Save(ctx context.Context, i *internal.Invoice) (err error) {
+	defer s.rc.purgeCustomers()
+
+	err = s.ServiceInvoice.Save(ctx, i)
+	return
+}
+
+// SaveBulk saves the invoices in mode and purges the reports that depend on invoices.
+func (s *InvoicesCached) SaveBulk(ctx context.Context, i []internal.Invoice, mode internal.BulkMode) (errs []error) {
+	defer s.rc.purgeCustomers()
+
+	errs = s.ServiceInvoice.SaveBulk(ctx, i, mode)
+	return
+}
+
+// UpdateTotal updates the total of all invoices and purges the reports that depend on invoices.
+func (s *InvoicesCached) UpdateTotal(ctx context.Context) (totalUpdated int, err error) {
+	defer s.rc.purgeCustomers()
+
+	totalUpdated, err = s.ServiceInvoice.UpdateTotal(ctx)
+	return
+}
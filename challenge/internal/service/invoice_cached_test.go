@@ -0,0 +1,68 @@
+package service_test
+
+import (
+	"app/internal"
+	"app/internal/service"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// invoiceServiceStub is an invoice service whose writes succeed without doing anything.
+type invoiceServiceStub struct {
+	internal.ServiceInvoice
+}
+
+func (s *invoiceServiceStub) Save(ctx context.Context, i *internal.Invoice) (err error) {
+	return
+}
+
+func (s *invoiceServiceStub) SaveBulk(ctx context.Context, i []internal.Invoice, mode internal.BulkMode) (errs []error) {
+	errs = make([]error, len(i))
+	return
+}
+
+func (s *invoiceServiceStub) UpdateTotal(ctx context.Context) (totalUpdated int, err error) {
+	return
+}
+
+// Tests for InvoicesCached
+func TestInvoicesCached(t *testing.T) {
+	ctx := context.Background()
+
+	writes := map[string]func(sv *service.InvoicesCached) error{
+		"Save": func(sv *service.InvoicesCached) error {
+			return sv.Save(ctx, &internal.Invoice{})
+		},
+		"SaveBulk": func(sv *service.InvoicesCached) error {
+			return sv.SaveBulk(ctx, []internal.Invoice{{}}, internal.BulkAtomic)[0]
+		},
+		"UpdateTotal": func(sv *service.InvoicesCached) error {
+			_, err := sv.UpdateTotal(ctx)
+			return err
+		},
+	}
+	for name, write := range writes {
+		t.Run(name+" purges the customer reports", func(t *testing.T) {
+			// arrange
+			rc := service.NewReportCache(10, time.Hour)
+			st := &customerServiceStub{}
+			svCustomer := service.NewCustomersCached(st, rc)
+			sv := service.NewInvoicesCached(&invoiceServiceStub{}, rc)
+			svCustomer.FindTotalByCondition(ctx)
+			svCustomer.FindTopActive(ctx, 3)
+
+			// act
+			err := write(sv)
+			svCustomer.FindTotalByCondition(ctx)
+			svCustomer.FindTopActive(ctx, 3)
+
+			// assert
+			require.NoError(t, err)
+			require.Equal(t, 2, st.totals)
+			require.Equal(t, 2, st.tops)
+		})
+	}
+}
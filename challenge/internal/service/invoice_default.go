@@ -1,6 +1,10 @@
 package service
 
-import "app/internal"
+import (
+	"app/internal"
+	"app/platform/tracing"
+	"context"
+)
 
 // NewInvoicesDefault creates new default service for invoice entity.
 func NewInvoicesDefault(rp internal.RepositoryInvoice) *InvoicesDefault {
@@ -13,20 +17,74 @@ type InvoicesDefault struct {
 	rp internal.RepositoryInvoice
 }
 
-// FindAll returns all invoices.
-func (s *InvoicesDefault) FindAll() (i []internal.Invoice, err error) {
-	i, err = s.rp.FindAll()
+// FindAll returns a page of invoices.
+func (s *InvoicesDefault) FindAll(ctx context.Context, q internal.ListQuery) (i []internal.Invoice, p internal.Page, err error) {
+	// trace
+	ctx, sp := tracing.Start(ctx, "InvoicesDefault.FindAll")
+	defer sp.EndError(&err)
+
+	i, p, err = s.rp.FindAll(ctx, q)
+	return
+}
+
+// Stream calls fn with each invoice of the list.
+func (s *InvoicesDefault) Stream(ctx context.Context, q internal.ListQuery, fn func(iv internal.Invoice) error) (err error) {
+	// trace
+	ctx, sp := tracing.Start(ctx, "InvoicesDefault.Stream")
+	defer sp.EndError(&err)
+
+	err = s.rp.Stream(ctx, q, fn)
+	return
+}
+
+// FindById returns the invoice with its customer and lines.
+func (s *InvoicesDefault) FindById(ctx context.Context, id int) (i internal.InvoiceDetail, err error) {
+	// trace
+	ctx, sp := tracing.Start(ctx, "InvoicesDefault.FindById")
+	defer sp.EndError(&err)
+
+	i, err = s.rp.FindById(ctx, id)
+	return
+}
+
+// FindAllDetail returns a page of invoices with their customer and, if lines, their lines.
+func (s *InvoicesDefault) FindAllDetail(ctx context.Context, q internal.ListQuery, lines bool) (i []internal.InvoiceDetail, p internal.Page, err error) {
+	// trace
+	ctx, sp := tracing.Start(ctx, "InvoicesDefault.FindAllDetail")
+	defer sp.EndError(&err)
+
+	i, p, err = s.rp.FindAllDetail(ctx, q, lines)
 	return
 }
 
 // Save saves the invoice.
-func (s *InvoicesDefault) Save(i *internal.Invoice) (err error) {
-	err = s.rp.Save(i)
+func (s *InvoicesDefault) Save(ctx context.Context, i *internal.Invoice) (err error) {
+	// trace
+	ctx, sp := tracing.Start(ctx, "InvoicesDefault.Save")
+	defer sp.EndError(&err)
+
+	err = s.rp.Save(ctx, i)
+	return
+}
+
+// SaveBulk saves the invoices in mode.
+func (s *InvoicesDefault) SaveBulk(ctx context.Context, i []internal.Invoice, mode internal.BulkMode) (errs []error) {
+	// trace
+	ctx, sp := tracing.Start(ctx, "InvoicesDefault.SaveBulk")
+	defer sp.End()
+	sp.SetAttribute("bulk.mode", string(mode))
+	sp.SetAttribute("bulk.items", len(i))
+
+	errs = s.rp.SaveBulk(ctx, i, mode)
 	return
 }
 
 // UpdateInvoicesTotal updates the total of all invoices.
-func (s *InvoicesDefault) UpdateTotal() (updated int, err error) {
-	updated, err = s.rp.UpdateTotal()
+func (s *InvoicesDefault) UpdateTotal(ctx context.Context) (updated int, err error) {
+	// trace
+	ctx, sp := tracing.Start(ctx, "InvoicesDefault.UpdateTotal")
+	defer sp.EndError(&err)
+
+	updated, err = s.rp.UpdateTotal(ctx)
 	return
 }
@@ -1,6 +1,10 @@
 package service
 
-import "app/internal"
+import (
+	"app/internal"
+	"app/platform/tracing"
+	"context"
+)
 
 // NewProductsDefault creates new default service for product entity.
 func NewProductsDefault(rp internal.RepositoryProduct) *ProductsDefault {
@@ -13,14 +17,44 @@ type ProductsDefault struct {
 	rp internal.RepositoryProduct
 }
 
-// FindAll returns all products.
-func (s *ProductsDefault) FindAll() (p []internal.Product, err error) {
-	p, err = s.rp.FindAll()
+// FindAll returns a page of products.
+func (s *ProductsDefault) FindAll(ctx context.Context, q internal.ListQuery) (p []internal.Product, pg internal.Page, err error) {
+	// trace
+	ctx, sp := tracing.Start(ctx, "ProductsDefault.FindAll")
+	defer sp.EndError(&err)
+
+	p, pg, err = s.rp.FindAll(ctx, q)
+	return
+}
+
+// Stream calls fn with each product of the list.
+func (s *ProductsDefault) Stream(ctx context.Context, q internal.ListQuery, fn func(pr internal.Product) error) (err error) {
+	// trace
+	ctx, sp := tracing.Start(ctx, "ProductsDefault.Stream")
+	defer sp.EndError(&err)
+
+	err = s.rp.Stream(ctx, q, fn)
 	return
 }
 
 // Save saves the product.
-func (s *ProductsDefault) Save(p *internal.Product) (err error) {
-	err = s.rp.Save(p)
+func (s *ProductsDefault) Save(ctx context.Context, p *internal.Product) (err error) {
+	// trace
+	ctx, sp := tracing.Start(ctx, "ProductsDefault.Save")
+	defer sp.EndError(&err)
+
+	err = s.rp.Save(ctx, p)
 	return
-}
\ No newline at end of file
+}
+
+// SaveBulk saves the products in mode.
+func (s *ProductsDefault) SaveBulk(ctx context.Context, p []internal.Product, mode internal.BulkMode) (errs []error) {
+	// trace
+	ctx, sp := tracing.Start(ctx, "ProductsDefault.SaveBulk")
+	defer sp.End()
+	sp.SetAttribute("bulk.mode", string(mode))
+	sp.SetAttribute("bulk.items", len(p))
+
+	errs = s.rp.SaveBulk(ctx, p, mode)
+	return
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"app/internal"
+	"app/platform/cache"
+	"app/platform/metrics"
+	"time"
+)
+
+// CacheRequests counts the report cache lookups by report and result, hit or miss.
+var CacheRequests = metrics.NewCounterVec("report_cache_requests_total", "Report cache lookups by report and result (hit or miss).", "report", "result")
+
+// NewReportCache creates a new ReportCache, each report holding at most size results for ttl.
+func NewReportCache(size int, ttl time.Duration) *ReportCache {
+	return &ReportCache{
+		totalByCondition: cache.New[struct{}, []internal.TotalByCondition](1, ttl),
+		topActive:        cache.New[int, []internal.CustomerAmount](size, ttl),
+		topSold:          cache.New[int, []internal.ProductSales](size, ttl),
+	}
+}
+
+// ReportCache memoises the results of the reports that aggregate whole tables.
+// It is shared by the cached services, which purge the reports that depend on what they write.
+// Writes that bypass the services, e.g. the migrate application, are only seen once the results expire.
+type ReportCache struct {
+	// totalByCondition is the result of CustomersCached.FindTotalByCondition, it depends on customers and invoices.
+	totalByCondition *cache.Cache[struct{}, []internal.TotalByCondition]
+	// topActive are the results of CustomersCached.FindTopActive by n, they depend on customers and invoices.
+	topActive *cache.Cache[int, []internal.CustomerAmount]
+	// topSold are the results of SalesCached.FindTopSold by n, they depend on sales.
+	topSold *cache.Cache[int, []internal.ProductSales]
+}
+
+// purgeCustomers purges the reports that depend on customers or invoices.
+func (rc *ReportCache) purgeCustomers() {
+	rc.totalByCondition.Purge()
+	rc.topActive.Purge()
+}
+
+// purgeSales purges the reports that depend on sales.
+func (rc *ReportCache) purgeSales() {
+	rc.topSold.Purge()
+}
+
+// observe counts a lookup of report.
+func observe(report string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	CacheRequests.Inc(report, result)
+}
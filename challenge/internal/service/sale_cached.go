@@ -0,0 +1,44 @@
+package service
+
+import (
+	"app/internal"
+	"context"
+)
+
+// NewSalesCached creates a new sale service caching the reports of sv in rc.
+func NewSalesCached(sv internal.ServiceSale, rc *ReportCache) *SalesCached {
+	return &SalesCached{sv, rc}
+}
+
+// SalesCached is a sale service decorator that memoises the reports and purges them on writes.
+type SalesCached struct {
+	// ServiceSale is the decorated service, the methods not overridden are passed through.
+	internal.ServiceSale
+	// rc is the cache of the reports.
+	rc *ReportCache
+}
+
+// Save saves the sale and purges the reports that depend on sales.
+func (sv *SalesCached) Save(ctx context.Context, s *internal.Sale) (err error) {
+	defer sv.rc.purgeSales()
+
+	err = sv.ServiceSale.Save(ctx, s)
+	return
+}
+
+// SaveBulk saves the sales in mode and purges the reports that depend on sales.
+func (sv *SalesCached) SaveBulk(ctx context.Context, s []internal.Sale, mode internal.BulkMode) (errs []error) {
+	defer sv.rc.purgeSales()
+
+	errs = sv.ServiceSale.SaveBulk(ctx, s, mode)
+	return
+}
+
+// FindTopSold returns the top n products sold, cached by n.
+func (sv *SalesCached) FindTopSold(ctx context.Context, n int) (p []internal.ProductSales, err error) {
+	p, hit, err := sv.rc.topSold.Load(n, func() ([]internal.ProductSales, error) {
+		return sv.ServiceSale.FindTopSold(ctx, n)
+	})
+	observe("sales_top_sold", hit)
+	return
+}
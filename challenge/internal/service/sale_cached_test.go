@@ -0,0 +1,99 @@
+package service_test
+
+import (
+	"app/internal"
+	"app/internal/service"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// saleServiceStub is a sale service counting the reports it computes.
+type saleServiceStub struct {
+	internal.ServiceSale
+	tops int
+}
+
+func (s *saleServiceStub) Save(ctx context.Context, sa *internal.Sale) (err error) {
+	return
+}
+
+func (s *saleServiceStub) SaveBulk(ctx context.Context, sa []internal.Sale, mode internal.BulkMode) (errs []error) {
+	errs = make([]error, len(sa))
+	return
+}
+
+func (s *saleServiceStub) FindTopSold(ctx context.Context, n int) (p []internal.ProductSales, err error) {
+	s.tops++
+	p = make([]internal.ProductSales, n)
+	return
+}
+
+// Tests for SalesCached
+func TestSalesCached(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("the top sold are computed once by n and their lookups counted", func(t *testing.T) {
+		// arrange
+		st := &saleServiceStub{}
+		sv := service.NewSalesCached(st, service.NewReportCache(10, time.Hour))
+		hits, misses := cacheRequests(t, "sales_top_sold", "hit"), cacheRequests(t, "sales_top_sold", "miss")
+
+		// act
+		_, err1 := sv.FindTopSold(ctx, 3)
+		_, err2 := sv.FindTopSold(ctx, 3)
+		p, err3 := sv.FindTopSold(ctx, 5)
+
+		// assert
+		require.NoError(t, err1)
+		require.NoError(t, err2)
+		require.NoError(t, err3)
+		require.Len(t, p, 5)
+		require.Equal(t, 2, st.tops)
+		require.Equal(t, hits+1, cacheRequests(t, "sales_top_sold", "hit"))
+		require.Equal(t, misses+2, cacheRequests(t, "sales_top_sold", "miss"))
+	})
+
+	writes := map[string]func(sv *service.SalesCached) error{
+		"Save": func(sv *service.SalesCached) error {
+			return sv.Save(ctx, &internal.Sale{})
+		},
+		"SaveBulk": func(sv *service.SalesCached) error {
+			return sv.SaveBulk(ctx, []internal.Sale{{}}, internal.BulkAtomic)[0]
+		},
+	}
+	for name, write := range writes {
+		t.Run(name+" purges the top sold", func(t *testing.T) {
+			// arrange
+			st := &saleServiceStub{}
+			sv := service.NewSalesCached(st, service.NewReportCache(10, time.Hour))
+			sv.FindTopSold(ctx, 3)
+
+			// act
+			err := write(sv)
+			sv.FindTopSold(ctx, 3)
+
+			// assert
+			require.NoError(t, err)
+			require.Equal(t, 2, st.tops)
+		})
+	}
+
+	t.Run("customer writes do not purge the top sold", func(t *testing.T) {
+		// arrange
+		rc := service.NewReportCache(10, time.Hour)
+		st := &saleServiceStub{}
+		sv := service.NewSalesCached(st, rc)
+		sv.FindTopSold(ctx, 3)
+
+		// act
+		err := service.NewCustomersCached(&customerServiceStub{}, rc).Save(ctx, &internal.Customer{})
+		sv.FindTopSold(ctx, 3)
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, 1, st.tops)
+	})
+}
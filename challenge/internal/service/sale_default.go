@@ -1,32 +1,92 @@
 package service
 
-import "app/internal"
+import (
+	"app/internal"
+	"app/platform/tracing"
+	"context"
+)
 
 // NewSalesDefault creates new default service for sale entity.
-func NewSalesDefault(rp internal.RepositorySale) *SalesDefault {
-	return &SalesDefault{rp}
+func NewSalesDefault(rp internal.RepositorySale, rpProduct internal.RepositoryProduct) *SalesDefault {
+	return &SalesDefault{rp, rpProduct}
 }
 
 // SalesDefault is the default service implementation for sale entity.
 type SalesDefault struct {
 	// rp is the repository for sale entity.
 	rp internal.RepositorySale
+	// rpProduct is the repository for product entity, used for the product sales history.
+	rpProduct internal.RepositoryProduct
 }
 
-// FindAll returns all sales.
-func (sv *SalesDefault) FindAll() (s []internal.Sale, err error) {
-	s, err = sv.rp.FindAll()
+// FindAll returns a page of sales.
+func (sv *SalesDefault) FindAll(ctx context.Context, q internal.ListQuery) (s []internal.Sale, p internal.Page, err error) {
+	// trace
+	ctx, sp := tracing.Start(ctx, "SalesDefault.FindAll")
+	defer sp.EndError(&err)
+
+	s, p, err = sv.rp.FindAll(ctx, q)
+	return
+}
+
+// Stream calls fn with each sale of the list.
+func (sv *SalesDefault) Stream(ctx context.Context, q internal.ListQuery, fn func(sa internal.Sale) error) (err error) {
+	// trace
+	ctx, sp := tracing.Start(ctx, "SalesDefault.Stream")
+	defer sp.EndError(&err)
+
+	err = sv.rp.Stream(ctx, q, fn)
 	return
 }
 
 // Save saves the sale.
-func (sv *SalesDefault) Save(s *internal.Sale) (err error) {
-	err = sv.rp.Save(s)
+func (sv *SalesDefault) Save(ctx context.Context, s *internal.Sale) (err error) {
+	// trace
+	ctx, sp := tracing.Start(ctx, "SalesDefault.Save")
+	defer sp.EndError(&err)
+
+	err = sv.rp.Save(ctx, s)
+	return
+}
+
+// SaveBulk saves the sales in mode.
+func (sv *SalesDefault) SaveBulk(ctx context.Context, s []internal.Sale, mode internal.BulkMode) (errs []error) {
+	// trace
+	ctx, sp := tracing.Start(ctx, "SalesDefault.SaveBulk")
+	defer sp.End()
+	sp.SetAttribute("bulk.mode", string(mode))
+	sp.SetAttribute("bulk.items", len(s))
+
+	errs = sv.rp.SaveBulk(ctx, s, mode)
 	return
 }
 
 // FindTopSold returns the top n products sold in the database.
-func (sv *SalesDefault) FindTopSold(n int) (p []internal.ProductSales, err error) {
-	p, err = sv.rp.FindTopSold(n)
+func (sv *SalesDefault) FindTopSold(ctx context.Context, n int) (p []internal.ProductSales, err error) {
+	// trace
+	ctx, sp := tracing.Start(ctx, "SalesDefault.FindTopSold")
+	defer sp.EndError(&err)
+
+	p, err = sv.rp.FindTopSold(ctx, n)
+	return
+}
+
+// FindByProductId returns a page of the sales of a product and its units and revenue aggregated by day or month.
+func (sv *SalesDefault) FindByProductId(ctx context.Context, productId int, q internal.ListQuery, period string) (s []internal.ProductSale, pg internal.Page, p []internal.ProductSalesPeriod, err error) {
+	// trace
+	ctx, sp := tracing.Start(ctx, "SalesDefault.FindByProductId")
+	defer sp.EndError(&err)
+
+	// check the product exists
+	_, err = sv.rpProduct.FindById(ctx, productId)
+	if err != nil {
+		return
+	}
+
+	s, pg, err = sv.rp.FindByProductId(ctx, productId, q)
+	if err != nil {
+		return
+	}
+	p, err = sv.rp.FindByProductIdPerPeriod(ctx, productId, period)
 	return
 }
@@ -0,0 +1,76 @@
+package service_test
+
+import (
+	"app/internal"
+	"app/internal/service"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// productsStub is a product repository finding the products in p, counting the lookups.
+type productsStub struct {
+	internal.RepositoryProduct
+	p     map[int]internal.Product
+	finds int
+}
+
+func (r *productsStub) FindById(ctx context.Context, id int) (p internal.Product, err error) {
+	r.finds++
+	p, ok := r.p[id]
+	if !ok {
+		err = internal.ErrRepositoryProductNotFound
+	}
+	return
+}
+
+// salesStub is a sale repository returning s and p as the sales of any product.
+type salesStub struct {
+	internal.RepositorySale
+	s []internal.ProductSale
+	p []internal.ProductSalesPeriod
+}
+
+func (r *salesStub) FindByProductId(ctx context.Context, productId int, q internal.ListQuery) (s []internal.ProductSale, pg internal.Page, err error) {
+	s = r.s
+	return
+}
+
+func (r *salesStub) FindByProductIdPerPeriod(ctx context.Context, productId int, period string) (p []internal.ProductSalesPeriod, err error) {
+	p = r.p
+	return
+}
+
+// Tests for SalesDefault.FindByProductId
+func TestSalesDefaultFindByProductId(t *testing.T) {
+	t.Run("sales and aggregates of a product, looked up once", func(t *testing.T) {
+		// arrange
+		rpProduct := &productsStub{p: map[int]internal.Product{1: {Id: 1}}}
+		rp := &salesStub{
+			s: []internal.ProductSale{{SaleId: 1, Quantity: 2}},
+			p: []internal.ProductSalesPeriod{{Period: "2021-01-01", Units: 2}},
+		}
+		sv := service.NewSalesDefault(rp, rpProduct)
+
+		// act
+		s, _, p, err := sv.FindByProductId(context.Background(), 1, internal.ListQuery{Limit: 10}, internal.PeriodDay)
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, rp.s, s)
+		require.Equal(t, rp.p, p)
+		require.Equal(t, 1, rpProduct.finds)
+	})
+
+	t.Run("a product that does not exist", func(t *testing.T) {
+		// arrange
+		sv := service.NewSalesDefault(&salesStub{}, &productsStub{})
+
+		// act
+		_, _, _, err := sv.FindByProductId(context.Background(), 1, internal.ListQuery{Limit: 10}, internal.PeriodDay)
+
+		// assert
+		require.ErrorIs(t, err, internal.ErrRepositoryProductNotFound)
+	})
+}
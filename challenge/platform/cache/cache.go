@@ -0,0 +1,142 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// New creates a new Cache holding at most size entries, each for ttl.
+// A non positive size defaults to 1.
+func New[K comparable, V any](size int, ttl time.Duration) (c *Cache[K, V]) {
+	if size <= 0 {
+		size = 1
+	}
+	c = &Cache[K, V]{
+		size:    size,
+		ttl:     ttl,
+		entries: make(map[K]*list.Element),
+		lru:     list.New(),
+		now:     time.Now,
+	}
+	return
+}
+
+// Cache is a memoising cache bounded in size, evicting the least recently used entry, and in age, expiring entries after a ttl.
+// It is safe for concurrent use.
+type Cache[K comparable, V any] struct {
+	// size is the maximum number of entries.
+	size int
+	// ttl is the duration an entry is valid for.
+	ttl time.Duration
+	// mu guards the fields below.
+	mu sync.Mutex
+	// entries are the elements of lru by key.
+	entries map[K]*list.Element
+	// lru orders the entries from the most to the least recently used.
+	lru *list.List
+	// generation is incremented by Purge, so that a value loaded across a purge is not stored.
+	generation uint64
+	// now returns the current time.
+	now func() time.Time
+}
+
+// entry is an entry of the cache.
+type entry[K comparable, V any] struct {
+	key     K
+	value   V
+	expires time.Time
+}
+
+// Get returns the value of k, ok is false if it is missing or expired.
+func (c *Cache[K, V]) Get(k K) (v V, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[k]
+	if !ok {
+		return
+	}
+	e := el.Value.(*entry[K, V])
+	if !c.now().Before(e.expires) {
+		c.remove(el)
+		ok = false
+		return
+	}
+	c.lru.MoveToFront(el)
+	v = e.value
+	return
+}
+
+// Load returns the value of k, calling fn to load and store it when missing. hit reports whether it was cached.
+// fn is called without holding the lock, and errors are not cached.
+// If the cache is purged while fn runs, the value is returned but not stored, as it may predate the write that purged it.
+func (c *Cache[K, V]) Load(k K, fn func() (V, error)) (v V, hit bool, err error) {
+	v, hit = c.Get(k)
+	if hit {
+		return
+	}
+
+	c.mu.Lock()
+	gen := c.generation
+	c.mu.Unlock()
+
+	v, err = fn()
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen == c.generation {
+		c.set(k, v)
+	}
+	return
+}
+
+// Set stores v as the value of k.
+func (c *Cache[K, V]) Set(k K, v V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.set(k, v)
+}
+
+// Purge removes every entry.
+func (c *Cache[K, V]) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[K]*list.Element)
+	c.lru.Init()
+	c.generation++
+}
+
+// Len returns the number of entries, including the expired ones not evicted yet.
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lru.Len()
+}
+
+// set stores v as the value of k, evicting the least recently used entry if the cache is full.
+func (c *Cache[K, V]) set(k K, v V) {
+	expires := c.now().Add(c.ttl)
+	if el, ok := c.entries[k]; ok {
+		e := el.Value.(*entry[K, V])
+		e.value, e.expires = v, expires
+		c.lru.MoveToFront(el)
+		return
+	}
+	if c.lru.Len() >= c.size {
+		c.remove(c.lru.Back())
+	}
+	c.entries[k] = c.lru.PushFront(&entry[K, V]{key: k, value: v, expires: expires})
+}
+
+// remove removes el.
+func (c *Cache[K, V]) remove(el *list.Element) {
+	c.lru.Remove(el)
+	delete(c.entries, el.Value.(*entry[K, V]).key)
+}
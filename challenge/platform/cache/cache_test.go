@@ -0,0 +1,116 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newTestCache returns a cache whose clock is advanced by the returned function.
+func newTestCache(size int, ttl time.Duration) (c *Cache[string, int], advance func(time.Duration)) {
+	now := time.Unix(1700000000, 0)
+	c = New[string, int](size, ttl)
+	c.now = func() time.Time { return now }
+	advance = func(d time.Duration) { now = now.Add(d) }
+	return
+}
+
+// Tests for Cache
+func TestCache(t *testing.T) {
+	t.Run("expires entries after the ttl", func(t *testing.T) {
+		// arrange
+		c, advance := newTestCache(2, time.Minute)
+		c.Set("a", 1)
+
+		// act & assert
+		v, ok := c.Get("a")
+		require.True(t, ok)
+		require.Equal(t, 1, v)
+		advance(time.Minute)
+		_, ok = c.Get("a")
+		require.False(t, ok)
+		require.Equal(t, 0, c.Len())
+	})
+
+	t.Run("evicts the least recently used entry", func(t *testing.T) {
+		// arrange
+		c, _ := newTestCache(2, time.Minute)
+		c.Set("a", 1)
+		c.Set("b", 2)
+		c.Get("a")
+
+		// act
+		c.Set("c", 3)
+
+		// assert
+		_, ok := c.Get("b")
+		require.False(t, ok)
+		_, ok = c.Get("a")
+		require.True(t, ok)
+		require.Equal(t, 2, c.Len())
+	})
+
+	t.Run("loads missing entries once and does not cache errors", func(t *testing.T) {
+		// arrange
+		c, _ := newTestCache(2, time.Minute)
+		calls := 0
+		fn := func() (int, error) { calls++; return 7, nil }
+
+		// act
+		_, _, err := c.Load("a", func() (int, error) { return 0, errors.New("boom") })
+		require.Error(t, err)
+		v1, hit1, err1 := c.Load("a", fn)
+		v2, hit2, err2 := c.Load("a", fn)
+
+		// assert
+		require.NoError(t, err1)
+		require.NoError(t, err2)
+		require.Equal(t, 7, v1)
+		require.Equal(t, 7, v2)
+		require.False(t, hit1)
+		require.True(t, hit2)
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run("does not store a value loaded across a purge", func(t *testing.T) {
+		// arrange
+		c, _ := newTestCache(2, time.Minute)
+
+		// act
+		v, _, err := c.Load("a", func() (int, error) {
+			c.Purge()
+			return 1, nil
+		})
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, 1, v)
+		_, ok := c.Get("a")
+		require.False(t, ok)
+	})
+
+	t.Run("is safe for concurrent use", func(t *testing.T) {
+		// arrange
+		c := New[int, int](10, time.Minute)
+
+		// act
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				c.Load(i%20, func() (int, error) { return i, nil })
+				if i%10 == 0 {
+					c.Purge()
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		// assert
+		require.LessOrEqual(t, c.Len(), 10)
+	})
+}
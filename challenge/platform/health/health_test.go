@@ -0,0 +1,122 @@
+package health_test
+
+import (
+	"app/platform/health"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for Checker
+func TestChecker(t *testing.T) {
+	t.Run("every check ok", func(t *testing.T) {
+		// arrange
+		ck := health.NewChecker(time.Second)
+		ck.Add("a", func(ctx context.Context) error { return nil })
+		ck.Add("b", func(ctx context.Context) error { return nil })
+
+		// act
+		r := ck.Run(context.Background())
+
+		// assert
+		require.True(t, r.Ok)
+		require.Len(t, r.Results, 2)
+		require.True(t, r.Results["a"].Ok)
+		require.True(t, r.Results["b"].Ok)
+	})
+
+	t.Run("a check fails", func(t *testing.T) {
+		// arrange
+		ck := health.NewChecker(time.Second)
+		ck.Add("a", func(ctx context.Context) error { return nil })
+		ck.Add("b", func(ctx context.Context) error { return errors.New("down") })
+
+		// act
+		r := ck.Run(context.Background())
+
+		// assert
+		require.False(t, r.Ok)
+		require.True(t, r.Results["a"].Ok)
+		require.False(t, r.Results["b"].Ok)
+		require.Equal(t, health.ReasonUnavailable, r.Results["b"].Error)
+		require.EqualError(t, r.Results["b"].Err, "down")
+	})
+
+	t.Run("a check exceeds the timeout", func(t *testing.T) {
+		// arrange
+		ck := health.NewChecker(10 * time.Millisecond)
+		ck.Add("slow", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+		// act
+		r := ck.Run(context.Background())
+
+		// assert
+		require.False(t, r.Ok)
+		require.Equal(t, health.ReasonTimeout, r.Results["slow"].Error)
+		require.ErrorIs(t, r.Results["slow"].Err, context.DeadlineExceeded)
+	})
+}
+
+// Tests for File
+func TestFile(t *testing.T) {
+	t.Run("readable file", func(t *testing.T) {
+		// arrange
+		path := filepath.Join(t.TempDir(), "store.json")
+		require.NoError(t, os.WriteFile(path, []byte("[]"), 0644))
+
+		// act
+		err := health.File(path)(context.Background())
+
+		// assert
+		require.NoError(t, err)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		// act
+		err := health.File(filepath.Join(t.TempDir(), "missing.json"))(context.Background())
+
+		// assert
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+// Tests for Retry
+func TestRetry(t *testing.T) {
+	t.Run("succeeds after failing", func(t *testing.T) {
+		// arrange
+		attempts := 0
+		ck := func(ctx context.Context) error {
+			attempts++
+			if attempts < 3 {
+				return errors.New("down")
+			}
+			return nil
+		}
+
+		// act
+		err := health.Retry(context.Background(), ck, time.Millisecond, time.Second)
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, 3, attempts)
+	})
+
+	t.Run("gives up after the timeout", func(t *testing.T) {
+		// arrange
+		ck := func(ctx context.Context) error { return errors.New("down") }
+
+		// act
+		err := health.Retry(context.Background(), ck, time.Millisecond, 20*time.Millisecond)
+
+		// assert
+		require.EqualError(t, err, "down")
+	})
+}
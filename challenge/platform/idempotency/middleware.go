@@ -0,0 +1,152 @@
+package idempotency
+
+import (
+	"app/platform/web/response"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const (
+	// HeaderKey is the header an idempotency key is read from.
+	HeaderKey = "Idempotency-Key"
+	// HeaderReplayed is the header set to true on the responses replayed from a key.
+	HeaderReplayed = "Idempotent-Replayed"
+)
+
+const (
+	// CodeKeyReused is the code of a request reusing the key of a request with another body.
+	CodeKeyReused response.Code = "idempotency_key_reused"
+	// CodeKeyInProgress is the code of a request whose key is still reserved by a request in progress.
+	CodeKeyInProgress response.Code = "idempotency_key_in_progress"
+)
+
+// Config is the configuration for Middleware.
+type Config struct {
+	// Store persists the keys and their responses, a Memory one by default.
+	Store Store
+	// TTL is the duration a key is kept for, replaying its response, 24 hours by default.
+	TTL time.Duration
+	// Client returns the client of a request, whose keys are its own, e.g. ratelimit.ClientKey. Keys are global by default.
+	Client func(r *http.Request) string
+	// MaxBytes is the maximum size of the bodies hashed, larger ones are answered with 413. 8 MiB by default.
+	MaxBytes int64
+	// Logger is the logger of the errors of the store.
+	Logger *slog.Logger
+}
+
+// Middleware makes the requests carrying an Idempotency-Key header idempotent, per client and key:
+// - the first request with a key reserves it and runs, its response is stored unless it is a 5xx one, which releases the key to be retried
+// - a request with the key of a stored response replays it, with Idempotent-Replayed: true
+// - a request with the key of another method, path, query or body is answered with 422 and CodeKeyReused
+// - a request with the key of a request in progress is answered with 409 and CodeKeyInProgress
+// Requests without the header are not affected. It must run after auth.Authenticator.Middleware for the keys to be per principal.
+func Middleware(cfg Config) func(http.Handler) http.Handler {
+	if cfg.Store == nil {
+		cfg.Store = NewMemory()
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = 24 * time.Hour
+	}
+	if cfg.Client == nil {
+		cfg.Client = func(r *http.Request) string { return "" }
+	}
+	if cfg.MaxBytes <= 0 {
+		cfg.MaxBytes = 8 << 20
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// request
+			// - header: Idempotency-Key
+			key, ok := r.Header[HeaderKey]
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) != 1 || ValidateKey(key[0]) != nil {
+				response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParameter, "invalid "+HeaderKey+", must be 1 to 255 printable ascii characters")
+				return
+			}
+			// - body, hashed with the method and the target
+			body, err := io.ReadAll(io.LimitReader(r.Body, cfg.MaxBytes+1))
+			if err != nil {
+				response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidBody, "error reading request body")
+				return
+			}
+			if int64(len(body)) > cfg.MaxBytes {
+				response.ErrorCode(w, http.StatusRequestEntityTooLarge, response.CodeBodyTooLarge, "request body too large")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			h := sha256.New()
+			io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+			h.Write(body)
+			hash := hex.EncodeToString(h.Sum(nil))
+
+			// process
+			// - reserve the key, or replay its response
+			ctx, client := r.Context(), cfg.Client(r)
+			rec, reserved, err := cfg.Store.Reserve(ctx, client, key[0], hash, time.Now(), cfg.TTL)
+			if err != nil {
+				cfg.Logger.ErrorContext(ctx, "error reserving idempotency key", "error", err)
+				response.Error(w, http.StatusInternalServerError, "error reserving idempotency key")
+				return
+			}
+			if !reserved {
+				switch {
+				case rec.Hash != hash:
+					response.ErrorCode(w, http.StatusUnprocessableEntity, CodeKeyReused, HeaderKey+" already used by a different request")
+				case rec.Response == nil:
+					w.Header().Set("Retry-After", "1")
+					response.ErrorCode(w, http.StatusConflict, CodeKeyInProgress, "a request with this "+HeaderKey+" is in progress")
+				default:
+					w.Header().Set("Content-Type", rec.Response.ContentType)
+					w.Header().Set(HeaderReplayed, "true")
+					w.WriteHeader(rec.Response.Status)
+					w.Write(rec.Response.Body)
+				}
+				return
+			}
+			// - run the request, releasing the key if it panics
+			// the key is stored or released even if the request is cancelled meanwhile
+			bg := context.WithoutCancel(ctx)
+			var buf bytes.Buffer
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			ww.Tee(&buf)
+			completed := false
+			defer func() {
+				if !completed {
+					cfg.Store.Release(bg, client, key[0], hash)
+				}
+			}()
+			next.ServeHTTP(ww, r)
+
+			// response
+			// - store it, unless it is a 5xx one
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if status >= http.StatusInternalServerError {
+				return
+			}
+			err = cfg.Store.Complete(bg, client, key[0], hash, Response{Status: status, ContentType: ww.Header().Get("Content-Type"), Body: buf.Bytes()})
+			if err != nil {
+				cfg.Logger.ErrorContext(bg, "error storing idempotent response", "error", err)
+				return
+			}
+			completed = true
+		})
+	}
+}
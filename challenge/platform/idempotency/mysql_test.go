@@ -0,0 +1,190 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-txdb"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	cfg := mysql.Config{
+		User:      "root",
+		Passwd:    "",
+		Net:       "tcp",
+		Addr:      "localhost:3306",
+		DBName:    "fantasy_products_test",
+		ParseTime: true,
+	}
+
+	txdb.Register("txdb_idempotency", "mysql", cfg.FormatDSN())
+}
+
+// openMySQL opens a transaction of the test database, rolled back on close.
+func openMySQL(t *testing.T) (db *sql.DB) {
+	db, err := sql.Open("txdb_idempotency", "fantasy_products_test")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return
+}
+
+func TestMySQLReserve(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2021, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("should reserve a key once and read back the request in progress", func(t *testing.T) {
+		// ARRANGE
+		m := NewMySQL(openMySQL(t))
+
+		// ACT
+		_, reserved1, err1 := m.Reserve(ctx, "a", "k", "h1", now, time.Hour)
+		rec, reserved2, err2 := m.Reserve(ctx, "a", "k", "h2", now.Add(time.Minute), time.Hour)
+		_, reserved3, err3 := m.Reserve(ctx, "b", "k", "h2", now, time.Hour)
+
+		// ASSERT
+		require.NoError(t, err1)
+		require.True(t, reserved1)
+		require.NoError(t, err2)
+		require.False(t, reserved2)
+		require.Equal(t, Record{Hash: "h1"}, rec)
+		require.NoError(t, err3)
+		require.True(t, reserved3)
+	})
+
+	t.Run("should replay the completed response", func(t *testing.T) {
+		// ARRANGE
+		m := NewMySQL(openMySQL(t))
+		_, _, err := m.Reserve(ctx, "a", "k", "h", now, time.Hour)
+		require.NoError(t, err)
+		res := Response{Status: http.StatusCreated, ContentType: "application/json", Body: []byte(`{"id":1}`)}
+
+		// ACT
+		err = m.Complete(ctx, "a", "k", "h", res)
+		rec, reserved, errReserve := m.Reserve(ctx, "a", "k", "h", now, time.Hour)
+
+		// ASSERT
+		require.NoError(t, err)
+		require.NoError(t, errReserve)
+		require.False(t, reserved)
+		require.Equal(t, Record{Hash: "h", Response: &res}, rec)
+	})
+
+	t.Run("should not complete a key reserved by another request", func(t *testing.T) {
+		// ARRANGE
+		db := openMySQL(t)
+		m := NewMySQL(db)
+		_, _, err := m.Reserve(ctx, "a", "k", "h2", now, time.Hour)
+		require.NoError(t, err)
+
+		// ACT
+		err = m.Complete(ctx, "a", "k", "h1", Response{Status: http.StatusCreated})
+
+		// ASSERT
+		require.NoError(t, err)
+		var status sql.NullInt64
+		require.NoError(t, db.QueryRow("SELECT `status` FROM `idempotency_keys` WHERE `client` = ? AND `key` = ?", "a", "k").Scan(&status))
+		require.False(t, status.Valid)
+	})
+
+	t.Run("should reserve an expired key again", func(t *testing.T) {
+		// ARRANGE
+		db := openMySQL(t)
+		m := NewMySQL(db)
+		_, _, err := m.Reserve(ctx, "a", "k", "h1", now, time.Hour)
+		require.NoError(t, err)
+		require.NoError(t, m.Complete(ctx, "a", "k", "h1", Response{Status: http.StatusCreated}))
+		// - not swept, so that the reservation finds the expired key
+		m.swept = now.Add(2 * time.Hour)
+
+		// ACT
+		_, reserved, err := m.Reserve(ctx, "a", "k", "h2", now.Add(2*time.Hour), time.Hour)
+
+		// ASSERT
+		require.NoError(t, err)
+		require.True(t, reserved)
+		var hash string
+		var status sql.NullInt64
+		var expires time.Time
+		require.NoError(t, db.QueryRow("SELECT `request_hash`, `status`, `expires_at` FROM `idempotency_keys` WHERE `client` = ? AND `key` = ?", "a", "k").Scan(&hash, &status, &expires))
+		require.Equal(t, "h2", hash)
+		require.False(t, status.Valid)
+		require.Equal(t, now.Add(3*time.Hour), expires)
+	})
+
+	t.Run("should reserve a released key again", func(t *testing.T) {
+		// ARRANGE
+		m := NewMySQL(openMySQL(t))
+		_, _, err := m.Reserve(ctx, "a", "k", "h", now, time.Hour)
+		require.NoError(t, err)
+
+		// ACT
+		err = m.Release(ctx, "a", "k", "h")
+		_, reserved, errReserve := m.Reserve(ctx, "a", "k", "h", now, time.Hour)
+
+		// ASSERT
+		require.NoError(t, err)
+		require.NoError(t, errReserve)
+		require.True(t, reserved)
+	})
+
+	t.Run("should not release a key reserved again by another request", func(t *testing.T) {
+		// ARRANGE
+		m := NewMySQL(openMySQL(t))
+		_, _, err := m.Reserve(ctx, "a", "k", "h1", now, time.Hour)
+		require.NoError(t, err)
+		// - not swept, so that the reservation finds the expired key
+		m.swept = now.Add(time.Hour)
+		_, _, err = m.Reserve(ctx, "a", "k", "h2", now.Add(time.Hour), time.Hour)
+		require.NoError(t, err)
+
+		// ACT
+		err = m.Release(ctx, "a", "k", "h1")
+		rec, reserved, errReserve := m.Reserve(ctx, "a", "k", "h3", now.Add(time.Hour), time.Hour)
+
+		// ASSERT
+		require.NoError(t, err)
+		require.NoError(t, errReserve)
+		require.False(t, reserved)
+		require.Equal(t, Record{Hash: "h2"}, rec)
+	})
+}
+
+func TestMySQLSweep(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2021, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("should delete the expired keys at most once per interval", func(t *testing.T) {
+		// ARRANGE
+		db := openMySQL(t)
+		m := NewMySQL(db)
+		// insert inserts a key expiring at expires
+		insert := func(key string, expires time.Time) {
+			_, err := db.Exec("INSERT INTO `idempotency_keys` (`client`, `key`, `request_hash`, `expires_at`) VALUES (?, ?, ?, ?)", "a", key, "h", expires)
+			require.NoError(t, err)
+		}
+		// count returns the number of keys
+		count := func() (n int) {
+			require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM `idempotency_keys`").Scan(&n))
+			return
+		}
+		insert("expired", now.Add(-time.Minute))
+		insert("expiring", now)
+		insert("live", now.Add(time.Hour))
+
+		// ACT & ASSERT
+		require.NoError(t, m.sweep(ctx, now))
+		require.Equal(t, 1, count())
+
+		insert("expired again", now.Add(-time.Minute))
+		require.NoError(t, m.sweep(ctx, now.Add(sweepInterval/2)))
+		require.Equal(t, 2, count())
+
+		require.NoError(t, m.sweep(ctx, now.Add(sweepInterval)))
+		require.Equal(t, 1, count())
+	})
+}
@@ -0,0 +1,56 @@
+package logging
+
+import (
+	"app/platform/tracing"
+	"context"
+	"io"
+	"log/slog"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// New returns a logger that writes JSON lines to w, adding the request id, route and trace id of the context to each record.
+func New(w io.Writer, level slog.Level) *slog.Logger {
+	return slog.New(NewContextHandler(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})))
+}
+
+// NewContextHandler returns a handler that adds the request id, route and trace id of the context to each record before h handles it.
+func NewContextHandler(h slog.Handler) *ContextHandler {
+	return &ContextHandler{h: h}
+}
+
+// ContextHandler is a slog.Handler that adds the request id, route and trace id of the context to each record.
+// Records are only enriched when logged with a context, e.g. with Logger.ErrorContext.
+type ContextHandler struct {
+	// h is the handler the records are passed to.
+	h slog.Handler
+}
+
+// Enabled reports whether h handles records at the level.
+func (c *ContextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return c.h.Enabled(ctx, level)
+}
+
+// Handle adds the request id, route and trace id of ctx to the record and passes it to h.
+func (c *ContextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if rc := chi.RouteContext(ctx); rc != nil && rc.RoutePattern() != "" {
+		r.AddAttrs(slog.String("route", rc.RoutePattern()))
+	}
+	if sp := tracing.SpanFromContext(ctx); sp != nil {
+		r.AddAttrs(slog.String("trace_id", sp.Context().TraceID.String()))
+	}
+	return c.h.Handle(ctx, r)
+}
+
+// WithAttrs returns a ContextHandler whose records include attrs.
+func (c *ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &ContextHandler{h: c.h.WithAttrs(attrs)}
+}
+
+// WithGroup returns a ContextHandler whose record attributes are qualified by name.
+func (c *ContextHandler) WithGroup(name string) slog.Handler {
+	return &ContextHandler{h: c.h.WithGroup(name)}
+}
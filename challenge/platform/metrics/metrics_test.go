@@ -0,0 +1,104 @@
+package metrics_test
+
+import (
+	"app/platform/metrics"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for Registry
+func TestRegistry_Write(t *testing.T) {
+	t.Run("counter with labels", func(t *testing.T) {
+		// arrange
+		c := metrics.NewCounterVec("imported_total", "Rows imported.", "entity")
+		c.Inc("sales")
+		c.Add(2, "customers")
+		c.Inc("sales")
+		reg := metrics.NewRegistry()
+		reg.Register(c)
+
+		// act
+		var sb strings.Builder
+		err := reg.Write(&sb)
+
+		// assert
+		expected := "# HELP imported_total Rows imported.\n" +
+			"# TYPE imported_total counter\n" +
+			"imported_total{entity=\"customers\"} 2\n" +
+			"imported_total{entity=\"sales\"} 2\n"
+		require.NoError(t, err)
+		require.Equal(t, expected, sb.String())
+	})
+
+	t.Run("histogram buckets are cumulative", func(t *testing.T) {
+		// arrange
+		h := metrics.NewHistogramVec("latency_seconds", "Latency.", []float64{0.1, 1}, "op")
+		h.Observe(0.05, "find")
+		h.Observe(0.5, "find")
+		h.Observe(2, "find")
+		reg := metrics.NewRegistry()
+		reg.Register(h)
+
+		// act
+		var sb strings.Builder
+		err := reg.Write(&sb)
+
+		// assert
+		expected := "# HELP latency_seconds Latency.\n" +
+			"# TYPE latency_seconds histogram\n" +
+			"latency_seconds_bucket{op=\"find\",le=\"0.1\"} 1\n" +
+			"latency_seconds_bucket{op=\"find\",le=\"1\"} 2\n" +
+			"latency_seconds_bucket{op=\"find\",le=\"+Inf\"} 3\n" +
+			"latency_seconds_sum{op=\"find\"} 2.55\n" +
+			"latency_seconds_count{op=\"find\"} 3\n"
+		require.NoError(t, err)
+		require.Equal(t, expected, sb.String())
+	})
+
+	t.Run("label values are escaped", func(t *testing.T) {
+		// arrange
+		c := metrics.NewCounterVec("x_total", "X.", "v")
+		c.Inc("a\"b\\c\nd")
+		reg := metrics.NewRegistry()
+		reg.Register(c)
+
+		// act
+		var sb strings.Builder
+		err := reg.Write(&sb)
+
+		// assert
+		require.NoError(t, err)
+		require.Contains(t, sb.String(), `x_total{v="a\"b\\c\nd"} 1`)
+	})
+}
+
+// Tests for HTTP
+func TestHTTP_Middleware(t *testing.T) {
+	t.Run("requests are labeled by route pattern and status code", func(t *testing.T) {
+		// arrange
+		m := metrics.NewHTTP()
+		rt := chi.NewRouter()
+		rt.Use(m.Middleware)
+		rt.Get("/customers/{id}", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		})
+		reg := metrics.NewRegistry()
+		reg.Register(m.Collectors()...)
+
+		// act
+		rt.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/customers/1", nil))
+		rt.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/customers/2", nil))
+		res := httptest.NewRecorder()
+		reg.Handler()(res, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+		// assert
+		require.Equal(t, metrics.ContentType, res.Header().Get("Content-Type"))
+		require.Contains(t, res.Body.String(), `http_requests_total{method="GET",route="/customers/{id}",code="404"} 2`)
+		require.Contains(t, res.Body.String(), `http_request_duration_seconds_count{method="GET",route="/customers/{id}",code="404"} 2`)
+	})
+}
@@ -0,0 +1,54 @@
+package ratelimit
+
+import (
+	"app/platform/auth"
+	"app/platform/web/response"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// KeyFunc returns the key of the bucket a request takes a token from.
+type KeyFunc func(r *http.Request) string
+
+// ClientKey keys the requests by authenticated principal, so that each API key or token subject has its own bucket,
+// and anonymous requests by client IP. X-Forwarded-For is not trusted, as any client can set it.
+func ClientKey(r *http.Request) string {
+	if p, ok := auth.PrincipalFrom(r.Context()); ok {
+		return "principal:" + p.Subject
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// Middleware takes a token from the bucket of each request, responding 429 with Retry-After when it is empty.
+// Every response carries the RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers.
+// It must run after auth.Authenticator.Middleware for the requests to be keyed by principal.
+func Middleware(lm *Limiter, key KeyFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			res := lm.Allow(key(r))
+
+			h := w.Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+			h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			h.Set("RateLimit-Reset", seconds(res.Reset))
+			if !res.Allowed {
+				h.Set("Retry-After", seconds(res.RetryAfter))
+				response.Error(w, http.StatusTooManyRequests, "rate limit exceeded")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// seconds formats d as a number of seconds rounded up, as the headers do not allow fractions.
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
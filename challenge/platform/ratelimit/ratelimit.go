@@ -0,0 +1,120 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit is the rate of a token bucket.
+type Limit struct {
+	// Rate is the number of requests per second refilled into the bucket.
+	Rate float64
+	// Burst is the capacity of the bucket, i.e. the number of requests a client can make at once.
+	Burst int
+}
+
+// Result is the outcome of Limiter.Allow.
+type Result struct {
+	// Allowed reports whether the request may proceed.
+	Allowed bool
+	// Limit is the capacity of the bucket.
+	Limit int
+	// Remaining is the number of requests left in the bucket.
+	Remaining int
+	// RetryAfter is the duration until the next request is allowed, zero if Allowed.
+	RetryAfter time.Duration
+	// Reset is the duration until the bucket is full again.
+	Reset time.Duration
+}
+
+// NewLimiter creates a new Limiter.
+// A non positive rate or burst defaults to 1.
+func NewLimiter(l Limit) (lm *Limiter) {
+	if l.Rate <= 0 {
+		l.Rate = 1
+	}
+	if l.Burst <= 0 {
+		l.Burst = 1
+	}
+	lm = &Limiter{
+		limit:   l,
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+	return
+}
+
+// Limiter is a set of token buckets, one per key, safe for concurrent use.
+// Buckets that refilled completely are forgotten, as they are equivalent to new ones, so memory is bounded
+// by the number of clients active over the refill period.
+type Limiter struct {
+	// limit is the rate of every bucket.
+	limit Limit
+	// mu guards buckets and swept.
+	mu sync.Mutex
+	// buckets are the buckets by key.
+	buckets map[string]*bucket
+	// swept is the last time the full buckets were forgotten.
+	swept time.Time
+	// now returns the current time.
+	now func() time.Time
+}
+
+// bucket is the state of a token bucket.
+type bucket struct {
+	// tokens is the number of tokens at last.
+	tokens float64
+	// last is the time tokens was computed at.
+	last time.Time
+}
+
+// Allow takes a token from the bucket of key.
+func (lm *Limiter) Allow(key string) (r Result) {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+
+	now := lm.now()
+	burst := float64(lm.limit.Burst)
+	lm.sweep(now)
+
+	// refill
+	b, ok := lm.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, last: now}
+		lm.buckets[key] = b
+	}
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*lm.limit.Rate)
+	b.last = now
+
+	// take
+	r.Limit = lm.limit.Burst
+	if b.tokens >= 1 {
+		b.tokens--
+		r.Allowed = true
+	} else {
+		r.RetryAfter = lm.duration(1 - b.tokens)
+	}
+	r.Remaining = int(b.tokens)
+	r.Reset = lm.duration(burst - b.tokens)
+	return
+}
+
+// duration returns the time to refill n tokens.
+func (lm *Limiter) duration(n float64) time.Duration {
+	return time.Duration(n / lm.limit.Rate * float64(time.Second))
+}
+
+// sweep forgets the buckets that are full by now, at most once per refill period.
+func (lm *Limiter) sweep(now time.Time) {
+	period := lm.duration(float64(lm.limit.Burst))
+	if now.Sub(lm.swept) < period {
+		return
+	}
+	for k, b := range lm.buckets {
+		if now.Sub(b.last) >= period {
+			delete(lm.buckets, k)
+		}
+	}
+	lm.swept = now
+}
@@ -0,0 +1,218 @@
+package sqlstats
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"time"
+)
+
+// connector wraps the connections of a driver.Connector.
+type connector struct {
+	// c is the wrapped connector.
+	c driver.Connector
+	// st records the statements.
+	st *Stats
+}
+
+// Connect returns a wrapped connection.
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	cn, err := c.c.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: cn, st: c.st}, nil
+}
+
+// Driver returns the wrapped driver.
+func (c *connector) Driver() driver.Driver {
+	return c.c.Driver()
+}
+
+// conn is a connection recording its statements. It implements the optional interfaces
+// of database/sql/driver by delegating to the wrapped connection, or by falling back
+// to what database/sql does when the wrapped connection does not implement them.
+type conn struct {
+	driver.Conn
+	// st records the statements.
+	st *Stats
+}
+
+// PrepareContext prepares a statement that is recorded when executed.
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var st driver.Stmt
+	var err error
+	if cp, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		st, err = cp.PrepareContext(ctx, query)
+	} else {
+		st, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &stmt{Stmt: st, query: query, st: c.st}, nil
+}
+
+// BeginTx starts a transaction.
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if cb, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return cb.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+// QueryContext runs the query without preparing it, if the wrapped connection supports it.
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	qc, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	return c.st.query(ctx, query, args, func() (driver.Rows, error) { return qc.QueryContext(ctx, query, args) })
+}
+
+// ExecContext runs the statement without preparing it, if the wrapped connection supports it.
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	ec, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	return c.st.exec(ctx, query, args, func() (driver.Result, error) { return ec.ExecContext(ctx, query, args) })
+}
+
+// Ping checks the connection is alive.
+func (c *conn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+// ResetSession resets the connection before it is reused.
+func (c *conn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+// IsValid reports whether the connection can be reused.
+func (c *conn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+// CheckNamedValue converts the arguments as the wrapped connection does.
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if nc, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return nc.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// stmt is a prepared statement recording its executions.
+type stmt struct {
+	driver.Stmt
+	// query is the text of the statement.
+	query string
+	// st records the statements.
+	st *Stats
+}
+
+// QueryContext runs the prepared query.
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.st.query(ctx, s.query, args, func() (driver.Rows, error) {
+		if sq, ok := s.Stmt.(driver.StmtQueryContext); ok {
+			return sq.QueryContext(ctx, args)
+		}
+		return nil, errors.New("sqlstats: driver statement does not implement StmtQueryContext")
+	})
+}
+
+// ExecContext runs the prepared statement.
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.st.exec(ctx, s.query, args, func() (driver.Result, error) {
+		if se, ok := s.Stmt.(driver.StmtExecContext); ok {
+			return se.ExecContext(ctx, args)
+		}
+		return nil, errors.New("sqlstats: driver statement does not implement StmtExecContext")
+	})
+}
+
+// query runs and records a query, the run ends when its rows are read or closed.
+func (s *Stats) query(ctx context.Context, query string, args []driver.NamedValue, fn func() (driver.Rows, error)) (driver.Rows, error) {
+	if ctx.Value(explainKey{}) != nil {
+		return fn()
+	}
+	start := time.Now()
+	r, err := fn()
+	if err != nil {
+		// skipped queries are retried by database/sql as prepared statements, recorded on their own
+		if !errors.Is(err, driver.ErrSkip) {
+			s.record(ctx, query, args, time.Since(start), 0, err)
+		}
+		return nil, err
+	}
+	return &rows{Rows: r, done: func(n int64, err error) { s.record(ctx, query, args, time.Since(start), n, err) }}, nil
+}
+
+// exec runs and records a statement that returns no rows.
+func (s *Stats) exec(ctx context.Context, query string, args []driver.NamedValue, fn func() (driver.Result, error)) (driver.Result, error) {
+	if ctx.Value(explainKey{}) != nil {
+		return fn()
+	}
+	start := time.Now()
+	r, err := fn()
+	if err != nil {
+		if !errors.Is(err, driver.ErrSkip) {
+			s.record(ctx, query, args, time.Since(start), 0, err)
+		}
+		return nil, err
+	}
+	n, _ := r.RowsAffected()
+	s.record(ctx, query, args, time.Since(start), n, nil)
+	return r, nil
+}
+
+// rows counts the rows read, recording the query once they are all read or closed.
+type rows struct {
+	driver.Rows
+	// done records the query.
+	done func(n int64, err error)
+	// n is the number of rows read.
+	n int64
+	// recorded reports whether done was called.
+	recorded bool
+}
+
+// Next reads the next row.
+func (r *rows) Next(dest []driver.Value) error {
+	err := r.Rows.Next(dest)
+	switch {
+	case err == nil:
+		r.n++
+	case errors.Is(err, io.EOF):
+		r.finish(nil)
+	default:
+		r.finish(err)
+	}
+	return err
+}
+
+// Close closes the rows, recording the query if it was not read to the end.
+func (r *rows) Close() error {
+	err := r.Rows.Close()
+	r.finish(nil)
+	return err
+}
+
+// finish records the query once.
+func (r *rows) finish(err error) {
+	if r.recorded {
+		return
+	}
+	r.recorded = true
+	r.done(r.n, err)
+}
@@ -0,0 +1,53 @@
+package tracing
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+)
+
+// NewJSONExporter returns an exporter that writes each span to w as a JSON line.
+func NewJSONExporter(w io.Writer) *JSONExporter {
+	return &JSONExporter{enc: json.NewEncoder(w)}
+}
+
+// NewFileExporter returns an exporter that appends each span to the file at path as a JSON line.
+// The file is created if needed, Close closes it.
+func NewFileExporter(path string) (e *JSONExporter, err error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return
+	}
+	e = &JSONExporter{enc: json.NewEncoder(f), c: f}
+	return
+}
+
+// JSONExporter writes spans as JSON lines, e.g. to stdout or to a file, so that traces can be read offline.
+type JSONExporter struct {
+	// mu serializes the writes.
+	mu sync.Mutex
+	// enc encodes the spans.
+	enc *json.Encoder
+	// c is closed by Close, nil if the exporter does not own the writer.
+	c io.Closer
+}
+
+// Export writes the span as a JSON line.
+func (e *JSONExporter) Export(s SpanData) (err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	err = e.enc.Encode(s)
+	return
+}
+
+// Close closes the file of the exporter, if it owns one.
+func (e *JSONExporter) Close() (err error) {
+	if e.c == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	err = e.c.Close()
+	return
+}
@@ -0,0 +1,212 @@
+package tracing
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+)
+
+// WrapConnector returns a connector whose connections start a span for each SQL statement,
+// child of the span in the context of the statement, with the query text and the row count.
+// Statements run without a span in their context, e.g. by batch jobs, are not traced.
+//
+//	cn, err := mysql.NewConnector(cfg)
+//	db := sql.OpenDB(tracing.WrapConnector(cn))
+func WrapConnector(c driver.Connector) driver.Connector {
+	return &connector{c: c}
+}
+
+// connector wraps the connections of a driver.Connector.
+type connector struct {
+	// c is the wrapped connector.
+	c driver.Connector
+}
+
+// Connect returns a wrapped connection.
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	cn, err := c.c.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{cn}, nil
+}
+
+// Driver returns the wrapped driver.
+func (c *connector) Driver() driver.Driver {
+	return c.c.Driver()
+}
+
+// conn is a connection tracing its statements. It implements the optional interfaces
+// of database/sql/driver by delegating to the wrapped connection, or by falling back
+// to what database/sql does when the wrapped connection does not implement them.
+type conn struct {
+	driver.Conn
+}
+
+// PrepareContext prepares a statement that is traced when executed.
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var st driver.Stmt
+	var err error
+	if cp, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		st, err = cp.PrepareContext(ctx, query)
+	} else {
+		st, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &stmt{Stmt: st, query: query}, nil
+}
+
+// BeginTx starts a transaction.
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if cb, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return cb.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+// QueryContext runs the query without preparing it, if the wrapped connection supports it.
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	qc, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	return traceQuery(ctx, query, func() (driver.Rows, error) { return qc.QueryContext(ctx, query, args) })
+}
+
+// ExecContext runs the statement without preparing it, if the wrapped connection supports it.
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	ec, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	return traceExec(ctx, query, func() (driver.Result, error) { return ec.ExecContext(ctx, query, args) })
+}
+
+// Ping checks the connection is alive.
+func (c *conn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+// ResetSession resets the connection before it is reused.
+func (c *conn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+// IsValid reports whether the connection can be reused.
+func (c *conn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+// CheckNamedValue converts the arguments as the wrapped connection does.
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if nc, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return nc.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// stmt is a prepared statement tracing its executions.
+type stmt struct {
+	driver.Stmt
+	// query is the text of the statement.
+	query string
+}
+
+// QueryContext runs the prepared query.
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return traceQuery(ctx, s.query, func() (driver.Rows, error) {
+		if sq, ok := s.Stmt.(driver.StmtQueryContext); ok {
+			return sq.QueryContext(ctx, args)
+		}
+		return nil, errors.New("tracing: driver statement does not implement StmtQueryContext")
+	})
+}
+
+// ExecContext runs the prepared statement.
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return traceExec(ctx, s.query, func() (driver.Result, error) {
+		if se, ok := s.Stmt.(driver.StmtExecContext); ok {
+			return se.ExecContext(ctx, args)
+		}
+		return nil, errors.New("tracing: driver statement does not implement StmtExecContext")
+	})
+}
+
+// traceQuery traces a query, the span ends when its rows are closed.
+func traceQuery(ctx context.Context, query string, fn func() (driver.Rows, error)) (driver.Rows, error) {
+	_, sp := Start(ctx, "sql.query")
+	sp.SetAttribute("db.statement", query)
+	r, err := fn()
+	if err != nil {
+		// skipped queries are retried by database/sql as prepared statements, traced on their own
+		if !errors.Is(err, driver.ErrSkip) {
+			sp.SetError(err)
+			sp.End()
+		}
+		return nil, err
+	}
+	if sp == nil {
+		return r, nil
+	}
+	return &rows{Rows: r, sp: sp}, nil
+}
+
+// traceExec traces a statement that returns no rows.
+func traceExec(ctx context.Context, query string, fn func() (driver.Result, error)) (driver.Result, error) {
+	_, sp := Start(ctx, "sql.exec")
+	sp.SetAttribute("db.statement", query)
+	r, err := fn()
+	if err != nil {
+		if !errors.Is(err, driver.ErrSkip) {
+			sp.SetError(err)
+			sp.End()
+		}
+		return nil, err
+	}
+	if n, errN := r.RowsAffected(); errN == nil {
+		sp.SetAttribute("db.rows_affected", n)
+	}
+	sp.End()
+	return r, nil
+}
+
+// rows counts the rows read, ending the span of the query when closed.
+type rows struct {
+	driver.Rows
+	// sp is the span of the query.
+	sp *Span
+	// n is the number of rows read.
+	n int
+}
+
+// Next reads the next row.
+func (r *rows) Next(dest []driver.Value) error {
+	err := r.Rows.Next(dest)
+	switch {
+	case err == nil:
+		r.n++
+	case !errors.Is(err, io.EOF):
+		r.sp.SetError(err)
+	}
+	return err
+}
+
+// Close closes the rows and ends the span of the query.
+func (r *rows) Close() error {
+	err := r.Rows.Close()
+	r.sp.SetAttribute("db.rows", r.n)
+	r.sp.End()
+	return err
+}
@@ -0,0 +1,190 @@
+package tracing_test
+
+import (
+	"app/platform/tracing"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+// exporterMock records the exported spans.
+type exporterMock struct {
+	mu    sync.Mutex
+	spans []tracing.SpanData
+}
+
+func (e *exporterMock) Export(s tracing.SpanData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, s)
+	return nil
+}
+
+// Tests for ParseTraceparent
+func TestParseTraceparent(t *testing.T) {
+	t.Run("valid header round trips", func(t *testing.T) {
+		// arrange
+		h := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+		// act
+		sc, err := tracing.ParseTraceparent(h)
+
+		// assert
+		require.NoError(t, err)
+		require.True(t, sc.Sampled)
+		require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+		require.Equal(t, h, sc.Traceparent())
+	})
+
+	t.Run("error - malformed header", func(t *testing.T) {
+		// arrange
+		headers := []string{
+			"",
+			"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+			"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+			"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+			"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			"00-4bf92f3577b34da6a3ce929d0e0e473z-00f067aa0ba902b7-01",
+		}
+
+		for _, h := range headers {
+			// act
+			_, err := tracing.ParseTraceparent(h)
+
+			// assert
+			require.ErrorIs(t, err, tracing.ErrTraceparentInvalid, h)
+		}
+	})
+}
+
+// Tests for Middleware
+func TestMiddleware(t *testing.T) {
+	t.Run("continues the trace of the request and names the span after the route", func(t *testing.T) {
+		// arrange
+		exp := &exporterMock{}
+		rt := chi.NewRouter()
+		rt.Use(tracing.Middleware(tracing.NewTracer(exp)))
+		rt.Get("/customers/{id}", func(w http.ResponseWriter, r *http.Request) {
+			_, sp := tracing.Start(r.Context(), "CustomersDefault.FindById")
+			sp.End()
+		})
+		req := httptest.NewRequest(http.MethodGet, "/customers/1", nil)
+		req.Header.Set(tracing.HeaderTraceparent, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		res := httptest.NewRecorder()
+
+		// act
+		rt.ServeHTTP(res, req)
+
+		// assert
+		require.Len(t, exp.spans, 2)
+		child, server := exp.spans[0], exp.spans[1]
+		require.Equal(t, "GET /customers/{id}", server.Name)
+		require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.TraceID)
+		require.Equal(t, "00f067aa0ba902b7", server.ParentID)
+		require.Equal(t, 200, server.Attributes["http.status_code"])
+		require.Equal(t, server.TraceID, child.TraceID)
+		require.Equal(t, server.SpanID, child.ParentID)
+		require.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-"+server.SpanID+"-01", res.Header().Get(tracing.HeaderTraceparent))
+	})
+
+	t.Run("unsampled traces are propagated but not exported", func(t *testing.T) {
+		// arrange
+		exp := &exporterMock{}
+		rt := chi.NewRouter()
+		rt.Use(tracing.Middleware(tracing.NewTracer(exp)))
+		rt.Get("/", func(w http.ResponseWriter, r *http.Request) {})
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(tracing.HeaderTraceparent, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+		res := httptest.NewRecorder()
+
+		// act
+		rt.ServeHTTP(res, req)
+
+		// assert
+		require.Empty(t, exp.spans)
+		require.Contains(t, res.Header().Get(tracing.HeaderTraceparent), "4bf92f3577b34da6a3ce929d0e0e4736")
+	})
+}
+
+// Tests for Start
+func TestStart(t *testing.T) {
+	t.Run("without a span in the context nothing is recorded", func(t *testing.T) {
+		// act
+		ctx, sp := tracing.Start(context.Background(), "batch")
+		sp.SetAttribute("k", "v")
+		sp.End()
+
+		// assert
+		require.Nil(t, sp)
+		require.Nil(t, tracing.SpanFromContext(ctx))
+	})
+}
+
+// connectorStub is a driver returning rowsStub for every query and 3 affected rows for every statement.
+type connectorStub struct{}
+
+func (c connectorStub) Connect(context.Context) (driver.Conn, error) { return connStub{}, nil }
+func (c connectorStub) Driver() driver.Driver                        { return nil }
+
+type connStub struct{}
+
+func (c connStub) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c connStub) Close() error                        { return nil }
+func (c connStub) Begin() (driver.Tx, error)           { return nil, driver.ErrSkip }
+func (c connStub) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	return &rowsStub{n: 2}, nil
+}
+func (c connStub) ExecContext(context.Context, string, []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(3), nil
+}
+
+type rowsStub struct{ n int }
+
+func (r *rowsStub) Columns() []string { return []string{"id"} }
+func (r *rowsStub) Close() error      { return nil }
+func (r *rowsStub) Next(dest []driver.Value) error {
+	if r.n == 0 {
+		return io.EOF
+	}
+	dest[0] = int64(r.n)
+	r.n--
+	return nil
+}
+
+// Tests for WrapConnector
+func TestWrapConnector(t *testing.T) {
+	t.Run("statements are traced with their text and row count", func(t *testing.T) {
+		// arrange
+		exp := &exporterMock{}
+		db := sql.OpenDB(tracing.WrapConnector(connectorStub{}))
+		defer db.Close()
+		ctx, root := tracing.NewTracer(exp).Start(context.Background(), "root", tracing.SpanContext{})
+
+		// act
+		rows, err := db.QueryContext(ctx, "SELECT id FROM customers")
+		require.NoError(t, err)
+		for rows.Next() {
+		}
+		require.NoError(t, rows.Close())
+		_, err = db.ExecContext(ctx, "UPDATE customers SET condition = 1")
+		require.NoError(t, err)
+		root.End()
+
+		// assert
+		require.Len(t, exp.spans, 3)
+		require.Equal(t, "sql.query", exp.spans[0].Name)
+		require.Equal(t, "SELECT id FROM customers", exp.spans[0].Attributes["db.statement"])
+		require.Equal(t, 2, exp.spans[0].Attributes["db.rows"])
+		require.Equal(t, root.Context().SpanID.String(), exp.spans[0].ParentID)
+		require.Equal(t, "sql.exec", exp.spans[1].Name)
+		require.Equal(t, int64(3), exp.spans[1].Attributes["db.rows_affected"])
+	})
+}
@@ -2,6 +2,7 @@ package request_test
 
 import (
 	"app/platform/web/request"
+	"app/platform/web/validate"
 	"io"
 	"net/http"
 	"strings"
@@ -22,7 +23,7 @@ func TestRequestJSON(t *testing.T) {
 		inputSchema := schema{}
 		inputRequest := http.Request{
 			Header: http.Header{"Content-Type": []string{"application/json"}},
-			Body: io.NopCloser(strings.NewReader(`{"name":"test"}`)),
+			Body:   io.NopCloser(strings.NewReader(`{"name":"test"}`)),
 		}
 		err := request.JSON(&inputRequest, &inputSchema)
 
@@ -42,7 +43,7 @@ func TestRequestJSON(t *testing.T) {
 		inputSchema := schema{}
 		inputRequest := http.Request{
 			Header: http.Header{"Content-Type": []string{"application/xml"}},
-			Body: io.NopCloser(strings.NewReader(`{"name":"test"}`)),
+			Body:   io.NopCloser(strings.NewReader(`{"name":"test"}`)),
 		}
 		err := request.JSON(&inputRequest, &inputSchema)
 
@@ -63,14 +64,212 @@ func TestRequestJSON(t *testing.T) {
 		inputSchema := schema{}
 		inputRequest := http.Request{
 			Header: http.Header{"Content-Type": []string{"application/json"}},
-			Body: io.NopCloser(strings.NewReader(`{"name":"test"`)),
+			Body:   io.NopCloser(strings.NewReader(`{"name":"test"`)),
 		}
 		err := request.JSON(&inputRequest, &inputSchema)
 
 		// assert
 		expectedSchema := schema{}
 		require.ErrorIs(t, err, request.ErrRequestJSONInvalid)
-		require.EqualError(t, err, "request json invalid. unexpected EOF")
+		require.EqualError(t, err, "request json invalid: body ends unexpectedly at offset 14")
 		require.Equal(t, expectedSchema, inputSchema)
 	})
-}
\ No newline at end of file
+	t.Run("success - media type with parameters", func(t *testing.T) {
+		// arrange
+		type schema struct {
+			Name string `json:"name"`
+		}
+
+		// act
+		inputSchema := schema{}
+		inputRequest := http.Request{
+			Header: http.Header{"Content-Type": []string{"application/json; charset=UTF-8"}},
+			Body:   io.NopCloser(strings.NewReader(`{"name":"test"}`)),
+		}
+		err := request.JSON(&inputRequest, &inputSchema)
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, schema{Name: "test"}, inputSchema)
+	})
+
+	t.Run("error - charset", func(t *testing.T) {
+		// act
+		inputRequest := http.Request{
+			Header: http.Header{"Content-Type": []string{"application/json; charset=latin1"}},
+			Body:   io.NopCloser(strings.NewReader(`{}`)),
+		}
+		err := request.JSON(&inputRequest, &struct{}{})
+
+		// assert
+		require.ErrorIs(t, err, request.ErrRequestContentTypeNotJSON)
+	})
+
+	t.Run("error - unknown field", func(t *testing.T) {
+		// arrange
+		type schema struct {
+			Name string `json:"name"`
+		}
+
+		// act
+		inputRequest := http.Request{
+			Header: http.Header{"Content-Type": []string{"application/json"}},
+			Body:   io.NopCloser(strings.NewReader(`{"name":"test","age":3}`)),
+		}
+		err := request.JSON(&inputRequest, &schema{})
+
+		// assert
+		var decodeErr *request.DecodeError
+		require.ErrorIs(t, err, request.ErrRequestJSONInvalid)
+		require.ErrorAs(t, err, &decodeErr)
+		require.Equal(t, "age", decodeErr.Field)
+	})
+
+	t.Run("error - type of a nested field", func(t *testing.T) {
+		// arrange
+		type line struct {
+			Price float64 `json:"price"`
+		}
+		type schema struct {
+			Lines []line `json:"lines"`
+		}
+
+		// act
+		inputRequest := http.Request{
+			Header: http.Header{"Content-Type": []string{"application/json"}},
+			Body:   io.NopCloser(strings.NewReader(`{"lines":[{"price":"1"}]}`)),
+		}
+		err := request.JSON(&inputRequest, &schema{})
+
+		// assert
+		require.EqualError(t, err, `request json invalid: field "lines.0.price": cannot be a string at offset 22`)
+	})
+
+	t.Run("error - multiple values", func(t *testing.T) {
+		// act
+		inputRequest := http.Request{
+			Header: http.Header{"Content-Type": []string{"application/json"}},
+			Body:   io.NopCloser(strings.NewReader(`{} {}`)),
+		}
+		err := request.JSON(&inputRequest, &struct{}{})
+
+		// assert
+		require.EqualError(t, err, "request json invalid: body must contain a single json value at offset 2")
+	})
+
+	t.Run("error - trailing garbage", func(t *testing.T) {
+		// act
+		inputRequest := http.Request{
+			Header: http.Header{"Content-Type": []string{"application/json"}},
+			Body:   io.NopCloser(strings.NewReader(`{}x`)),
+		}
+		err := request.JSON(&inputRequest, &struct{}{})
+
+		// assert
+		require.ErrorIs(t, err, request.ErrRequestJSONInvalid)
+	})
+
+	t.Run("error - body too large", func(t *testing.T) {
+		// act
+		inputRequest := http.Request{
+			Header: http.Header{"Content-Type": []string{"application/json"}},
+			Body:   io.NopCloser(strings.NewReader(`{"name":"` + strings.Repeat("a", 16) + `"}`)),
+		}
+		err := request.NewDecoder(request.Config{MaxBytes: 16}).JSON(&inputRequest, &struct {
+			Name string `json:"name"`
+		}{})
+
+		// assert
+		require.ErrorIs(t, err, request.ErrRequestBodyTooLarge)
+	})
+
+	t.Run("error - validation", func(t *testing.T) {
+		// arrange
+		type schema struct {
+			Name  string  `json:"name" validate:"required"`
+			Price float64 `json:"price" validate:"min=0"`
+		}
+
+		// act
+		inputRequest := http.Request{
+			Header: http.Header{"Content-Type": []string{"application/json"}},
+			Body:   io.NopCloser(strings.NewReader(`{"price":-1}`)),
+		}
+		err := request.JSON(&inputRequest, &schema{})
+
+		// assert
+		require.ErrorIs(t, err, validate.ErrValidation)
+		require.Equal(t, validate.Errors{
+			{Field: "name", Rule: "required", Message: "is required"},
+			{Field: "price", Rule: "min", Message: "must be at least 0"},
+		}, err)
+	})
+
+	t.Run("success - unknown fields allowed", func(t *testing.T) {
+		// act
+		inputRequest := http.Request{
+			Header: http.Header{"Content-Type": []string{"application/json"}},
+			Body:   io.NopCloser(strings.NewReader(`{"age":3}`)),
+		}
+		err := request.NewDecoder(request.Config{AllowUnknownFields: true}).JSON(&inputRequest, &struct{}{})
+
+		// assert
+		require.NoError(t, err)
+	})
+}
+
+// Tests for Field
+func TestField(t *testing.T) {
+	// arrange
+	type schema struct {
+		Name     request.Field[string]  `json:"name"`
+		Quantity request.Field[int]     `json:"quantity"`
+		Price    request.Field[float64] `json:"price"`
+	}
+
+	// act
+	inputSchema := schema{}
+	inputRequest := http.Request{
+		Header: http.Header{"Content-Type": []string{"application/json"}},
+		Body:   io.NopCloser(strings.NewReader(`{"quantity":0,"price":null}`)),
+	}
+	err := request.JSON(&inputRequest, &inputSchema)
+
+	// assert
+	require.NoError(t, err)
+	require.Equal(t, request.Field[string]{}, inputSchema.Name)
+	require.Equal(t, request.Field[int]{Set: true}, inputSchema.Quantity)
+	require.Equal(t, request.Field[float64]{Set: true, Null: true}, inputSchema.Price)
+}
+
+// Tests for Field with validate rules
+func TestFieldValidate(t *testing.T) {
+	// arrange
+	type schema struct {
+		Quantity request.Field[int] `json:"quantity" validate:"required,min=0"`
+	}
+	cases := map[string]string{
+		`{"quantity":0}`:    "",
+		`{}`:                "validation failed: quantity is required",
+		`{"quantity":null}`: "validation failed: quantity is required",
+		`{"quantity":-1}`:   "validation failed: quantity must be at least 0",
+	}
+
+	for body, expected := range cases {
+		// act
+		inputSchema := schema{}
+		inputRequest := http.Request{
+			Header: http.Header{"Content-Type": []string{"application/json"}},
+			Body:   io.NopCloser(strings.NewReader(body)),
+		}
+		err := request.JSON(&inputRequest, &inputSchema)
+
+		// assert
+		if expected == "" {
+			require.NoError(t, err, body)
+			continue
+		}
+		require.ErrorIs(t, err, validate.ErrValidation, body)
+		require.EqualError(t, err, expected, body)
+	}
+}
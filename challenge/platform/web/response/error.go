@@ -2,41 +2,195 @@ package response
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 )
 
-type errorResponse struct {
-	Status  string `json:"status"`
-	Message string `json:"message"`
+// ContentTypeProblem is the media type of the error responses, see RFC 7807.
+const ContentTypeProblem = "application/problem+json"
+
+// Code is a stable, machine readable error code. Clients branch on it rather than on the detail, which may change.
+type Code string
+
+const (
+	// CodeBadRequest is the code of a request that is invalid for another reason than the ones below.
+	CodeBadRequest Code = "bad_request"
+	// CodeInvalidBody is the code of a request body that cannot be decoded.
+	CodeInvalidBody Code = "invalid_body"
+	// CodeValidationFailed is the code of a request body whose fields fail their rules, listed in the invalid params.
+	CodeValidationFailed Code = "validation_failed"
+	// CodeInvalidParameter is the code of an invalid path or query parameter.
+	CodeInvalidParameter Code = "invalid_parameter"
+	// CodeUnauthorized is the code of a request without valid credentials.
+	CodeUnauthorized Code = "unauthorized"
+	// CodeForbidden is the code of a request whose credentials lack the required role.
+	CodeForbidden Code = "forbidden"
+	// CodeNotFound is the code of a route or resource that does not exist.
+	CodeNotFound Code = "not_found"
+	// CodeMethodNotAllowed is the code of a method the route does not support.
+	CodeMethodNotAllowed Code = "method_not_allowed"
+	// CodeNotAcceptable is the code of a request accepting none of the media types the route can respond with.
+	CodeNotAcceptable Code = "not_acceptable"
+	// CodeConflict is the code of a request conflicting with the current state of a resource.
+	CodeConflict Code = "conflict"
+	// CodeBodyTooLarge is the code of a request body over the maximum size.
+	CodeBodyTooLarge Code = "body_too_large"
+	// CodeUnsupportedMediaType is the code of a request body in a media type the route does not accept.
+	CodeUnsupportedMediaType Code = "unsupported_media_type"
+	// CodeRateLimited is the code of a request over the rate limit of the client.
+	CodeRateLimited Code = "rate_limited"
+	// CodeInternal is the code of an unexpected error, whose cause is logged rather than exposed.
+	CodeInternal Code = "internal_error"
+	// CodeUnavailable is the code of a request the server cannot handle at the moment.
+	CodeUnavailable Code = "unavailable"
+)
+
+// codeByStatus is the code of the errors written without one.
+var codeByStatus = map[int]Code{
+	http.StatusBadRequest:            CodeBadRequest,
+	http.StatusUnauthorized:          CodeUnauthorized,
+	http.StatusForbidden:             CodeForbidden,
+	http.StatusNotFound:              CodeNotFound,
+	http.StatusMethodNotAllowed:      CodeMethodNotAllowed,
+	http.StatusConflict:              CodeConflict,
+	http.StatusRequestEntityTooLarge: CodeBodyTooLarge,
+	http.StatusUnsupportedMediaType:  CodeUnsupportedMediaType,
+	http.StatusUnprocessableEntity:   CodeValidationFailed,
+	http.StatusTooManyRequests:       CodeRateLimited,
+	http.StatusInternalServerError:   CodeInternal,
+	http.StatusServiceUnavailable:    CodeUnavailable,
 }
 
+// Problem is the body of the error responses: RFC 7807 problem details extended with a code.
+type Problem struct {
+	// Type is a URI identifying the problem type, about:blank as the code identifies it.
+	Type string `json:"type"`
+	// Title is the status text of the response.
+	Title string `json:"title"`
+	// Status is the status code of the response.
+	Status int `json:"status"`
+	// Detail explains this occurrence of the problem to a human.
+	Detail string `json:"detail,omitempty"`
+	// Code identifies the problem to a machine.
+	Code Code `json:"code"`
+	// InvalidParams are the parameters of the request that are not valid, if the problem is about them.
+	InvalidParams []InvalidParam `json:"invalid_params,omitempty"`
+}
+
+// InvalidParam is a parameter of the request that is not valid, e.g. a field of the body.
+type InvalidParam struct {
+	// Name is the name of the parameter, the path of a field of the body.
+	Name string `json:"name"`
+	// Reason explains why it is not valid.
+	Reason string `json:"reason"`
+}
+
+// invalidParamsError is implemented by errors about the parameters of the request, listed in the response.
+type invalidParamsError interface {
+	InvalidParams() []InvalidParam
+}
+
+// Error writes an error response with the code of the status code.
 func Error(w http.ResponseWriter, statusCode int, message string) {
+	ErrorCode(w, statusCode, "", message)
+}
+
+// Errorf writes an error response like Error, formatting the message.
+func Errorf(w http.ResponseWriter, statusCode int, format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	Error(w, statusCode, message)
+}
+
+// ErrorCode writes an error response with code, or the code of the status code if it is empty.
+// A status code that is not an error one is replaced by 500.
+func ErrorCode(w http.ResponseWriter, statusCode int, code Code, detail string) {
+	writeProblem(w, NewProblem(statusCode, code, detail))
+}
+
+// NewProblem returns the body of an error response with code, or the code of the status code if it is empty,
+// e.g. to report the error of an item in a response about many. A status code that is not an error one is replaced by 500.
+func NewProblem(statusCode int, code Code, detail string) Problem {
 	// default status code
-	defaultStatusCode := http.StatusInternalServerError
-	// check if status code is valid
-	if statusCode > 299 && statusCode < 600 {
-		defaultStatusCode = statusCode
+	if statusCode < 400 || statusCode > 599 {
+		statusCode = http.StatusInternalServerError
+	}
+	// default code
+	if code == "" {
+		code = codeByStatus[statusCode]
+		if code == "" {
+			code = CodeBadRequest
+			if statusCode >= 500 {
+				code = CodeInternal
+			}
+		}
 	}
 
-	// response
-	body := errorResponse{
-		Status:  http.StatusText(defaultStatusCode),
-		Message: message,
+	return Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(statusCode),
+		Status: statusCode,
+		Detail: detail,
+		Code:   code,
 	}
-	bytes, err := json.Marshal(body)
+}
+
+// writeProblem writes an error response with body p.
+func writeProblem(w http.ResponseWriter, p Problem) {
+	bytes, err := json.Marshal(p)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
 	// write response
-	w.WriteHeader(defaultStatusCode)
-	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Type", ContentTypeProblem)
+	w.WriteHeader(p.Status)
 	w.Write(bytes)
 }
 
-func Errorf(w http.ResponseWriter, statusCode int, format string, args ...interface{}) {
-	message := fmt.Sprintf(format, args...)
-	Error(w, statusCode, message)
-}
\ No newline at end of file
+// ErrorMapping is the response of a sentinel error.
+type ErrorMapping struct {
+	// Err is the sentinel error, matched with errors.Is.
+	Err error
+	// Status is the status code of the response.
+	Status int
+	// Code is the code of the response, the code of Status if empty.
+	Code Code
+	// Detail is the detail of the response, the message of the error if empty.
+	Detail string
+}
+
+// ErrorMap maps sentinel errors to their responses, so that each is answered the same by every handler.
+type ErrorMap []ErrorMapping
+
+// Write writes the response of the first mapping matching err and returns true,
+// or returns false without writing anything if none does.
+func (m ErrorMap) Write(w http.ResponseWriter, err error) bool {
+	p, ok := m.Problem(err)
+	if ok {
+		writeProblem(w, p)
+	}
+	return ok
+}
+
+// Problem returns the body of the response of the first mapping matching err and true, or false if none does.
+// If err has an InvalidParams() []InvalidParam method, they are listed in the body.
+func (m ErrorMap) Problem(err error) (p Problem, ok bool) {
+	for _, v := range m {
+		if !errors.Is(err, v.Err) {
+			continue
+		}
+		detail := v.Detail
+		if detail == "" {
+			detail = err.Error()
+		}
+		p = NewProblem(v.Status, v.Code, detail)
+		var ipe invalidParamsError
+		if errors.As(err, &ipe) {
+			p.InvalidParams = ipe.InvalidParams()
+		}
+		return p, true
+	}
+	return
+}
@@ -0,0 +1,151 @@
+package response_test
+
+import (
+	"app/platform/web/response"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for Error function
+func TestError(t *testing.T) {
+	t.Run("404 - problem with the code of the status", func(t *testing.T) {
+		// act
+		rr := httptest.NewRecorder()
+		response.Error(rr, http.StatusNotFound, "customer not found")
+
+		// assert
+		require.Equal(t, http.StatusNotFound, rr.Code)
+		require.Equal(t, response.ContentTypeProblem, rr.Header().Get("Content-Type"))
+		require.JSONEq(t, `{"type":"about:blank","title":"Not Found","status":404,"detail":"customer not found","code":"not_found"}`, rr.Body.String())
+	})
+
+	t.Run("500 - status code that is not an error one", func(t *testing.T) {
+		// act
+		rr := httptest.NewRecorder()
+		response.Error(rr, http.StatusOK, "")
+
+		// assert
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.JSONEq(t, `{"type":"about:blank","title":"Internal Server Error","status":500,"code":"internal_error"}`, rr.Body.String())
+	})
+
+	t.Run("410 - status code without a code of its own", func(t *testing.T) {
+		// act
+		rr := httptest.NewRecorder()
+		response.Error(rr, http.StatusGone, "customer deleted")
+
+		// assert
+		require.Equal(t, http.StatusGone, rr.Code)
+		require.Contains(t, rr.Body.String(), `"code":"bad_request"`)
+	})
+}
+
+// Tests for NewProblem function
+func TestNewProblem(t *testing.T) {
+	// act
+	p := response.NewProblem(http.StatusFailedDependency, "bulk_aborted", "another item failed")
+
+	// assert
+	require.Equal(t, response.Problem{Type: "about:blank", Title: "Failed Dependency", Status: http.StatusFailedDependency, Detail: "another item failed", Code: "bulk_aborted"}, p)
+}
+
+// Tests for ErrorCode function
+func TestErrorCode(t *testing.T) {
+	// act
+	rr := httptest.NewRecorder()
+	response.ErrorCode(rr, http.StatusBadRequest, response.CodeInvalidParameter, "invalid id")
+
+	// assert
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+	require.JSONEq(t, `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid id","code":"invalid_parameter"}`, rr.Body.String())
+}
+
+// errInvalidFields is matched by invalidFieldsError.
+var errInvalidFields = errors.New("invalid fields")
+
+// invalidFieldsError is an error listing invalid params.
+type invalidFieldsError struct{}
+
+func (invalidFieldsError) Error() string        { return errInvalidFields.Error() }
+func (invalidFieldsError) Is(target error) bool { return target == errInvalidFields }
+func (invalidFieldsError) InvalidParams() []response.InvalidParam {
+	return []response.InvalidParam{{Name: "price", Reason: "must be at least 0"}}
+}
+
+// Tests for ErrorMap
+func TestErrorMap(t *testing.T) {
+	errNotFound := errors.New("repository: item not found")
+	errInvalid := errors.New("query invalid")
+	m := response.ErrorMap{
+		{Err: errNotFound, Status: http.StatusNotFound, Code: "item_not_found", Detail: "item not found"},
+		{Err: errInvalid, Status: http.StatusBadRequest, Code: response.CodeInvalidParameter},
+	}
+
+	t.Run("writes the mapping of a wrapped sentinel error", func(t *testing.T) {
+		// act
+		rr := httptest.NewRecorder()
+		ok := m.Write(rr, fmt.Errorf("finding item 1: %w", errNotFound))
+
+		// assert
+		require.True(t, ok)
+		require.Equal(t, http.StatusNotFound, rr.Code)
+		require.JSONEq(t, `{"type":"about:blank","title":"Not Found","status":404,"detail":"item not found","code":"item_not_found"}`, rr.Body.String())
+	})
+
+	t.Run("details the error when the mapping has no detail", func(t *testing.T) {
+		// act
+		rr := httptest.NewRecorder()
+		ok := m.Write(rr, fmt.Errorf("%w: limit must be positive", errInvalid))
+
+		// assert
+		require.True(t, ok)
+		require.Contains(t, rr.Body.String(), `"detail":"query invalid: limit must be positive"`)
+	})
+
+	t.Run("lists the invalid params of the error", func(t *testing.T) {
+		// arrange
+		m := response.ErrorMap{{Err: errInvalidFields, Status: http.StatusUnprocessableEntity}}
+
+		// act
+		rr := httptest.NewRecorder()
+		ok := m.Write(rr, invalidFieldsError{})
+
+		// assert
+		require.True(t, ok)
+		require.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		require.JSONEq(t, `{"type":"about:blank","title":"Unprocessable Entity","status":422,"detail":"invalid fields","code":"validation_failed",
+			"invalid_params":[{"name":"price","reason":"must be at least 0"}]}`, rr.Body.String())
+	})
+
+	t.Run("returns the problem of a wrapped sentinel error without writing it", func(t *testing.T) {
+		// act
+		p, ok := m.Problem(fmt.Errorf("saving item 3: %w", errNotFound))
+
+		// assert
+		require.True(t, ok)
+		require.Equal(t, response.Problem{Type: "about:blank", Title: "Not Found", Status: http.StatusNotFound, Detail: "item not found", Code: "item_not_found"}, p)
+	})
+
+	t.Run("returns no problem for an error that is not mapped", func(t *testing.T) {
+		// act
+		_, ok := m.Problem(errors.New("connection refused"))
+
+		// assert
+		require.False(t, ok)
+	})
+
+	t.Run("writes nothing for an error that is not mapped", func(t *testing.T) {
+		// act
+		rr := httptest.NewRecorder()
+		ok := m.Write(rr, errors.New("connection refused"))
+
+		// assert
+		require.False(t, ok)
+		require.Equal(t, 0, rr.Body.Len())
+	})
+}
@@ -0,0 +1,105 @@
+package response_test
+
+import (
+	"app/platform/web/response"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for JSONConditional function
+func TestJSONConditional(t *testing.T) {
+	body := struct{ Message string }{Message: "ok"}
+	etag := response.ETag([]byte(`{"Message":"ok"}`))
+	modified := time.Date(2024, 1, 2, 3, 4, 5, 600, time.UTC)
+
+	t.Run("200 - tags the body", func(t *testing.T) {
+		// arrange
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+		// act
+		rr := httptest.NewRecorder()
+		response.JSONConditional(rr, req, http.StatusOK, body, modified)
+
+		// assert
+		expectedHeader := http.Header{
+			"Content-Type":  []string{"application/json"},
+			"Etag":          []string{etag},
+			"Last-Modified": []string{"Tue, 02 Jan 2024 03:04:05 GMT"},
+		}
+		require.Equal(t, expectedHeader, rr.Header())
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.JSONEq(t, `{"Message":"ok"}`, rr.Body.String())
+	})
+
+	t.Run("304 - if none match", func(t *testing.T) {
+		// arrange
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("If-None-Match", `"other", W/`+etag)
+
+		// act
+		rr := httptest.NewRecorder()
+		response.JSONConditional(rr, req, http.StatusOK, body, time.Time{})
+
+		// assert
+		require.Equal(t, http.StatusNotModified, rr.Code)
+		require.Equal(t, etag, rr.Header().Get("ETag"))
+		require.Empty(t, rr.Body.String())
+	})
+
+	t.Run("200 - if none match takes precedence over if modified since", func(t *testing.T) {
+		// arrange
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("If-None-Match", `"other"`)
+		req.Header.Set("If-Modified-Since", modified.Format(http.TimeFormat))
+
+		// act
+		rr := httptest.NewRecorder()
+		response.JSONConditional(rr, req, http.StatusOK, body, modified)
+
+		// assert
+		require.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("304 - if modified since", func(t *testing.T) {
+		// arrange
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("If-Modified-Since", modified.Format(http.TimeFormat))
+
+		// act
+		rr := httptest.NewRecorder()
+		response.JSONConditional(rr, req, http.StatusOK, body, modified)
+
+		// assert
+		require.Equal(t, http.StatusNotModified, rr.Code)
+	})
+
+	t.Run("200 - modified since", func(t *testing.T) {
+		// arrange
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("If-Modified-Since", modified.Add(-time.Second).Format(http.TimeFormat))
+
+		// act
+		rr := httptest.NewRecorder()
+		response.JSONConditional(rr, req, http.StatusOK, body, modified)
+
+		// assert
+		require.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("200 - preconditions are ignored on other methods", func(t *testing.T) {
+		// arrange
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header.Set("If-None-Match", "*")
+
+		// act
+		rr := httptest.NewRecorder()
+		response.JSONConditional(rr, req, http.StatusOK, body, time.Time{})
+
+		// assert
+		require.Equal(t, http.StatusOK, rr.Code)
+	})
+}
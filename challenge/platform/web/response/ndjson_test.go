@@ -0,0 +1,55 @@
+package response_test
+
+import (
+	"app/platform/web/response"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for NDJSON writer
+func TestNDJSON(t *testing.T) {
+	t.Run("one line per value, flushed", func(t *testing.T) {
+		// arrange
+		// ...
+
+		// act
+		rr := httptest.NewRecorder()
+		nd := response.NewNDJSON(rr, http.StatusOK)
+		err1 := nd.Write(struct{ Id int }{Id: 1})
+		err2 := nd.Write(struct{ Id int }{Id: 2})
+		nd.Close()
+
+		// assert
+		expectedHeader := http.Header{"Content-Type": []string{"application/x-ndjson"}}
+		expectedCode := http.StatusOK
+		expectedBody := "{\"Id\":1}\n{\"Id\":2}\n"
+		require.NoError(t, err1)
+		require.NoError(t, err2)
+		require.True(t, nd.Started())
+		require.True(t, rr.Flushed)
+		require.Equal(t, expectedHeader, rr.Header())
+		require.Equal(t, expectedCode, rr.Code)
+		require.Equal(t, expectedBody, rr.Body.String())
+	})
+
+	t.Run("empty stream", func(t *testing.T) {
+		// arrange
+		// ...
+
+		// act
+		rr := httptest.NewRecorder()
+		nd := response.NewNDJSON(rr, http.StatusOK)
+		started := nd.Started()
+		nd.Close()
+
+		// assert
+		expectedHeader := http.Header{"Content-Type": []string{"application/x-ndjson"}}
+		require.False(t, started)
+		require.Equal(t, expectedHeader, rr.Header())
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Equal(t, "", rr.Body.String())
+	})
+}
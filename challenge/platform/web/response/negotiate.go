@@ -0,0 +1,154 @@
+package response
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format is a representation the lists and reports can be written in.
+type Format string
+
+const (
+	// FormatJSON is the usual json envelope.
+	FormatJSON Format = "json"
+	// FormatCSV is a spreadsheet of the rows, with a header row.
+	FormatCSV Format = "csv"
+	// FormatXML is the envelope as xml.
+	FormatXML Format = "xml"
+)
+
+const (
+	// ContentTypeCSV is the media type of the csv responses.
+	ContentTypeCSV = "text/csv; charset=utf-8"
+	// ContentTypeXML is the media type of the xml responses.
+	ContentTypeXML = "application/xml; charset=utf-8"
+)
+
+var (
+	// ErrFormatUnknown is used when the format query param is not one of the formats.
+	ErrFormatUnknown = errors.New("format unknown")
+	// ErrFormatNotAcceptable is used when the Accept header has none of the media types of the formats.
+	ErrFormatNotAcceptable = errors.New("format not acceptable")
+)
+
+// formatMediaTypes are the media types of the formats, in the order a wildcard picks them.
+var formatMediaTypes = []struct {
+	mediaType string
+	format    Format
+}{
+	{"application/json", FormatJSON},
+	{"text/csv", FormatCSV},
+	{"application/xml", FormatXML},
+	{"text/xml", FormatXML},
+}
+
+// NegotiateFormat returns the format of the response to r: the format query param if given,
+// otherwise the format of the media type the Accept header prefers, json if it is missing.
+// Errors wrap ErrFormatUnknown or ErrFormatNotAcceptable.
+func NegotiateFormat(r *http.Request) (f Format, err error) {
+	// query param, overriding the header for links and spreadsheets that cannot set it
+	if v := r.URL.Query().Get("format"); v != "" {
+		switch f = Format(strings.ToLower(v)); f {
+		case FormatJSON, FormatCSV, FormatXML:
+			return
+		}
+		f, err = "", fmt.Errorf("%w: %s, must be json, csv or xml", ErrFormatUnknown, v)
+		return
+	}
+
+	// header
+	accept := r.Header.Get("Accept")
+	if strings.TrimSpace(accept) == "" {
+		f = FormatJSON
+		return
+	}
+	best := 0.0
+	for _, rng := range strings.Split(accept, ",") {
+		mt, params, perr := mime.ParseMediaType(rng)
+		if perr != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, perr = strconv.ParseFloat(v, 64); perr != nil {
+				continue
+			}
+		}
+		// the first range of a higher quality wins, ties are won by the earlier one
+		if q <= best {
+			continue
+		}
+		for _, v := range formatMediaTypes {
+			if matchMediaType(mt, v.mediaType) {
+				f, best = v.format, q
+				break
+			}
+		}
+	}
+	if f == "" {
+		err = fmt.Errorf("%w: %s, accepts application/json, text/csv or application/xml", ErrFormatNotAcceptable, accept)
+	}
+	return
+}
+
+// matchMediaType returns true if the media range rng, e.g. text/*, matches the media type mt.
+func matchMediaType(rng, mt string) bool {
+	if rng == "*/*" || rng == mt {
+		return true
+	}
+	typ, sub, _ := strings.Cut(rng, "/")
+	return sub == "*" && strings.HasPrefix(mt, typ+"/")
+}
+
+// Table is a list or a report, written in the negotiated format by Negotiated.
+type Table struct {
+	// Body is the body in json and xml, the envelope of the rows.
+	Body any
+	// Rows are the body in csv, a slice of structs whose json tags name the columns, see CSV.
+	Rows any
+	// Filename is the name of the csv and xml downloads, without extension.
+	Filename string
+	// LastModified is the time the table was last modified, zero if unknown.
+	LastModified time.Time
+}
+
+// Negotiated writes t in the format f, conditionally like JSONConditional.
+// The responses vary on Accept, and csv and xml ones are sent as attachments named after t.Filename.
+func Negotiated(w http.ResponseWriter, r *http.Request, code int, f Format, t Table) {
+	// marshal body
+	var (
+		bytes       []byte
+		contentType string
+		err         error
+	)
+	switch f {
+	case FormatCSV:
+		bytes, err = CSV(t.Rows)
+		contentType = ContentTypeCSV
+	case FormatXML:
+		bytes, err = XML(t.Body)
+		contentType = ContentTypeXML
+	default:
+		bytes, err = json.Marshal(t.Body)
+		contentType = "application/json"
+	}
+	if err != nil {
+		// default error
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// set headers
+	w.Header().Add("Vary", "Accept")
+	if f == FormatCSV || f == FormatXML {
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": t.Filename + "." + string(f)}))
+	}
+
+	writeConditional(w, r, code, contentType, bytes, t.LastModified)
+}
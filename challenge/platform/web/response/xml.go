@@ -0,0 +1,113 @@
+package response
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// XML encodes body as xml the way encoding/json would encode it as json, under a response root element:
+// struct fields and map keys become elements named after their json names, empty elements for null,
+// and the elements of slices become item elements.
+func XML(body any) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	enc := xml.NewEncoder(&b)
+	if err := encodeXML(enc, "response", reflect.ValueOf(body)); err != nil {
+		return nil, err
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// encodeXML encodes v as the element name.
+func encodeXML(enc *xml.Encoder, name string, v reflect.Value) (err error) {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if err = enc.EncodeToken(start); err != nil {
+		return
+	}
+
+	// content, none for null
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			v = reflect.Value{}
+			break
+		}
+		v = v.Elem()
+	}
+	switch {
+	case !v.IsValid():
+	case v.Kind() == reflect.Slice || v.Kind() == reflect.Array || v.Kind() == reflect.Map:
+		err = encodeXMLCollection(enc, v)
+	case isScalar(v.Type()):
+		err = enc.EncodeToken(xml.CharData(formatScalar(v)))
+	default:
+		for _, f := range jsonFields(v.Type()) {
+			fv, ok := fieldByIndex(v, f.index)
+			if f.omitEmpty && (!ok || isEmptyValue(fv)) {
+				continue
+			}
+			if err = encodeXML(enc, f.name, fv); err != nil {
+				return
+			}
+		}
+	}
+	if err != nil {
+		return
+	}
+
+	return enc.EncodeToken(start.End())
+}
+
+// encodeXMLCollection encodes the elements of the slice or map v: map entries as elements named after their keys, sorted,
+// and slice elements as item elements.
+func encodeXMLCollection(enc *xml.Encoder, v reflect.Value) (err error) {
+	if v.Kind() == reflect.Map {
+		keys := v.MapKeys()
+		names := make([]string, len(keys))
+		for i, k := range keys {
+			names[i] = fmt.Sprint(k.Interface())
+		}
+		sort.Sort(byName{names, keys})
+		for i, k := range keys {
+			if err = encodeXML(enc, names[i], v.MapIndex(k)); err != nil {
+				return
+			}
+		}
+		return
+	}
+	for i := 0; i < v.Len(); i++ {
+		if err = encodeXML(enc, "item", v.Index(i)); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// isEmptyValue returns true if v is empty as the omitempty option of encoding/json defines it.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.String:
+		return v.Len() == 0
+	case reflect.Struct:
+		return false
+	}
+	return v.IsZero()
+}
+
+// byName sorts map keys by their names.
+type byName struct {
+	names []string
+	keys  []reflect.Value
+}
+
+func (s byName) Len() int           { return len(s.names) }
+func (s byName) Less(i, j int) bool { return s.names[i] < s.names[j] }
+func (s byName) Swap(i, j int) {
+	s.names[i], s.names[j] = s.names[j], s.names[i]
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+}
@@ -0,0 +1,117 @@
+package validate_test
+
+import (
+	"app/platform/web/validate"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type lineJSON struct {
+	Quantity int     `json:"quantity" validate:"required,min=1"`
+	Price    float64 `json:"price" validate:"min=0,max=999.99"`
+}
+
+type invoiceJSON struct {
+	Customer  string     `json:"customer" validate:"required,max=5"`
+	Date      string     `json:"date" validate:"required,date"`
+	Condition int        `json:"condition" validate:"oneof=0 1"`
+	Code      string     `json:"code" validate:"len=3"`
+	Lines     []lineJSON `json:"lines" validate:"required"`
+	Note      *string    `json:"note,omitempty" validate:"min=2"`
+}
+
+// Tests for Struct
+func TestStruct(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		// arrange
+		v := invoiceJSON{Customer: "Ike", Date: "2022-05-15", Condition: 1, Code: "ABC", Lines: []lineJSON{{Quantity: 1, Price: 9.5}}}
+
+		// act
+		err := validate.Struct(&v)
+
+		// assert
+		require.NoError(t, err)
+	})
+
+	t.Run("zero values pass every rule but required", func(t *testing.T) {
+		// arrange
+		v := invoiceJSON{Customer: "Ike", Date: "2022-05-15", Lines: []lineJSON{{Quantity: 1}}}
+
+		// act
+		err := validate.Struct(v)
+
+		// assert
+		require.NoError(t, err)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		// arrange
+		note := "a"
+		v := invoiceJSON{Customer: "Brannon", Date: "15/05/2022", Condition: 2, Code: "AB", Lines: []lineJSON{{Quantity: 1}, {Price: 1000}}, Note: &note}
+
+		// act
+		err := validate.Struct(&v)
+
+		// assert
+		require.ErrorIs(t, err, validate.ErrValidation)
+		require.Equal(t, validate.Errors{
+			{Field: "customer", Rule: "max", Message: "must have at most 5 characters"},
+			{Field: "date", Rule: "date", Message: "must be a date in the YYYY-MM-DD format"},
+			{Field: "condition", Rule: "oneof", Message: "must be one of 0, 1"},
+			{Field: "code", Rule: "len", Message: "must have exactly 3 characters"},
+			{Field: "lines.1.quantity", Rule: "required", Message: "is required"},
+			{Field: "lines.1.price", Rule: "max", Message: "must be at most 999.99"},
+			{Field: "note", Rule: "min", Message: "must have at least 2 characters"},
+		}, err)
+		require.EqualError(t, err, "validation failed: customer must have at most 5 characters; date must be a date in the YYYY-MM-DD format; "+
+			"condition must be one of 0, 1; code must have exactly 3 characters; lines.1.quantity is required; lines.1.price must be at most 999.99; "+
+			"note must have at least 2 characters")
+	})
+
+	t.Run("unknown rule", func(t *testing.T) {
+		// arrange
+		v := struct {
+			Name string `json:"name" validate:"email"`
+		}{Name: "ike"}
+
+		// act & assert
+		require.Panics(t, func() { validate.Struct(v) })
+	})
+	t.Run("optional fields are required to be present and their value checked", func(t *testing.T) {
+		// arrange
+		type conditionJSON struct {
+			Condition optional `json:"condition" validate:"required,oneof=0 1"`
+		}
+		cases := []struct {
+			v        conditionJSON
+			expected error
+		}{
+			{v: conditionJSON{Condition: optional{value: 0, present: true}}},
+			{v: conditionJSON{}, expected: validate.Errors{{Field: "condition", Rule: "required", Message: "is required"}}},
+			{v: conditionJSON{Condition: optional{value: 2, present: true}}, expected: validate.Errors{{Field: "condition", Rule: "oneof", Message: "must be one of 0, 1"}}},
+		}
+
+		for _, c := range cases {
+			// act
+			err := validate.Struct(c.v)
+
+			// assert
+			if c.expected == nil {
+				require.NoError(t, err)
+				continue
+			}
+			require.Equal(t, c.expected, err)
+		}
+	})
+}
+
+// optional is an Optional int.
+type optional struct {
+	value   int
+	present bool
+}
+
+func (o optional) Optional() (v any, present bool) {
+	return o.value, o.present
+}
@@ -1,40 +1,73 @@
 package main
 
 import (
-	"fmt"
+	"log/slog"
+	"os"
 	"supermarket/internal/application"
+	"supermarket/platform/auth"
+	"supermarket/platform/logging"
+	"supermarket/platform/tracing"
+	"time"
 
 	"github.com/go-sql-driver/mysql"
 )
 
 func main() {
+	// logger
+	lg := logging.New(os.Stdout, slog.LevelInfo)
+
 	// env
-	// ...
+	// - API_KEYS: comma separated key:role:subject entries, roles are viewer, clerk and admin
+	// - JWT_SECRET: HMAC key of the HS256 bearer tokens
+	keys, err := auth.ParseAPIKeys(os.Getenv("API_KEYS"))
+	if err != nil {
+		lg.Error("error parsing API_KEYS", "error", err)
+		return
+	}
+	au := auth.Config{
+		APIKeys: keys,
+		Secret:  []byte(os.Getenv("JWT_SECRET")),
+	}
+
+	// tracer
+	// - spans are appended to traces.jsonl, one JSON line per span
+	exp, err := tracing.NewFileExporter("traces.jsonl")
+	if err != nil {
+		lg.Error("error opening the trace file", "error", err)
+		return
+	}
+	defer exp.Close()
 
 	// app
 	// - config
 	// -- default store
-	// app := application.NewApplicationDefault("", "./docs/db/json/products.json")
+	// app := application.NewApplicationDefault("", "./docs/db/json/products.json", lg, au)
 	// -- mysql store
-	app := application.NewApplicationMySQL("", mysql.Config{
-		User:      "user1",
-		Passwd:    "secret_password",
-		Net:       "tcp",
-		Addr:      "localhost:3306",
-		DBName:    "supermarket",
-		ParseTime: true,
+	app := application.NewApplicationMySQL(application.ConfigApplicationMySQL{
+		Db: mysql.Config{
+			User:      "user1",
+			Passwd:    "secret_password",
+			Net:       "tcp",
+			Addr:      "localhost:3306",
+			DBName:    "supermarket",
+			ParseTime: true,
+		},
+		QueryTimeout:  5 * time.Second,
+		Logger:        lg,
+		TraceExporter: exp,
+		Auth:          au,
 	})
 
 	// - tear down
 	defer app.TearDown()
 	// - set up
 	if err := app.SetUp(); err != nil {
-		fmt.Println(err)
+		lg.Error("error setting up the application", "error", err)
 		return
 	}
 	// - run
 	if err := app.Run(); err != nil {
-		fmt.Println(err)
+		lg.Error("error running the application", "error", err)
 		return
 	}
 }
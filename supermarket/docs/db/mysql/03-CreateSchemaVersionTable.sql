@@ -0,0 +1,13 @@
+-- Uso base de datos `supermarket`
+USE `supermarket`;
+
+-- Crear tabla schema_version con la version del esquema aplicada
+-- La aplicacion verifica en /readyz que la version sea al menos la que necesita
+CREATE TABLE IF NOT EXISTS `schema_version` (
+  `version` int NOT NULL,
+  `applied_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`version`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Registrar la version 1 del esquema
+INSERT IGNORE INTO `schema_version` (`version`) VALUES (1);
@@ -0,0 +1,10 @@
+-- Uso base de datos `supermarket`
+USE `supermarket`;
+
+-- Agregar a products la fecha de su ultima modificacion, mantenida por MySQL
+-- La aplicacion la envia como Last-Modified en GET /products/{id}
+ALTER TABLE `products`
+  ADD `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP;
+
+-- Registrar la version 2 del esquema
+INSERT IGNORE INTO `schema_version` (`version`) VALUES (2);
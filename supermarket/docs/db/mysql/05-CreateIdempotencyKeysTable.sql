@@ -0,0 +1,20 @@
+-- Uso base de datos `supermarket`
+USE `supermarket`;
+
+-- Crear tabla idempotency_keys con las respuestas de los POST por cliente y header Idempotency-Key
+-- La aplicacion repite la respuesta guardada hasta que la clave expira, `status` es NULL mientras la request esta en curso
+CREATE TABLE IF NOT EXISTS `idempotency_keys` (
+  `client` varchar(255) NOT NULL,
+  `key` varchar(255) NOT NULL,
+  `request_hash` char(64) NOT NULL,
+  `status` int DEFAULT NULL,
+  `content_type` varchar(255) DEFAULT NULL,
+  `body` mediumblob,
+  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `expires_at` datetime NOT NULL,
+  PRIMARY KEY (`client`, `key`),
+  KEY `idx_idempotency_keys_expires_at` (`expires_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Registrar la version 3 del esquema
+INSERT IGNORE INTO `schema_version` (`version`) VALUES (3);
@@ -42,4 +42,33 @@ ALTER TABLE `warehouses`
 ALTER TABLE `products` ADD `id_warehouse` INT NOT NULL AFTER `price`;
 
 -- Designar el id_warehouse con el valor 1 a cada product
-UPDATE `products` SET `id_warehouse` = '1';
\ No newline at end of file
+UPDATE `products` SET `id_warehouse` = '1';
+
+-- Se agrega una columna con la fecha de la ultima modificacion de cada product
+ALTER TABLE `products` ADD `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP;
+
+DROP TABLE IF EXISTS `schema_version`;
+
+CREATE TABLE `schema_version` (
+  `version` int NOT NULL,
+  `applied_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`version`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+DROP TABLE IF EXISTS `idempotency_keys`;
+
+-- Respuestas de los POST por cliente y header Idempotency-Key, `status` es NULL mientras la request esta en curso
+CREATE TABLE `idempotency_keys` (
+  `client` varchar(255) NOT NULL,
+  `key` varchar(255) NOT NULL,
+  `request_hash` char(64) NOT NULL,
+  `status` int DEFAULT NULL,
+  `content_type` varchar(255) DEFAULT NULL,
+  `body` mediumblob,
+  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `expires_at` datetime NOT NULL,
+  PRIMARY KEY (`client`, `key`),
+  KEY `idx_idempotency_keys_expires_at` (`expires_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+INSERT INTO `schema_version` (`version`) VALUES (1), (2), (3);
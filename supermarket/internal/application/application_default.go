@@ -1,28 +1,52 @@
 package application
 
 import (
+	"log/slog"
 	"net/http"
+	"os"
 	"supermarket/internal/handler"
 	"supermarket/internal/repository"
 	"supermarket/internal/store"
+	"supermarket/platform/auth"
+	"supermarket/platform/health"
+	"supermarket/platform/idempotency"
+	"supermarket/platform/logging"
+	"supermarket/platform/metrics"
+	"supermarket/platform/ratelimit"
+	"supermarket/platform/web/response"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
 )
 
 // NewApplicationDefault creates a new default application.
-func NewApplicationDefault(addr, filePathStore string) (a *ApplicationDefault) {
+// A nil logger logs JSON lines on stdout. Every route but the health and metrics ones requires a role granted by au.
+func NewApplicationDefault(addr, filePathStore string, lg *slog.Logger, au auth.Config) (a *ApplicationDefault) {
 	// default config
 	defaultRouter := chi.NewRouter()
 	defaultAddr := ":8080"
 	if addr != "" {
 		defaultAddr = addr
 	}
+	if lg == nil {
+		lg = logging.New(os.Stdout, slog.LevelInfo)
+	}
+
+	srv := &http.Server{
+		Addr:           defaultAddr,
+		Handler:        defaultRouter,
+		ReadTimeout:    defaultReadTimeout,
+		WriteTimeout:   defaultWriteTimeout,
+		IdleTimeout:    defaultIdleTimeout,
+		MaxHeaderBytes: defaultMaxHeaderBytes,
+	}
 
 	a = &ApplicationDefault{
 		rt:            defaultRouter,
 		addr:          defaultAddr,
+		srv:           srv,
 		filePathStore: filePathStore,
+		lg:            lg,
+		au:            auth.NewAuthenticator(au),
 	}
 	return
 }
@@ -33,8 +57,14 @@ type ApplicationDefault struct {
 	rt *chi.Mux
 	// addr is the address to listen.
 	addr string
+	// srv is the http server.
+	srv *http.Server
 	// filePathStore is the file path to store.
 	filePathStore string
+	// lg is the logger of the application.
+	lg *slog.Logger
+	// au authenticates the requests.
+	au *auth.Authenticator
 }
 
 // TearDown tears down the application.
@@ -50,31 +80,68 @@ func (a *ApplicationDefault) SetUp() (err error) {
 	// - repository
 	rp := repository.NewRepositoryProductStore(st)
 	// - handler
-	hd := handler.NewHandlerProduct(rp)
+	hd := handler.NewHandlerProduct(rp, a.lg)
+	// - health
+	ck := health.NewChecker(defaultReadinessTimeout)
+	ck.Add("store", health.File(a.filePathStore))
+	hh := handler.NewHandlerHealth(ck, a.lg)
+	// - metrics
+	mh := metrics.NewHTTP()
+	reg := metrics.NewRegistry()
+	reg.Register(mh.Collectors()...)
 
 	// router
 	// - middlewares
-	a.rt.Use(middleware.Logger)
-	a.rt.Use(middleware.Recoverer)
+	a.rt.Use(logging.RequestIDMiddleware)
+	a.rt.Use(mh.Middleware)
+	a.rt.Use(logging.Middleware(a.lg))
+	a.rt.Use(logging.Recoverer(a.lg))
+	a.rt.Use(a.au.Middleware)
+	// - errors of the router, in the format of the ones of the handlers
+	a.rt.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		response.Error(w, http.StatusNotFound, "route not found")
+	})
+	a.rt.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		response.Error(w, http.StatusMethodNotAllowed, "method not allowed")
+	})
+	// - rate limits: a bucket per client, bulk creates have their own tighter one
+	limit := ratelimit.Middleware(ratelimit.NewLimiter(defaultRateLimit), ratelimit.ClientKey)
+	bulk := ratelimit.Middleware(ratelimit.NewLimiter(defaultBulkRateLimit), ratelimit.ClientKey)
+	// - idempotency: the creates replay the response of an Idempotency-Key of the same client, kept in memory as the store has no table for them
+	idempotent := idempotency.Middleware(idempotency.Config{
+		TTL:    defaultIdempotencyTTL,
+		Client: ratelimit.ClientKey,
+		Logger: a.lg,
+	})
 	// - endpoints
+	// GET /healthz
+	a.rt.Get("/healthz", hh.Live())
+	// GET /readyz
+	a.rt.Get("/readyz", hh.Ready())
+	// GET /metrics
+	a.rt.Get("/metrics", reg.Handler())
 	a.rt.Route("/products", func(r chi.Router) {
 		// GET /products/{id}
-		r.Get("/{id}", hd.GetById())
+		r.With(auth.Require(auth.RoleViewer), limit).Get("/{id}", hd.GetById())
 		// POST /products
-		r.Post("/", hd.Create())
+		r.With(auth.Require(auth.RoleClerk), limit, idempotent).Post("/", hd.Create())
+		// POST /products/bulk
+		r.With(auth.Require(auth.RoleClerk), bulk, idempotent).Post("/bulk", hd.CreateBulk())
 		// PUT /products/{id}
-		r.Put("/{id}", hd.UpdateOrCreate())
+		r.With(auth.Require(auth.RoleClerk), limit).Put("/{id}", hd.UpdateOrCreate())
 		// PATCH /products/{id}
-		r.Patch("/{id}", hd.Update())
+		r.With(auth.Require(auth.RoleClerk), limit).Patch("/{id}", hd.Update())
 		// DELETE /products/{id}
-		r.Delete("/{id}", hd.Delete())
+		r.With(auth.Require(auth.RoleAdmin), limit).Delete("/{id}", hd.Delete())
 	})
+	// GET /openapi.json
+	a.rt.Get("/openapi.json", openAPI().Handler(a.rt))
 
 	return
 }
 
-// Run runs the application.
+// Run runs the application until it receives SIGINT or SIGTERM, then drains the in-flight requests.
 func (a *ApplicationDefault) Run() (err error) {
-	err = http.ListenAndServe(a.addr, a.rt)
+	err = serve(a.srv, defaultShutdownTimeout)
 	return
 }
@@ -1,31 +1,158 @@
 package application
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"os"
 	"supermarket/internal/handler"
 	"supermarket/internal/repository"
+	"supermarket/platform/auth"
+	"supermarket/platform/health"
+	"supermarket/platform/idempotency"
+	"supermarket/platform/logging"
+	"supermarket/platform/metrics"
+	"supermarket/platform/ratelimit"
+	"supermarket/platform/sqlstats"
+	"supermarket/platform/tracing"
+	"supermarket/platform/web/response"
+	"time"
 
 	"github.com/go-sql-driver/mysql"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
 )
 
+// ConfigApplicationMySQL is the configuration for NewApplicationMySQL.
+type ConfigApplicationMySQL struct {
+	// Addr is the address to listen.
+	Addr string
+	// Db is the database config.
+	Db mysql.Config
+	// QueryTimeout bounds each database query, on top of the request being cancelled. Zero means no timeout.
+	QueryTimeout time.Duration
+	// ReadTimeout is the maximum duration for reading a request, including its body.
+	ReadTimeout time.Duration
+	// WriteTimeout is the maximum duration for writing a response, streamed responses are not bounded by it.
+	WriteTimeout time.Duration
+	// IdleTimeout is the maximum duration a keep-alive connection waits for the next request.
+	IdleTimeout time.Duration
+	// MaxHeaderBytes is the maximum size of the request headers.
+	MaxHeaderBytes int
+	// ShutdownTimeout is the maximum duration to wait for in-flight requests on shutdown.
+	ShutdownTimeout time.Duration
+	// StartupTimeout is the maximum duration to wait for the database to be reachable on set up.
+	StartupTimeout time.Duration
+	// ReadinessTimeout is the deadline of the readiness checks.
+	ReadinessTimeout time.Duration
+	// Logger is the logger of the application, JSON lines on stdout by default.
+	Logger *slog.Logger
+	// TraceExporter receives the spans of the requests, e.g. a tracing.JSONExporter.
+	// If nil, traces are propagated but not exported.
+	TraceExporter tracing.Exporter
+	// SlowQueryThreshold is the duration above which a statement is logged along with its plan.
+	SlowQueryThreshold time.Duration
+	// Auth configures the API keys and the secret of the bearer tokens.
+	// Every route but the health and metrics ones requires a role, so the zero value rejects them all.
+	Auth auth.Config
+	// RateLimit is the rate allowed to each client on most routes.
+	RateLimit ratelimit.Limit
+	// ReportRateLimit is the tighter rate allowed to each client on the report routes, which aggregate whole tables.
+	ReportRateLimit ratelimit.Limit
+	// BulkRateLimit is the tighter rate allowed to each client on the bulk create routes, which write many rows per request.
+	BulkRateLimit ratelimit.Limit
+	// IdempotencyTTL is the duration the responses of the Idempotency-Key headers are replayed for.
+	IdempotencyTTL time.Duration
+}
+
 // NewApplicationMySQL creates a new default application.
-func NewApplicationMySQL(addr string, dbConfig mysql.Config) (a *ApplicationMySQL) {
+func NewApplicationMySQL(cfg ConfigApplicationMySQL) (a *ApplicationMySQL) {
 	// default config
 	defaultRouter := chi.NewRouter()
 	defaultAddr := ":8080"
-	if addr != "" {
-		defaultAddr = addr
+	if cfg.Addr != "" {
+		defaultAddr = cfg.Addr
+	}
+	defaultQueryTimeout := 5 * time.Second
+	if cfg.QueryTimeout != 0 {
+		defaultQueryTimeout = cfg.QueryTimeout
+	}
+	srv := &http.Server{
+		Addr:           defaultAddr,
+		Handler:        defaultRouter,
+		ReadTimeout:    defaultReadTimeout,
+		WriteTimeout:   defaultWriteTimeout,
+		IdleTimeout:    defaultIdleTimeout,
+		MaxHeaderBytes: defaultMaxHeaderBytes,
+	}
+	if cfg.ReadTimeout != 0 {
+		srv.ReadTimeout = cfg.ReadTimeout
+	}
+	if cfg.WriteTimeout != 0 {
+		srv.WriteTimeout = cfg.WriteTimeout
+	}
+	if cfg.IdleTimeout != 0 {
+		srv.IdleTimeout = cfg.IdleTimeout
+	}
+	if cfg.MaxHeaderBytes != 0 {
+		srv.MaxHeaderBytes = cfg.MaxHeaderBytes
+	}
+	shutdownTimeout := defaultShutdownTimeout
+	if cfg.ShutdownTimeout != 0 {
+		shutdownTimeout = cfg.ShutdownTimeout
+	}
+	startupTimeout := defaultStartupTimeout
+	if cfg.StartupTimeout != 0 {
+		startupTimeout = cfg.StartupTimeout
+	}
+	readinessTimeout := defaultReadinessTimeout
+	if cfg.ReadinessTimeout != 0 {
+		readinessTimeout = cfg.ReadinessTimeout
+	}
+	slowQueryThreshold := defaultSlowQueryThreshold
+	if cfg.SlowQueryThreshold != 0 {
+		slowQueryThreshold = cfg.SlowQueryThreshold
+	}
+	rateLimit := defaultRateLimit
+	if cfg.RateLimit != (ratelimit.Limit{}) {
+		rateLimit = cfg.RateLimit
+	}
+	reportRateLimit := defaultReportRateLimit
+	if cfg.ReportRateLimit != (ratelimit.Limit{}) {
+		reportRateLimit = cfg.ReportRateLimit
+	}
+	bulkRateLimit := defaultBulkRateLimit
+	if cfg.BulkRateLimit != (ratelimit.Limit{}) {
+		bulkRateLimit = cfg.BulkRateLimit
+	}
+	idempotencyTTL := defaultIdempotencyTTL
+	if cfg.IdempotencyTTL != 0 {
+		idempotencyTTL = cfg.IdempotencyTTL
+	}
+	lg := cfg.Logger
+	if lg == nil {
+		lg = logging.New(os.Stdout, slog.LevelInfo)
 	}
 
 	a = &ApplicationMySQL{
-		rt:       defaultRouter,
-		addr:     defaultAddr,
-		dbConfig: dbConfig,
+		rt:                 defaultRouter,
+		addr:               defaultAddr,
+		srv:                srv,
+		shutdownTimeout:    shutdownTimeout,
+		startupTimeout:     startupTimeout,
+		readinessTimeout:   readinessTimeout,
+		dbConfig:           cfg.Db,
+		queryTimeout:       defaultQueryTimeout,
+		slowQueryThreshold: slowQueryThreshold,
+		idempotencyTTL:     idempotencyTTL,
+		lg:                 lg,
+		tracer:             tracing.NewTracer(cfg.TraceExporter),
+		au:                 auth.NewAuthenticator(cfg.Auth),
+		limit:              ratelimit.Middleware(ratelimit.NewLimiter(rateLimit), ratelimit.ClientKey),
+		report:             ratelimit.Middleware(ratelimit.NewLimiter(reportRateLimit), ratelimit.ClientKey),
+		bulk:               ratelimit.Middleware(ratelimit.NewLimiter(bulkRateLimit), ratelimit.ClientKey),
 	}
 	return
 }
@@ -36,17 +163,50 @@ type ApplicationMySQL struct {
 	rt *chi.Mux
 	// addr is the address to listen.
 	addr string
+	// srv is the http server.
+	srv *http.Server
+	// shutdownTimeout is the maximum duration to wait for in-flight requests on shutdown.
+	shutdownTimeout time.Duration
+	// startupTimeout is the maximum duration to wait for the database on set up.
+	startupTimeout time.Duration
+	// readinessTimeout is the deadline of the readiness checks.
+	readinessTimeout time.Duration
 	// dbConfig is the database config.
 	dbConfig mysql.Config
+	// queryTimeout bounds each database query.
+	queryTimeout time.Duration
+	// slowQueryThreshold is the duration above which a statement is logged along with its plan.
+	slowQueryThreshold time.Duration
+	// idempotencyTTL is the duration the responses of the Idempotency-Key headers are replayed for.
+	idempotencyTTL time.Duration
+	// lg is the logger of the application.
+	lg *slog.Logger
+	// tracer starts the spans of the requests.
+	tracer *tracing.Tracer
+	// au authenticates the requests.
+	au *auth.Authenticator
+	// limit rate limits each client on most routes.
+	limit func(http.Handler) http.Handler
+	// report rate limits each client on the report routes, with a tighter rate.
+	report func(http.Handler) http.Handler
+	// bulk rate limits each client on the bulk create routes, with a tighter rate.
+	bulk func(http.Handler) http.Handler
+	// idempotent replays the responses of the creates by client and Idempotency-Key, kept in the database.
+	idempotent func(http.Handler) http.Handler
 	// db is the connection to the database.
 	db *sql.DB
+	// stats records the statements run against the database.
+	stats *sqlstats.Stats
 }
 
 // TearDown tears down the application.
 func (a *ApplicationMySQL) TearDown() (err error) {
 	// close the database connection
+	if a.db == nil {
+		return
+	}
 	if err := a.db.Close(); err != nil {
-		fmt.Printf("error closing database: %v", err)
+		a.lg.Error("error closing database", "error", err)
 	}
 	return
 }
@@ -55,19 +215,81 @@ func (a *ApplicationMySQL) TearDown() (err error) {
 func (a *ApplicationMySQL) SetUp() (err error) {
 	// dependencies
 
-	// - store
-	db, err := sql.Open("mysql", a.dbConfig.FormatDSN())
+	// - store, tracing and recording each statement
+	cn, err := mysql.NewConnector(&a.dbConfig)
 	if err != nil {
-		fmt.Printf("error connecting to database: %v", err)
+		err = fmt.Errorf("error connecting to database: %w", err)
+		return
 	}
-	if err := db.Ping(); err != nil {
-		fmt.Printf("error pinging database: %v", err)
+	a.db, a.stats = sqlstats.Open(tracing.WrapConnector(cn), sqlstats.Config{
+		SlowThreshold: a.slowQueryThreshold,
+		Logger:        a.lg,
+	})
+	// - store: ping, retrying with backoff until the database is reachable
+	err = health.Retry(context.Background(), health.Ping(a.db), 100*time.Millisecond, a.startupTimeout)
+	if err != nil {
+		err = fmt.Errorf("error pinging database: %w", err)
+		return
 	}
-	a.db = db
+
+	err = a.setUpRouter()
+	return
+}
+
+// setUpRouter registers the middlewares and the routes on top of the database.
+// It does not use the database, so that the routes can be checked without one.
+func (a *ApplicationMySQL) setUpRouter() (err error) {
+	// - metrics
+	mh := metrics.NewHTTP()
+	reg := metrics.NewRegistry()
+	reg.Register(mh.Collectors()...)
+	reg.Register(metrics.DBStats(a.db)...)
+	reg.Register(repository.QueryDuration)
 
 	// - middlewares
-	a.rt.Use(middleware.Logger)
-	a.rt.Use(middleware.Recoverer)
+	a.rt.Use(logging.RequestIDMiddleware)
+	a.rt.Use(tracing.Middleware(a.tracer))
+	a.rt.Use(mh.Middleware)
+	a.rt.Use(logging.Middleware(a.lg))
+	a.rt.Use(logging.Recoverer(a.lg))
+	a.rt.Use(a.au.Middleware)
+	// - errors of the router, in the format of the ones of the handlers
+	a.rt.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		response.Error(w, http.StatusNotFound, "route not found")
+	})
+	a.rt.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		response.Error(w, http.StatusMethodNotAllowed, "method not allowed")
+	})
+
+	// - health
+	ck := health.NewChecker(a.readinessTimeout)
+	ck.Add("database", health.Ping(a.db))
+	ck.Add("schema", health.SchemaVersion(a.db, schemaVersion))
+	hh := handler.NewHandlerHealth(ck, a.lg)
+	// GET /healthz
+	a.rt.Get("/healthz", hh.Live())
+	// GET /readyz
+	a.rt.Get("/readyz", hh.Ready())
+	// GET /metrics
+	a.rt.Get("/metrics", reg.Handler())
+
+	// - query statistics
+	hq := handler.NewHandlerQueryStats(a.stats)
+	a.rt.Route("/admin", func(r chi.Router) {
+		r.Use(auth.Require(auth.RoleAdmin), a.limit)
+		// GET /admin/queries
+		r.Get("/queries", hq.GetTop())
+		// DELETE /admin/queries
+		r.Delete("/queries", hq.Reset())
+	})
+
+	// - idempotency of the creates
+	a.idempotent = idempotency.Middleware(idempotency.Config{
+		Store:  idempotency.NewMySQL(a.db),
+		TTL:    a.idempotencyTTL,
+		Client: ratelimit.ClientKey,
+		Logger: a.lg,
+	})
 
 	// - warehouse
 	err = a.setUpWarehouse()
@@ -80,56 +302,60 @@ func (a *ApplicationMySQL) SetUp() (err error) {
 		return
 	}
 
+	// GET /openapi.json
+	a.rt.Get("/openapi.json", openAPI().Handler(a.rt))
 	return
 }
 
-// Run runs the application.
+// Run runs the application until it receives SIGINT or SIGTERM, then drains the in-flight requests.
 func (a *ApplicationMySQL) Run() (err error) {
-	err = http.ListenAndServe(a.addr, a.rt)
+	err = serve(a.srv, a.shutdownTimeout)
 	return
 }
 
 func (a *ApplicationMySQL) setUpWarehouse() (err error) {
 	// dependencies
 	// - repository
-	rw := repository.NewRepositoryWarehouseMySQL(a.db)
+	rw := repository.NewRepositoryWarehouseMySQL(a.db, a.queryTimeout, a.lg)
 	// - handler
-	wh := handler.NewWarehouseHandler(rw)
+	wh := handler.NewWarehouseHandler(rw, a.lg)
 	// routes
 	a.rt.Route("/warehouses", func(r chi.Router) {
 		// GET /warehouses
-		r.Get("/", wh.GetAll())
+		r.With(auth.Require(auth.RoleViewer), a.limit).Get("/", wh.GetAll())
 		// GET /warehouses/{id}
-		r.Get("/{id}", wh.GetByID())
+		r.With(auth.Require(auth.RoleViewer), a.limit).Get("/{id}", wh.GetByID())
 		// POST /warehouses
-		r.Post("/", wh.Create())
+		r.With(auth.Require(auth.RoleClerk), a.limit, a.idempotent).Post("/", wh.Create())
 		// GET /reportProducts
-		r.Get("/reportProducts", wh.GetProductReports())
+		r.With(auth.Require(auth.RoleViewer), a.report).Get("/reportProducts", wh.GetProductReports())
 	})
 	return
 }
 
 func (a *ApplicationMySQL) setUpProduct() (err error) {
 	// - repository
-	rp := repository.NewRepositoryProductMySQL(a.db)
+	rp := repository.NewRepositoryProductMySQL(a.db, a.queryTimeout, a.lg)
 	// - handler
-	hd := handler.NewHandlerProduct(rp)
+	hd := handler.NewHandlerProduct(rp, a.lg)
 
 	// router
 	// - endpoints
 	a.rt.Route("/products", func(r chi.Router) {
 		// GET /products
-		r.Get("/", hd.GetAll())
+		r.With(auth.Require(auth.RoleViewer), a.limit).Get("/", hd.GetAll())
 		// GET /products/{id}
-		r.Get("/{id}", hd.GetById())
+		r.With(auth.Require(auth.RoleViewer), a.limit).Get("/{id}", hd.GetById())
 		// POST /products
-		r.Post("/", hd.Create())
+		r.With(auth.Require(auth.RoleClerk), a.limit, a.idempotent).Post("/", hd.Create())
+		// POST /products/bulk
+		r.With(auth.Require(auth.RoleClerk), a.bulk, a.idempotent).Post("/bulk", hd.CreateBulk())
 		// PUT /products/{id}
-		r.Put("/{id}", hd.UpdateOrCreate())
+		r.With(auth.Require(auth.RoleClerk), a.limit).Put("/{id}", hd.UpdateOrCreate())
 		// PATCH /products/{id}
-		r.Patch("/{id}", hd.Update())
+		r.With(auth.Require(auth.RoleClerk), a.limit).Patch("/{id}", hd.Update())
 		// DELETE /products/{id}
-		r.Delete("/{id}", hd.Delete())
+		r.With(auth.Require(auth.RoleAdmin), a.limit).Delete("/{id}", hd.Delete())
 	})
 	return
 }
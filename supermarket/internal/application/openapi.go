@@ -0,0 +1,161 @@
+package application
+
+import (
+	"net/http"
+	"supermarket/internal/handler"
+	"supermarket/platform/auth"
+	"supermarket/platform/idempotency"
+	"supermarket/platform/metrics"
+	"supermarket/platform/openapi"
+	"supermarket/platform/web/response"
+)
+
+// envelope is the body of the successful responses.
+type envelope[T any] struct {
+	Message string `json:"message"`
+	Data    T      `json:"data"`
+}
+
+// pageEnvelope is the body of a page of a list.
+type pageEnvelope[T any] struct {
+	Message string           `json:"message"`
+	Data    T                `json:"data"`
+	Page    handler.PageJSON `json:"page"`
+}
+
+// healthBody is the body of the liveness and readiness responses.
+type healthBody struct {
+	Status string                             `json:"status"`
+	Checks map[string]handler.HealthCheckJSON `json:"checks,omitempty"`
+}
+
+// listParams are the query parameters of the lists, any other parameter is a filter.
+var listParams = []openapi.Param{
+	{Name: "limit", Type: "integer", Description: "Page size, or the total number of items when streaming with Accept: application/x-ndjson."},
+	{Name: "cursor", Description: "The next_cursor of the previous page."},
+	{Name: "sort", Description: "Field to sort by, prefixed with - for descending order."},
+}
+
+// formatParam is the query parameter of the lists and reports choosing their format, see response.NegotiateFormat.
+var formatParam = openapi.Param{Name: "format", Description: "json, csv or xml, overriding the Accept header. csv and xml are downloads."}
+
+// negotiated returns op of a list or report, with the format parameter and the csv and xml responses of response.Negotiated.
+func negotiated(op openapi.Operation) openapi.Operation {
+	op.Params = append(append([]openapi.Param{}, op.Params...), formatParam)
+	op.Responses = append(op.Responses,
+		openapi.Response{Status: http.StatusOK, ContentType: "text/csv", Body: openapi.Schema{"type": "string"}},
+		openapi.Response{Status: http.StatusOK, ContentType: "application/xml", Body: openapi.Schema{"type": "string"}},
+	)
+	op.Responses = append(op.Responses, fails(http.StatusBadRequest, http.StatusNotAcceptable)...)
+	return op
+}
+
+// bulkParams are the query parameters of the bulk creates.
+var bulkParams = []openapi.Param{
+	{Name: "mode", Description: "atomic, the default, to create all the items or none, or best_effort to create those that can be."},
+}
+
+// bulk returns the operation creating the items of body in bulk at path, body being a slice of request bodies.
+func bulk(path, summary, tag string, body any) openapi.Operation {
+	return secured(auth.RoleClerk, idempotent(openapi.Operation{Method: http.MethodPost, Path: path, Summary: summary, Tags: []string{tag},
+		Params: bulkParams,
+		Body:   body,
+		Responses: append([]openapi.Response{
+			{Status: http.StatusCreated, Description: "Every item created.", Body: envelope[handler.BulkJSON]{}},
+			{Status: http.StatusMultiStatus, Description: "Some items not created, the status and error of each are listed.", Body: envelope[handler.BulkJSON]{}},
+		}, fails(http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnsupportedMediaType, http.StatusUnprocessableEntity)...)}))
+}
+
+// idempotencyParam is the header parameter of the creates replaying their response, see idempotency.Middleware.
+var idempotencyParam = openapi.Param{Name: idempotency.HeaderKey, In: "header",
+	Description: "Key of the request, to retry it safely: the response of the first request with the key is replayed, " +
+		"with Idempotent-Replayed: true, until the key expires. Reusing it with another request fails with 422."}
+
+// idempotent returns op of a create, with the Idempotency-Key header and the responses of idempotency.Middleware.
+func idempotent(op openapi.Operation) openapi.Operation {
+	op.Params = append(append([]openapi.Param{}, op.Params...), idempotencyParam)
+	op.Responses = append(op.Responses, fails(http.StatusBadRequest, http.StatusConflict, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity)...)
+	return op
+}
+
+// ok returns the successful response of an operation.
+func ok(status int, body any) openapi.Response {
+	return openapi.Response{Status: status, Body: body}
+}
+
+// fails returns the error responses of an operation with the given status codes.
+// Operations requiring a role may also fail with 401, 403 and 429, added by secured.
+func fails(codes ...int) (rs []openapi.Response) {
+	for _, c := range codes {
+		rs = append(rs, openapi.Response{Status: c, ContentType: response.ContentTypeProblem, Body: response.Problem{}})
+	}
+	return
+}
+
+// secured returns op requiring role, with the responses of the authentication and rate limit middlewares.
+func secured(role auth.Role, op openapi.Operation) openapi.Operation {
+	op.Role = role
+	op.Responses = append(op.Responses, fails(http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests)...)
+	return op
+}
+
+// openAPI returns the OpenAPI document of the routes of both applications.
+// Each serves the operations it registers, and TestOpenAPI fails when a registered route is missing from it.
+func openAPI() (d *openapi.Document) {
+	d = openapi.New("supermarket", "1.0.0")
+
+	// operations
+	d.Add(
+		openapi.Operation{Method: http.MethodGet, Path: "/healthz", Summary: "Liveness of the process.", Tags: []string{"operations"},
+			Responses: []openapi.Response{ok(http.StatusOK, healthBody{})}},
+		openapi.Operation{Method: http.MethodGet, Path: "/readyz", Summary: "Readiness of the dependencies.", Tags: []string{"operations"},
+			Responses: []openapi.Response{ok(http.StatusOK, healthBody{}), ok(http.StatusServiceUnavailable, healthBody{})}},
+		openapi.Operation{Method: http.MethodGet, Path: "/metrics", Summary: "Prometheus metrics.", Tags: []string{"operations"},
+			Responses: []openapi.Response{{Status: http.StatusOK, ContentType: metrics.ContentType, Body: openapi.Schema{"type": "string"}}}},
+		openapi.Operation{Method: http.MethodGet, Path: "/openapi.json", Summary: "This document.", Tags: []string{"operations"},
+			Responses: []openapi.Response{ok(http.StatusOK, openapi.Schema{"type": "object"})}},
+		secured(auth.RoleAdmin, openapi.Operation{Method: http.MethodGet, Path: "/admin/queries", Summary: "Top queries by total time.", Tags: []string{"operations"},
+			Params:    []openapi.Param{{Name: "limit", Type: "integer", Description: "Number of queries, 10 by default."}},
+			Responses: append([]openapi.Response{ok(http.StatusOK, envelope[[]handler.QueryStatsJSON]{})}, fails(http.StatusBadRequest)...)}),
+		secured(auth.RoleAdmin, openapi.Operation{Method: http.MethodDelete, Path: "/admin/queries", Summary: "Reset the query statistics.", Tags: []string{"operations"},
+			Responses: []openapi.Response{{Status: http.StatusNoContent}}}),
+	)
+
+	// warehouses
+	d.Add(
+		secured(auth.RoleViewer, negotiated(openapi.Operation{Method: http.MethodGet, Path: "/warehouses", Summary: "List the warehouses.", Tags: []string{"warehouses"},
+			Params:    listParams,
+			Responses: append([]openapi.Response{ok(http.StatusOK, pageEnvelope[[]handler.WarehouseJSON]{})}, fails(http.StatusBadRequest, http.StatusInternalServerError)...)})),
+		secured(auth.RoleViewer, openapi.Operation{Method: http.MethodGet, Path: "/warehouses/{id}", Summary: "Get a warehouse.", Tags: []string{"warehouses"},
+			Responses: append([]openapi.Response{ok(http.StatusOK, envelope[handler.WarehouseJSON]{})}, fails(http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError)...)}),
+		secured(auth.RoleClerk, idempotent(openapi.Operation{Method: http.MethodPost, Path: "/warehouses", Summary: "Create a warehouse.", Tags: []string{"warehouses"},
+			Body:      handler.RequestBodyWarehouseCreate{},
+			Responses: append([]openapi.Response{ok(http.StatusCreated, envelope[handler.WarehouseJSON]{})}, fails(http.StatusBadRequest, http.StatusUnprocessableEntity, http.StatusInternalServerError)...)})),
+		secured(auth.RoleViewer, negotiated(openapi.Operation{Method: http.MethodGet, Path: "/warehouses/reportProducts", Summary: "Number of products by warehouse.", Tags: []string{"warehouses", "reports"},
+			Params:    []openapi.Param{{Name: "id", Type: "integer", Description: "Warehouse to report, repeated for several, every warehouse by default."}},
+			Responses: append([]openapi.Response{ok(http.StatusOK, envelope[[]handler.ReportProductJSON]{})}, fails(http.StatusBadRequest, http.StatusInternalServerError)...)})),
+	)
+
+	// products
+	d.Add(
+		secured(auth.RoleViewer, negotiated(openapi.Operation{Method: http.MethodGet, Path: "/products", Summary: "List the products.", Tags: []string{"products"},
+			Params:    listParams,
+			Responses: append([]openapi.Response{ok(http.StatusOK, pageEnvelope[[]handler.ProductJSON]{})}, fails(http.StatusBadRequest, http.StatusInternalServerError)...)})),
+		secured(auth.RoleViewer, openapi.Operation{Method: http.MethodGet, Path: "/products/{id}", Summary: "Get a product.", Tags: []string{"products"},
+			Responses: append([]openapi.Response{ok(http.StatusOK, envelope[handler.ProductJSON]{})}, fails(http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError)...)}),
+		secured(auth.RoleClerk, idempotent(openapi.Operation{Method: http.MethodPost, Path: "/products", Summary: "Create a product.", Tags: []string{"products"},
+			Body:      handler.RequestBodyProductCreate{},
+			Responses: append([]openapi.Response{ok(http.StatusCreated, envelope[handler.ProductJSON]{})}, fails(http.StatusBadRequest, http.StatusUnprocessableEntity, http.StatusInternalServerError)...)})),
+		bulk("/products/bulk", "Create products in bulk.", "products", []handler.RequestBodyProductCreate{}),
+		secured(auth.RoleClerk, openapi.Operation{Method: http.MethodPut, Path: "/products/{id}", Summary: "Replace a product, creating it if it does not exist.", Tags: []string{"products"},
+			Body:      handler.RequestBodyProductCreate{},
+			Responses: append([]openapi.Response{ok(http.StatusOK, envelope[handler.ProductJSON]{})}, fails(http.StatusBadRequest, http.StatusUnprocessableEntity, http.StatusInternalServerError)...)}),
+		secured(auth.RoleClerk, openapi.Operation{Method: http.MethodPatch, Path: "/products/{id}", Summary: "Update the given fields of a product.", Tags: []string{"products"},
+			Body:      handler.RequestBodyProductPatch{},
+			Responses: append([]openapi.Response{ok(http.StatusOK, envelope[handler.ProductJSON]{})}, fails(http.StatusBadRequest, http.StatusUnprocessableEntity, http.StatusNotFound, http.StatusInternalServerError)...)}),
+		secured(auth.RoleAdmin, openapi.Operation{Method: http.MethodDelete, Path: "/products/{id}", Summary: "Delete a product.", Tags: []string{"products"},
+			Responses: append([]openapi.Response{{Status: http.StatusNoContent}}, fails(http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError)...)}),
+	)
+
+	return
+}
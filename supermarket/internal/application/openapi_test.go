@@ -0,0 +1,37 @@
+package application
+
+import (
+	"supermarket/platform/auth"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for openAPI
+func TestOpenAPI(t *testing.T) {
+	t.Run("describes every route of the mysql application", func(t *testing.T) {
+		// arrange
+		a := NewApplicationMySQL(ConfigApplicationMySQL{})
+		require.NoError(t, a.setUpRouter())
+
+		// act
+		missing, err := openAPI().Missing(a.rt)
+
+		// assert
+		require.NoError(t, err)
+		require.Empty(t, missing, "routes missing from the openapi document")
+	})
+
+	t.Run("describes every route of the default application", func(t *testing.T) {
+		// arrange
+		a := NewApplicationDefault("", t.TempDir()+"/products.json", nil, auth.Config{})
+		require.NoError(t, a.SetUp())
+
+		// act
+		missing, err := openAPI().Missing(a.rt)
+
+		// assert
+		require.NoError(t, err)
+		require.Empty(t, missing, "routes missing from the openapi document")
+	})
+}
@@ -0,0 +1,105 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"supermarket/platform/ratelimit"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+const (
+	// defaultReadTimeout is the maximum duration for reading a request, including its body.
+	defaultReadTimeout = 10 * time.Second
+	// defaultWriteTimeout is the maximum duration for writing a response, streamed responses are not bounded by it.
+	defaultWriteTimeout = 30 * time.Second
+	// defaultIdleTimeout is the maximum duration a keep-alive connection waits for the next request.
+	defaultIdleTimeout = 60 * time.Second
+	// defaultMaxHeaderBytes is the maximum size of the request headers.
+	defaultMaxHeaderBytes = 1 << 20
+	// defaultShutdownTimeout is the maximum duration to wait for in-flight requests on shutdown.
+	defaultShutdownTimeout = 15 * time.Second
+	// cancelTimeout is the maximum duration to wait for the requests cancelled after the shutdown timeout to return.
+	cancelTimeout = 2 * time.Second
+	// defaultStartupTimeout is the maximum duration to wait for the database to be reachable on set up.
+	defaultStartupTimeout = 30 * time.Second
+	// defaultReadinessTimeout is the deadline of the readiness checks.
+	defaultReadinessTimeout = 2 * time.Second
+	// defaultSlowQueryThreshold is the duration above which a statement is logged along with its plan.
+	defaultSlowQueryThreshold = 500 * time.Millisecond
+	// defaultIdempotencyTTL is the duration the responses of the Idempotency-Key headers are replayed for.
+	defaultIdempotencyTTL = 24 * time.Hour
+)
+
+var (
+	// defaultRateLimit is the rate allowed to each client on most routes.
+	defaultRateLimit = ratelimit.Limit{Rate: 20, Burst: 40}
+	// defaultReportRateLimit is the rate allowed to each client on the report routes.
+	defaultReportRateLimit = ratelimit.Limit{Rate: 0.5, Burst: 5}
+	// defaultBulkRateLimit is the rate allowed to each client on the bulk create routes.
+	defaultBulkRateLimit = ratelimit.Limit{Rate: 1, Burst: 5}
+)
+
+// schemaVersion is the version of the database schema the application needs, see docs/db/mysql.
+const schemaVersion = 3
+
+// serve listens and serves srv until the process receives SIGINT or SIGTERM.
+// Then it stops accepting connections and waits at most shutdownTimeout for the in-flight requests to finish.
+// Requests still running after the deadline have their context cancelled, which aborts their queries,
+// and serve waits at most cancelTimeout more for them to return.
+func serve(srv *http.Server, shutdownTimeout time.Duration) (err error) {
+	// signals
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// base context of the requests, cancelled when the shutdown deadline is exceeded
+	ctxBase, cancelBase := context.WithCancel(context.Background())
+	defer cancelBase()
+	srv.BaseContext = func(net.Listener) context.Context { return ctxBase }
+
+	// in-flight requests, waited for once cancelled
+	var inFlight atomic.Int64
+	h := srv.Handler
+	if h == nil {
+		h = http.DefaultServeMux
+	}
+	srv.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inFlight.Add(1)
+		defer inFlight.Add(-1)
+		h.ServeHTTP(w, r)
+	})
+
+	// serve
+	errServe := make(chan error, 1)
+	go func() {
+		errServe <- srv.ListenAndServe()
+	}()
+
+	// wait for the server to fail or for a signal
+	select {
+	case err = <-errServe:
+		return
+	case <-ctx.Done():
+	}
+	// - a second signal kills the process
+	stop()
+
+	// shut down
+	ctxShutdown, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	err = srv.Shutdown(ctxShutdown)
+	if errors.Is(err, context.DeadlineExceeded) {
+		cancelBase()
+		// - give the cancelled requests a moment to return, so that their queries are aborted before the process exits
+		deadline := time.Now().Add(cancelTimeout)
+		for inFlight.Load() > 0 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	return
+}
@@ -0,0 +1,16 @@
+package internal
+
+import "errors"
+
+// BulkMode is how a bulk save treats the items that fail.
+type BulkMode string
+
+const (
+	// BulkAtomic saves all the items or none of them.
+	BulkAtomic BulkMode = "atomic"
+	// BulkBestEffort saves the items that can be saved and reports the others.
+	BulkBestEffort BulkMode = "best_effort"
+)
+
+// ErrBulkAborted is the error of the items of an atomic bulk save not saved because another item failed.
+var ErrBulkAborted = errors.New("bulk aborted: another item failed")
@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"supermarket/internal"
+	"supermarket/platform/web/request"
+	"supermarket/platform/web/response"
+	"supermarket/platform/web/validate"
+)
+
+const (
+	// bulkMaxItems is the maximum number of items of a bulk create.
+	bulkMaxItems = 10000
+	// bulkMaxBytes is the maximum size of the body of a bulk create.
+	bulkMaxBytes = 8 << 20
+)
+
+// bulkDecoder is the decoder of the bodies of the bulk creates, larger than the others.
+var bulkDecoder = request.NewDecoder(request.Config{MaxBytes: bulkMaxBytes})
+
+// BulkItemJSON is a struct that represents the result of an item of a bulk create in JSON format
+type BulkItemJSON struct {
+	Index  int               `json:"index"`
+	Status int               `json:"status"`
+	Id     int               `json:"id,omitempty"`
+	Error  *response.Problem `json:"error,omitempty"`
+}
+
+// BulkJSON is a struct that represents the result of a bulk create in JSON format
+type BulkJSON struct {
+	Mode    internal.BulkMode `json:"mode"`
+	Created int               `json:"created"`
+	Failed  int               `json:"failed"`
+	Items   []BulkItemJSON    `json:"items"`
+}
+
+// createBulk creates the items of a bulk request, whose body is a json array of request bodies B, saving them as E with save
+// - query param mode is atomic (default), saving all the items or none, or best_effort, saving those that can be saved
+// - every item is validated, in atomic mode an invalid one aborts the others without saving any
+// - responds 201 if every item is created, otherwise 207 Multi-Status, with the status and the id or the error of each item
+// - errors of items other than the sentinel ones of errorMap are logged to lg, once per request
+func createBulk[B, E any](w http.ResponseWriter, r *http.Request, lg *slog.Logger, name string, save func(context.Context, []E, internal.BulkMode) []error, deserialize func(B) E, id func(E) int) {
+	// request
+	// - query param: mode
+	mode := internal.BulkAtomic
+	if v := r.URL.Query().Get("mode"); v != "" {
+		mode = internal.BulkMode(v)
+	}
+	if mode != internal.BulkAtomic && mode != internal.BulkBestEffort {
+		response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParameter, "invalid mode, must be atomic or best_effort")
+		return
+	}
+	// - body
+	var body []B
+	err := bulkDecoder.JSON(r, &body)
+	if err != nil {
+		writeError(w, r.Context(), lg, err, "error parsing request body")
+		return
+	}
+	if len(body) == 0 || len(body) > bulkMaxItems {
+		response.ErrorCode(w, http.StatusUnprocessableEntity, response.CodeValidationFailed, fmt.Sprintf("body must have between 1 and %d items", bulkMaxItems))
+		return
+	}
+
+	// process
+	// - validate each item, keeping the position of the valid ones among the items to save
+	errs := make([]error, len(body))
+	pos := make([]int, len(body))
+	var items []E
+	for ix, v := range body {
+		errs[ix] = validate.Struct(v)
+		if errs[ix] == nil {
+			pos[ix] = len(items)
+			items = append(items, deserialize(v))
+		}
+	}
+	// - save the valid items, unless an invalid one aborts them
+	switch {
+	case len(items) < len(body) && mode == internal.BulkAtomic:
+		for ix := range errs {
+			if errs[ix] == nil {
+				errs[ix] = internal.ErrBulkAborted
+			}
+		}
+	case len(items) > 0:
+		saved := save(r.Context(), items, mode)
+		for ix := range errs {
+			if errs[ix] == nil {
+				errs[ix] = saved[pos[ix]]
+			}
+		}
+	}
+
+	// response
+	// - serialize
+	res := BulkJSON{Mode: mode, Items: make([]BulkItemJSON, len(body))}
+	var unexpected error
+	for ix, err := range errs {
+		if err == nil {
+			res.Created++
+			res.Items[ix] = BulkItemJSON{Index: ix, Status: http.StatusCreated, Id: id(items[pos[ix]])}
+			continue
+		}
+		res.Failed++
+		p, ok := errorMap.Problem(err)
+		if !ok {
+			unexpected = err
+			p = response.NewProblem(http.StatusInternalServerError, "", "error saving "+name)
+		}
+		res.Items[ix] = BulkItemJSON{Index: ix, Status: p.Status, Error: &p}
+	}
+	if unexpected != nil {
+		lg.ErrorContext(r.Context(), "error saving "+name, "error", unexpected, "failed", res.Failed)
+	}
+	code := http.StatusCreated
+	if res.Failed > 0 {
+		code = http.StatusMultiStatus
+	}
+	response.JSON(w, code, map[string]any{
+		"message": fmt.Sprintf("%d of %d %s created", res.Created, len(body), name),
+		"data":    res,
+	})
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"supermarket/platform/health"
+	"supermarket/platform/web/response"
+)
+
+// NewHandlerHealth creates a new handler for the liveness and readiness checks.
+func NewHandlerHealth(ck *health.Checker, lg *slog.Logger) (h *HandlerHealth) {
+	h = &HandlerHealth{
+		ck: ck,
+		lg: lg,
+	}
+	return
+}
+
+// HandlerHealth is a handler for the liveness and readiness checks.
+type HandlerHealth struct {
+	// ck runs the readiness checks of the dependencies.
+	ck *health.Checker
+	// lg is the logger of the failed readiness checks.
+	lg *slog.Logger
+}
+
+// HealthCheckJSON is the result of a readiness check in JSON format.
+type HealthCheckJSON struct {
+	Status     string  `json:"status"`
+	Error      string  `json:"error,omitempty"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+// Live returns ok while the process is up, it does not check any dependency.
+func (h *HandlerHealth) Live() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		response.JSON(w, http.StatusOK, map[string]any{
+			"status": "ok",
+		})
+	}
+}
+
+// Ready checks the dependencies and returns the result of each one, 503 if any is not ready.
+func (h *HandlerHealth) Ready() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// process
+		// - run the checks
+		rp := h.ck.Run(r.Context())
+
+		// response
+		// - serialize the results to JSON
+		checks := make(map[string]HealthCheckJSON, len(rp.Results))
+		for name, v := range rp.Results {
+			status := "ok"
+			if !v.Ok {
+				status = "unavailable"
+				h.lg.ErrorContext(r.Context(), "readiness check failed", "check", name, "error", v.Err)
+			}
+			checks[name] = HealthCheckJSON{
+				Status:     status,
+				Error:      v.Error,
+				DurationMs: float64(v.Duration.Microseconds()) / 1000,
+			}
+		}
+		code, status := http.StatusOK, "ok"
+		if !rp.Ok {
+			code, status = http.StatusServiceUnavailable, "unavailable"
+		}
+		response.JSON(w, code, map[string]any{
+			"status": status,
+			"checks": checks,
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"supermarket/internal"
+	"supermarket/platform/web/response"
+)
+
+const (
+	// defaultListLimit is the page size of a list when the limit is not given.
+	defaultListLimit = 100
+	// maxListLimit is the maximum page size of a list.
+	maxListLimit = 1000
+)
+
+// PageJSON is a struct that represents the pagination metadata of a list in JSON format
+type PageJSON struct {
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// listQuery parses the pagination, sorting and filters of a list request
+// - query param limit is the page size, when streaming it is the total number of items and defaults to all of them
+// - query param cursor is the next_cursor of the previous page
+// - query param sort is the field to sort by, prefixed with "-" for descending order
+// - query param format is the format of the response, see response.NegotiateFormat
+// - any other query param not in reserved is a filter
+func listQuery(r *http.Request, reserved ...string) (q internal.ListQuery, err error) {
+	values := r.URL.Query()
+
+	// limit
+	q.Limit = defaultListLimit
+	if wantsNDJSON(r) {
+		q.Limit = 0
+	}
+	if v := values.Get("limit"); v != "" {
+		q.Limit, err = strconv.Atoi(v)
+		if err != nil || q.Limit < 1 || q.Limit > maxListLimit {
+			err = fmt.Errorf("%w: limit must be between 1 and %d", internal.ErrListQueryInvalid, maxListLimit)
+			return
+		}
+	}
+
+	// cursor and sort
+	q.Cursor = values.Get("cursor")
+	q.Sort = values.Get("sort")
+
+	// filters
+	skip := map[string]bool{"limit": true, "cursor": true, "sort": true, "format": true}
+	for _, v := range reserved {
+		skip[v] = true
+	}
+	for k, v := range values {
+		if skip[k] {
+			continue
+		}
+		if q.Filters == nil {
+			q.Filters = make(map[string]string)
+		}
+		q.Filters[k] = v[0]
+	}
+
+	return
+}
+
+// wantsNDJSON returns true if the client accepts newline delimited json, in which case the whole list is streamed
+// - a format query param overrides it
+func wantsNDJSON(r *http.Request) bool {
+	return r.URL.Query().Get("format") == "" && strings.Contains(r.Header.Get("Accept"), response.ContentTypeNDJSON)
+}
+
+// streamList streams the items of a list as newline delimited json, serialized with serialize, as they are read
+// - once the first line is written the status code can no longer change, so later errors end the stream early
+// - errors other than the sentinel ones of errorMap are logged to lg
+func streamList[T any](w http.ResponseWriter, ctx context.Context, lg *slog.Logger, q internal.ListQuery, stream func(context.Context, internal.ListQuery, func(T) error) error, serialize func(T) any) {
+	nd := response.NewNDJSON(w, http.StatusOK)
+	err := stream(ctx, q, func(v T) error {
+		return nd.Write(serialize(v))
+	})
+	if err != nil {
+		switch {
+		case nd.Started():
+			lg.ErrorContext(ctx, "error streaming list, stream ended early", "error", err)
+		default:
+			writeError(w, ctx, lg, err, "error streaming list")
+		}
+		return
+	}
+	nd.Close()
+}
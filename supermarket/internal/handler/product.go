@@ -1,7 +1,7 @@
 package handler
 
 import (
-	"errors"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"supermarket/internal"
@@ -13,9 +13,10 @@ import (
 )
 
 // NewHandlerProduct creates a new handler for products.
-func NewHandlerProduct(rp internal.RepositoryProduct) (h *HandlerProduct) {
+func NewHandlerProduct(rp internal.RepositoryProduct, lg *slog.Logger) (h *HandlerProduct) {
 	h = &HandlerProduct{
 		rp: rp,
+		lg: lg,
 	}
 	return
 }
@@ -24,6 +25,8 @@ func NewHandlerProduct(rp internal.RepositoryProduct) (h *HandlerProduct) {
 type HandlerProduct struct {
 	// rp is the repository for products.
 	rp internal.RepositoryProduct
+	// lg is the logger of the errors behind 5xx responses.
+	lg *slog.Logger
 }
 
 // ProductJSON is a product in JSON format.
@@ -40,36 +43,44 @@ type ProductJSON struct {
 
 // RequestBodyProductCreate is a request body for creating a product.
 type RequestBodyProductCreate struct {
-	Name        string  `json:"name"`
-	Quantity    int     `json:"quantity"`
-	CodeValue   string  `json:"code_value"`
+	Name        string                 `json:"name" validate:"required,max=50"`
+	Quantity    request.Field[int]     `json:"quantity" validate:"required,min=0"`
+	CodeValue   string                 `json:"code_value" validate:"required,max=50"`
+	IsPublished bool                   `json:"is_published"`
+	Expiration  string                 `json:"expiration" validate:"required,date"`
+	Price       request.Field[float64] `json:"price" validate:"required,min=0,max=999.99"`
+	WarehouseId int                    `json:"id_warehouse" validate:"required,min=1"` // new field
+}
+
+// RequestBodyProductPatch is a request body for updating some fields of a product, applied over its current ones.
+// Products of the JSON store have no warehouse, so unlike on creation it is not required.
+type RequestBodyProductPatch struct {
+	Name        string  `json:"name" validate:"required,max=50"`
+	Quantity    int     `json:"quantity" validate:"min=0"`
+	CodeValue   string  `json:"code_value" validate:"required,max=50"`
 	IsPublished bool    `json:"is_published"`
-	Expiration  string  `json:"expiration"`
-	Price       float64 `json:"price"`
-	WarehouseId int     `json:"id_warehouse"` // new field
+	Expiration  string  `json:"expiration" validate:"required,date"`
+	Price       float64 `json:"price" validate:"min=0,max=999.99"`
+	WarehouseId int     `json:"id_warehouse" validate:"min=0"`
 }
 
 // GetById gets a product by id.
+// It answers If-None-Match and If-Modified-Since with 304 Not Modified when the product did not change.
 func (h *HandlerProduct) GetById() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// request
 		// - path parameter: id
 		id, err := strconv.Atoi(chi.URLParam(r, "id"))
 		if err != nil {
-			response.JSON(w, http.StatusBadRequest, "invalid id")
+			response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParameter, "invalid id")
 			return
 		}
 
 		// process
 		// - find product by id
-		p, err := h.rp.FindById(id)
+		p, err := h.rp.FindById(r.Context(), id)
 		if err != nil {
-			switch {
-			case errors.Is(err, internal.ErrRepositoryProductNotFound):
-				response.JSON(w, http.StatusNotFound, "product not found")
-			default:
-				response.JSON(w, http.StatusInternalServerError, "internal server error")
-			}
+			writeError(w, r.Context(), h.lg, err, "error finding product", "product_id", id)
 			return
 		}
 
@@ -85,24 +96,52 @@ func (h *HandlerProduct) GetById() http.HandlerFunc {
 			Price:       p.Price,
 			WarehouseId: p.WarehouseId,
 		}
-		response.JSON(w, http.StatusOK, map[string]any{
+		response.JSONConditional(w, r, http.StatusOK, map[string]any{
 			"message": "success",
 			"data":    data,
-		})
+		}, p.UpdatedAt)
 	}
 }
 
-// GetAll gets all products.
+// GetAll gets a page of products.
+// With header Accept: application/x-ndjson it streams the whole list instead, one product per line.
+// Query param format (json, csv or xml) or header Accept negotiates the format of a page, csv and xml as downloads.
 func (h *HandlerProduct) GetAll() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// request
-		// ...
+		// - query params: limit, cursor, sort and filters
+		q, err := listQuery(r)
+		if err != nil {
+			response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParameter, err.Error())
+			return
+		}
 
 		// process
-		// - find all products
-		products, err := h.rp.GetAll()
+		// - stream the whole list when newline delimited json is accepted
+		if wantsNDJSON(r) {
+			streamList(w, r.Context(), h.lg, q, h.rp.Stream, func(p internal.Product) any {
+				return ProductJSON{
+					Id:          p.Id,
+					Name:        p.Name,
+					Quantity:    p.Quantity,
+					CodeValue:   p.CodeValue,
+					IsPublished: p.IsPublished,
+					Expiration:  p.Expiration.Format(time.DateOnly),
+					Price:       p.Price,
+					WarehouseId: p.WarehouseId,
+				}
+			})
+			return
+		}
+		// - otherwise find a page of products, in the negotiated format
+		f, err := response.NegotiateFormat(r)
 		if err != nil {
-			response.JSON(w, http.StatusInternalServerError, "internal server error")
+			writeError(w, r.Context(), h.lg, err, "error negotiating format")
+			return
+		}
+		products, pg, err := h.rp.GetAll(r.Context(), q)
+		if err != nil {
+			writeError(w, r.Context(), h.lg, err, "error getting products")
 			return
 		}
 
@@ -121,9 +160,14 @@ func (h *HandlerProduct) GetAll() http.HandlerFunc {
 				WarehouseId: p.WarehouseId,
 			})
 		}
-		response.JSON(w, http.StatusOK, map[string]any{
-			"data":    data,
-			"message": "succesfully retrieved all products",
+		response.Negotiated(w, r, http.StatusOK, f, response.Table{
+			Body: map[string]any{
+				"data":    data,
+				"message": "succesfully retrieved all products",
+				"page":    PageJSON{Limit: q.Limit, NextCursor: pg.NextCursor},
+			},
+			Rows:     data,
+			Filename: "products",
 		})
 	}
 }
@@ -136,18 +180,13 @@ func (h *HandlerProduct) Create() http.HandlerFunc {
 		var body RequestBodyProductCreate
 		err := request.JSON(r, &body)
 		if err != nil {
-			response.JSON(w, http.StatusBadRequest, "invalid body")
-			return
-		}
-		// - check if WarehouseId is provided
-		if body.WarehouseId == 0 {
-			response.JSON(w, http.StatusBadRequest, "WarehouseId is required")
+			writeError(w, r.Context(), h.lg, err, "error parsing request body")
 			return
 		}
-		// - expiration
+		// - expiration, already validated
 		exp, err := time.Parse(time.DateOnly, body.Expiration)
 		if err != nil {
-			response.JSON(w, http.StatusBadRequest, "invalid expiration")
+			response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidBody, "invalid expiration")
 			return
 		}
 
@@ -156,17 +195,17 @@ func (h *HandlerProduct) Create() http.HandlerFunc {
 		p := internal.Product{
 			ProductAttributes: internal.ProductAttributes{
 				Name:        body.Name,
-				Quantity:    body.Quantity,
+				Quantity:    body.Quantity.Value,
 				CodeValue:   body.CodeValue,
 				IsPublished: body.IsPublished,
 				Expiration:  exp,
-				Price:       body.Price,
+				Price:       body.Price.Value,
 				WarehouseId: body.WarehouseId,
 			},
 		}
-		err = h.rp.Save(&p)
+		err = h.rp.Save(r.Context(), &p)
 		if err != nil {
-			response.JSON(w, http.StatusInternalServerError, "internal server error")
+			writeError(w, r.Context(), h.lg, err, "error saving product")
 			return
 		}
 
@@ -189,6 +228,29 @@ func (h *HandlerProduct) Create() http.HandlerFunc {
 	}
 }
 
+// CreateBulk creates the products of a json array, each validated like in Create, see createBulk.
+func (h *HandlerProduct) CreateBulk() http.HandlerFunc {
+	deserialize := func(b RequestBodyProductCreate) internal.Product {
+		// expiration, already validated
+		exp, _ := time.Parse(time.DateOnly, b.Expiration)
+		return internal.Product{
+			ProductAttributes: internal.ProductAttributes{
+				Name:        b.Name,
+				Quantity:    b.Quantity.Value,
+				CodeValue:   b.CodeValue,
+				IsPublished: b.IsPublished,
+				Expiration:  exp,
+				Price:       b.Price.Value,
+				WarehouseId: b.WarehouseId,
+			},
+		}
+	}
+	id := func(p internal.Product) int { return p.Id }
+	return func(w http.ResponseWriter, r *http.Request) {
+		createBulk(w, r, h.lg, "products", h.rp.SaveBulk, deserialize, id)
+	}
+}
+
 // UpdateOrCreate updates or creates a product.
 func (h *HandlerProduct) UpdateOrCreate() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -196,20 +258,20 @@ func (h *HandlerProduct) UpdateOrCreate() http.HandlerFunc {
 		// - path parameter: id
 		id, err := strconv.Atoi(chi.URLParam(r, "id"))
 		if err != nil {
-			response.JSON(w, http.StatusBadRequest, "invalid id")
+			response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParameter, "invalid id")
 			return
 		}
 		// - body
 		var body RequestBodyProductCreate
 		err = request.JSON(r, &body)
 		if err != nil {
-			response.JSON(w, http.StatusBadRequest, "invalid body")
+			writeError(w, r.Context(), h.lg, err, "error parsing request body")
 			return
 		}
-		// - expiration
+		// - expiration, already validated
 		exp, err := time.Parse(time.DateOnly, body.Expiration)
 		if err != nil {
-			response.JSON(w, http.StatusBadRequest, "invalid expiration")
+			response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidBody, "invalid expiration")
 			return
 		}
 
@@ -219,17 +281,17 @@ func (h *HandlerProduct) UpdateOrCreate() http.HandlerFunc {
 			Id: id,
 			ProductAttributes: internal.ProductAttributes{
 				Name:        body.Name,
-				Quantity:    body.Quantity,
+				Quantity:    body.Quantity.Value,
 				CodeValue:   body.CodeValue,
 				IsPublished: body.IsPublished,
 				Expiration:  exp,
-				Price:       body.Price,
+				Price:       body.Price.Value,
 				WarehouseId: body.WarehouseId,
 			},
 		}
-		err = h.rp.UpdateOrSave(&p)
+		err = h.rp.UpdateOrSave(r.Context(), &p)
 		if err != nil {
-			response.JSON(w, http.StatusInternalServerError, "internal server error")
+			writeError(w, r.Context(), h.lg, err, "error updating or saving product", "product_id", id)
 			return
 		}
 
@@ -259,24 +321,19 @@ func (h *HandlerProduct) Update() http.HandlerFunc {
 		// - path parameter: id
 		id, err := strconv.Atoi(chi.URLParam(r, "id"))
 		if err != nil {
-			response.JSON(w, http.StatusBadRequest, "invalid id")
+			response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParameter, "invalid id")
 			return
 		}
 
 		// process
 		// - find product by id
-		p, err := h.rp.FindById(id)
+		p, err := h.rp.FindById(r.Context(), id)
 		if err != nil {
-			switch {
-			case errors.Is(err, internal.ErrRepositoryProductNotFound):
-				response.JSON(w, http.StatusNotFound, "product not found")
-			default:
-				response.JSON(w, http.StatusInternalServerError, "internal server error")
-			}
+			writeError(w, r.Context(), h.lg, err, "error finding product", "product_id", id)
 			return
 		}
 		// - patch product
-		body := RequestBodyProductCreate{
+		body := RequestBodyProductPatch{
 			Name:        p.Name,
 			Quantity:    p.Quantity,
 			CodeValue:   p.CodeValue,
@@ -287,13 +344,13 @@ func (h *HandlerProduct) Update() http.HandlerFunc {
 		}
 		err = request.JSON(r, &body)
 		if err != nil {
-			response.JSON(w, http.StatusBadRequest, "invalid body")
+			writeError(w, r.Context(), h.lg, err, "error parsing request body")
 			return
 		}
-		// - expiration
+		// - expiration, already validated
 		exp, err := time.Parse(time.DateOnly, body.Expiration)
 		if err != nil {
-			response.JSON(w, http.StatusBadRequest, "invalid expiration")
+			response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidBody, "invalid expiration")
 			return
 		}
 		// - update product
@@ -304,9 +361,9 @@ func (h *HandlerProduct) Update() http.HandlerFunc {
 		p.Expiration = exp
 		p.Price = body.Price
 		p.WarehouseId = body.WarehouseId
-		err = h.rp.Update(&p)
+		err = h.rp.Update(r.Context(), &p)
 		if err != nil {
-			response.JSON(w, http.StatusInternalServerError, "internal server error")
+			writeError(w, r.Context(), h.lg, err, "error updating product", "product_id", id)
 			return
 		}
 
@@ -336,20 +393,15 @@ func (h *HandlerProduct) Delete() http.HandlerFunc {
 		// - path parameter: id
 		id, err := strconv.Atoi(chi.URLParam(r, "id"))
 		if err != nil {
-			response.JSON(w, http.StatusBadRequest, "invalid id")
+			response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParameter, "invalid id")
 			return
 		}
 
 		// process
 		// - delete product by id
-		err = h.rp.Delete(id)
+		err = h.rp.Delete(r.Context(), id)
 		if err != nil {
-			switch {
-			case errors.Is(err, internal.ErrRepositoryProductNotFound):
-				response.JSON(w, http.StatusNotFound, "product not found")
-			default:
-				response.JSON(w, http.StatusInternalServerError, "internal server error")
-			}
+			writeError(w, r.Context(), h.lg, err, "error deleting product", "product_id", id)
 			return
 		}
 
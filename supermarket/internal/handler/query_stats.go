@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"supermarket/platform/sqlstats"
+	"supermarket/platform/web/response"
+)
+
+// NewHandlerQueryStats creates a new handler for the query statistics.
+func NewHandlerQueryStats(st *sqlstats.Stats) (h *HandlerQueryStats) {
+	h = &HandlerQueryStats{
+		st: st,
+	}
+	return
+}
+
+// HandlerQueryStats is a handler for the query statistics.
+type HandlerQueryStats struct {
+	// st records the statements run by the repositories.
+	st *sqlstats.Stats
+}
+
+// QueryStatsJSON is the statistics of a statement in JSON format.
+type QueryStatsJSON struct {
+	Query   string  `json:"query"`
+	Calls   int64   `json:"calls"`
+	Errors  int64   `json:"errors"`
+	Rows    int64   `json:"rows"`
+	TotalMs float64 `json:"total_ms"`
+	MeanMs  float64 `json:"mean_ms"`
+	MaxMs   float64 `json:"max_ms"`
+}
+
+// GetTop gets the statements with the most total time since start up or the last reset.
+// Query param limit (default 10) bounds the number of statements.
+func (h *HandlerQueryStats) GetTop() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - query param: limit
+		limit := 10
+		if v := r.URL.Query().Get("limit"); v != "" {
+			var err error
+			limit, err = strconv.Atoi(v)
+			if err != nil || limit < 1 {
+				response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParameter, "invalid limit")
+				return
+			}
+		}
+
+		// process
+		q := h.st.Top(limit)
+
+		// response
+		// - serialize the statistics to JSON
+		data := make([]QueryStatsJSON, len(q))
+		for ix, v := range q {
+			data[ix] = QueryStatsJSON{
+				Query:   v.Query,
+				Calls:   v.Calls,
+				Errors:  v.Errors,
+				Rows:    v.Rows,
+				TotalMs: float64(v.Total.Microseconds()) / 1000,
+				MeanMs:  float64(v.Mean().Microseconds()) / 1000,
+				MaxMs:   float64(v.Max.Microseconds()) / 1000,
+			}
+		}
+		response.JSON(w, http.StatusOK, map[string]any{
+			"data":    data,
+			"message": "top queries found successfully",
+		})
+	}
+}
+
+// Reset forgets the recorded statistics.
+func (h *HandlerQueryStats) Reset() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// process
+		h.st.Reset()
+
+		// response
+		response.JSON(w, http.StatusNoContent, nil)
+	}
+}
@@ -2,11 +2,13 @@ package handler
 
 import (
 	"encoding/json"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"supermarket/internal"
 	"supermarket/platform/web/request"
 	"supermarket/platform/web/response"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -15,12 +17,15 @@ import (
 type WarehouseHandler struct {
 	// rw is the repository warehouse.
 	rw internal.RepositoryWarehouse
+	// lg is the logger of the errors behind 5xx responses.
+	lg *slog.Logger
 }
 
 // NewWarehouseHandler creates a new warehouse handler.
-func NewWarehouseHandler(rw internal.RepositoryWarehouse) (wh *WarehouseHandler) {
+func NewWarehouseHandler(rw internal.RepositoryWarehouse, lg *slog.Logger) (wh *WarehouseHandler) {
 	wh = &WarehouseHandler{
 		rw: rw,
+		lg: lg,
 	}
 	return
 }
@@ -36,10 +41,10 @@ type WarehouseJSON struct {
 
 // RequestBodyWarehouseCreate is a request body for creating a warehouse.
 type RequestBodyWarehouseCreate struct {
-	Name      string `json:"name"`
-	Address   string `json:"address"`
-	Telephone string `json:"telephone"`
-	Capacity  int    `json:"capacity"`
+	Name      string             `json:"name" validate:"required,max=255"`
+	Address   string             `json:"address" validate:"required,max=150"`
+	Telephone string             `json:"telephone" validate:"required,max=150"`
+	Capacity  request.Field[int] `json:"capacity" validate:"required,min=0"`
 }
 
 // ReportProductJSON is a report product in JSON format.
@@ -55,21 +60,15 @@ func (h *WarehouseHandler) GetByID() http.HandlerFunc {
 		// - path parameter: id
 		id, err := strconv.Atoi(chi.URLParam(r, "id"))
 		if err != nil {
-			response.JSON(w, http.StatusBadRequest, "invalid id")
+			response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParameter, "invalid id")
 			return
 		}
 
 		// process
-		warehouse, err := h.rw.FindById(id)
+		warehouse, err := h.rw.FindById(r.Context(), id)
 		if err != nil {
-			switch err {
-			case internal.ErrRepositoryWarehouseNotFound:
-				response.JSON(w, http.StatusNotFound, "warehouse not found")
-				return
-			default:
-				response.JSON(w, http.StatusInternalServerError, "internal server error")
-				return
-			}
+			writeError(w, r.Context(), h.lg, err, "error finding warehouse", "warehouse_id", id)
+			return
 		}
 
 		// response
@@ -82,20 +81,49 @@ func (h *WarehouseHandler) GetByID() http.HandlerFunc {
 			Capacity:  warehouse.Capacity,
 		}
 
-		response.JSON(w, http.StatusOK, map[string]interface{}{
+		response.JSONConditional(w, r, http.StatusOK, map[string]interface{}{
 			"data":    data,
 			"message": "warehouse found successfully",
-		})
+		}, time.Time{})
 	}
 }
 
-// GetAll returns all warehouses.
+// GetAll returns a page of warehouses.
+// With header Accept: application/x-ndjson it streams the whole list instead, one warehouse per line.
+// Query param format (json, csv or xml) or header Accept negotiates the format of a page, csv and xml as downloads.
 func (h *WarehouseHandler) GetAll() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - query params: limit, cursor, sort and filters
+		q, err := listQuery(r)
+		if err != nil {
+			response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParameter, err.Error())
+			return
+		}
+
 		// process
-		warehouses, err := h.rw.GetAll()
+		// - stream the whole list when newline delimited json is accepted
+		if wantsNDJSON(r) {
+			streamList(w, r.Context(), h.lg, q, h.rw.Stream, func(warehouse internal.Warehouse) any {
+				return WarehouseJSON{
+					Id:        warehouse.Id,
+					Name:      warehouse.Name,
+					Address:   warehouse.Address,
+					Telephone: warehouse.Telephone,
+					Capacity:  warehouse.Capacity,
+				}
+			})
+			return
+		}
+		// - otherwise find a page of warehouses, in the negotiated format
+		f, err := response.NegotiateFormat(r)
+		if err != nil {
+			writeError(w, r.Context(), h.lg, err, "error negotiating format")
+			return
+		}
+		warehouses, pg, err := h.rw.GetAll(r.Context(), q)
 		if err != nil {
-			response.JSON(w, http.StatusInternalServerError, "internal server error")
+			writeError(w, r.Context(), h.lg, err, "error getting warehouses")
 			return
 		}
 
@@ -112,9 +140,14 @@ func (h *WarehouseHandler) GetAll() http.HandlerFunc {
 			})
 		}
 
-		response.JSON(w, http.StatusOK, map[string]interface{}{
-			"data":    data,
-			"message": "warehouses found successfully",
+		response.Negotiated(w, r, http.StatusOK, f, response.Table{
+			Body: map[string]interface{}{
+				"data":    data,
+				"message": "warehouses found successfully",
+				"page":    PageJSON{Limit: q.Limit, NextCursor: pg.NextCursor},
+			},
+			Rows:     data,
+			Filename: "warehouses",
 		})
 	}
 }
@@ -127,7 +160,7 @@ func (h *WarehouseHandler) Create() http.HandlerFunc {
 		var body RequestBodyWarehouseCreate
 		err := request.JSON(r, &body)
 		if err != nil {
-			response.JSON(w, http.StatusBadRequest, "invalid body")
+			writeError(w, r.Context(), h.lg, err, "error parsing request body")
 			return
 		}
 
@@ -136,11 +169,11 @@ func (h *WarehouseHandler) Create() http.HandlerFunc {
 			Name:      body.Name,
 			Address:   body.Address,
 			Telephone: body.Telephone,
-			Capacity:  body.Capacity,
+			Capacity:  body.Capacity.Value,
 		}
-		err = h.rw.Save(&warehouse)
+		err = h.rw.Save(r.Context(), &warehouse)
 		if err != nil {
-			response.JSON(w, http.StatusInternalServerError, "internal server error")
+			writeError(w, r.Context(), h.lg, err, "error saving warehouse")
 			return
 		}
 
@@ -162,6 +195,7 @@ func (h *WarehouseHandler) Create() http.HandlerFunc {
 }
 
 // GerProductReports returns a reports of products by warehouse.
+// Query param format (json, csv or xml) or header Accept negotiates the format, csv and xml as downloads.
 func (h *WarehouseHandler) GetProductReports() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// request
@@ -173,15 +207,21 @@ func (h *WarehouseHandler) GetProductReports() http.HandlerFunc {
 			// Parse the first query parameter as a JSON array
 			err := json.Unmarshal([]byte(queryIds[0]), &intIds)
 			if err != nil {
-				response.JSON(w, http.StatusBadRequest, "invalid id")
+				response.ErrorCode(w, http.StatusBadRequest, response.CodeInvalidParameter, "invalid id")
 				return
 			}
 		}
+		// - format
+		f, err := response.NegotiateFormat(r)
+		if err != nil {
+			writeError(w, r.Context(), h.lg, err, "error negotiating format")
+			return
+		}
 
 		// process
-		reports, err := h.rw.ReportProducts(intIds)
+		reports, err := h.rw.ReportProducts(r.Context(), intIds)
 		if err != nil {
-			response.JSON(w, http.StatusInternalServerError, "internal server error")
+			writeError(w, r.Context(), h.lg, err, "error getting product reports", "warehouse_ids", intIds)
 			return
 		}
 
@@ -195,9 +235,13 @@ func (h *WarehouseHandler) GetProductReports() http.HandlerFunc {
 			})
 		}
 
-		response.JSON(w, http.StatusOK, map[string]interface{}{
-			"data":    data,
-			"message": "reports found successfully",
+		response.Negotiated(w, r, http.StatusOK, f, response.Table{
+			Body: map[string]interface{}{
+				"data":    data,
+				"message": "reports found successfully",
+			},
+			Rows:     data,
+			Filename: "warehouses-product-report",
 		})
 	}
 }
@@ -1,6 +1,7 @@
 package internal
 
 import (
+	"context"
 	"errors"
 	"time"
 )
@@ -29,6 +30,8 @@ type Product struct {
 	Id int
 	// ProductAttributes is the attributes of the product
 	ProductAttributes
+	// UpdatedAt is the last time the product was written, zero if unknown
+	UpdatedAt time.Time
 }
 
 var (
@@ -41,17 +44,21 @@ var (
 // RepositoryProduct is an interface that contains the methods for a product repository
 type RepositoryProduct interface {
 	// FindById returns a product by its id
-	FindById(id int) (p Product, err error)
-	// GetAll returns all products
-	GetAll() (p []Product, err error)
+	FindById(ctx context.Context, id int) (p Product, err error)
+	// GetAll returns a page of products
+	GetAll(ctx context.Context, q ListQuery) (p []Product, pg Page, err error)
+	// Stream calls fn with each product of the list, stopping at the first error
+	Stream(ctx context.Context, q ListQuery, fn func(p Product) error) (err error)
 	// Save saves a product
-	Save(p *Product) (err error)
+	Save(ctx context.Context, p *Product) (err error)
+	// SaveBulk saves products in mode, setting their ids, and returns the error of each one, nil if it was saved
+	SaveBulk(ctx context.Context, p []Product, mode BulkMode) (errs []error)
 	// UpdateOrSave updates or saves a product
-	UpdateOrSave(p *Product) (err error)
+	UpdateOrSave(ctx context.Context, p *Product) (err error)
 	// Update updates a product
-	Update(p *Product) (err error)
+	Update(ctx context.Context, p *Product) (err error)
 	// Delete deletes a product
-	Delete(id int) (err error)
+	Delete(ctx context.Context, id int) (err error)
 }
 
 // StoreProduct is an interface for a product store.
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"supermarket/internal"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// bulkBatchSize is the number of items a best-effort bulk save inserts per transaction.
+const bulkBatchSize = 500
+
+// bulkSpec describes how a bulk save inserts the items of an entity.
+type bulkSpec[T any] struct {
+	// repository is the name of the repository, the label of the latency of the inserts.
+	repository string
+	// query is the INSERT statement of an item.
+	query string
+	// args returns the arguments of the statement for an item.
+	args func(T) []any
+	// setId sets the id of an item, zero once its insert is rolled back.
+	setId func(*T, int)
+}
+
+// save inserts items into db, setting their ids, and returns the error of each item, nil if it was saved.
+// - internal.BulkAtomic inserts them in a single transaction, rolled back at the first item that fails,
+// the other items failing with internal.ErrBulkAborted
+// - internal.BulkBestEffort inserts them in transactions of bulkBatchSize items, committed with the items that did not fail,
+// as MySQL only rolls back the statement that fails. If the transaction itself fails every item of its batch fails.
+// Each insert is bounded by timeout and observed as the SaveBulk method of the repository.
+func (s bulkSpec[T]) save(ctx context.Context, db *sql.DB, timeout time.Duration, lg *slog.Logger, items []T, mode internal.BulkMode) (errs []error) {
+	errs = make([]error, len(items))
+	size := bulkBatchSize
+	if mode == internal.BulkAtomic {
+		size = len(items)
+	}
+	for start := 0; start < len(items); start += size {
+		end := min(start+size, len(items))
+		s.saveBatch(ctx, db, timeout, lg, items[start:end], mode, errs[start:end])
+	}
+	return
+}
+
+// saveBatch inserts items in a transaction, setting the error of each item in errs.
+func (s bulkSpec[T]) saveBatch(ctx context.Context, db *sql.DB, timeout time.Duration, lg *slog.Logger, items []T, mode internal.BulkMode, errs []error) {
+	// fail fails the items not failed yet with err, once the transaction is rolled back
+	fail := func(err error) {
+		for ix := range items {
+			if errs[ix] == nil {
+				errs[ix] = err
+				s.setId(&items[ix], 0)
+			}
+		}
+	}
+
+	// begin the transaction
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		fail(err)
+		return
+	}
+	stmt, err := tx.PrepareContext(ctx, s.query)
+	if err != nil {
+		tx.Rollback()
+		fail(err)
+		return
+	}
+	defer stmt.Close()
+
+	// insert the items
+	for ix := range items {
+		errs[ix] = s.insert(ctx, stmt, timeout, lg, &items[ix])
+		if errs[ix] == nil {
+			continue
+		}
+		if mode == internal.BulkAtomic {
+			tx.Rollback()
+			fail(internal.ErrBulkAborted)
+			return
+		}
+		// a deadlock rolls back the whole transaction, not only the statement
+		var mysqlErr *mysql.MySQLError
+		if errors.As(errs[ix], &mysqlErr) && mysqlErr.Number == 1213 {
+			tx.Rollback()
+			fail(errs[ix])
+			return
+		}
+	}
+
+	// commit the transaction
+	if err = tx.Commit(); err != nil {
+		fail(err)
+	}
+}
+
+// insert inserts an item with stmt and sets its id.
+func (s bulkSpec[T]) insert(ctx context.Context, stmt *sql.Stmt, timeout time.Duration, lg *slog.Logger, item *T) (err error) {
+	// bound the query
+	ctx, cancel := queryContext(ctx, timeout, lg, s.repository, "SaveBulk")
+	defer cancel()
+
+	// execute the query
+	res, err := stmt.ExecContext(ctx, s.args(*item)...)
+	if err != nil {
+		return insertError(err)
+	}
+
+	// get the last inserted id
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	// set the id
+	s.setId(item, int(id))
+	return
+}
+
+// insertError translates the error of an INSERT: a duplicated key is internal.ErrBuyerRepositoryDuplicated,
+// any other error is returned as is.
+func insertError(err error) error {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+		return internal.ErrBuyerRepositoryDuplicated
+	}
+	return err
+}
@@ -0,0 +1,360 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"supermarket/internal"
+	"time"
+)
+
+// listSort is a field a list can be sorted by.
+type listSort[T any] struct {
+	// column is the column of the field.
+	column string
+	// value returns the value of the field of an item, used to build the cursor.
+	value func(T) any
+}
+
+// listSpec describes how the list of an entity is paginated, filtered and sorted.
+// Pagination is keyset based: the cursor holds the sort value and id of the last item of the page,
+// so the next page starts right after it regardless of the rows inserted or deleted meanwhile.
+type listSpec[T any] struct {
+	// id is the column of the unique id, used to sort by default and to break ties.
+	id string
+	// idValue returns the id of an item.
+	idValue func(T) int
+	// sorts are the fields the list can be sorted by, by name.
+	sorts map[string]listSort[T]
+	// filters are the conditions the list can be filtered by, by name, with a single placeholder.
+	filters map[string]string
+	// values parse the value of a filter to the type of its column before it is bound, by name.
+	// The values of the filters without one are bound as they are.
+	values map[string]func(value string) (v any, err error)
+	// match are the in-memory equivalent of filters, by name, used by the stores that can not query.
+	match map[string]func(item T, value string) (ok bool, err error)
+}
+
+// listCursor is the decoded cursor of a list.
+type listCursor struct {
+	// Sort is the sort the cursor was built with.
+	Sort string `json:"s"`
+	// Value is the sort value of the last item.
+	Value any `json:"v"`
+	// Id is the id of the last item.
+	Id int `json:"id"`
+}
+
+// listClause is the SQL built for a list query.
+type listClause struct {
+	// where is the WHERE clause, empty if there are no conditions.
+	where string
+	// args are the arguments of the WHERE clause placeholders.
+	args []any
+	// order are the ORDER BY terms, without the ORDER BY keyword.
+	order []string
+	// limit is the number of rows to fetch, one more than the page size to know if there is a next page. Zero fetches all.
+	limit int
+}
+
+// sql returns the WHERE, ORDER BY and LIMIT clauses, prefixing the ORDER BY columns with table if not empty.
+func (c listClause) sql(table string) (s string) {
+	s = c.where
+	order := make([]string, len(c.order))
+	for ix, v := range c.order {
+		order[ix] = v
+		if table != "" {
+			order[ix] = table + "." + v
+		}
+	}
+	s += " ORDER BY " + strings.Join(order, ", ")
+	if c.limit > 0 {
+		s += fmt.Sprintf(" LIMIT %d", c.limit)
+	}
+	return
+}
+
+// build validates the query against the spec and builds its SQL clauses.
+func (s listSpec[T]) build(q internal.ListQuery) (c listClause, err error) {
+	// sort
+	column, desc, err := s.sort(q.Sort)
+	if err != nil {
+		return
+	}
+	dir, cmp := "ASC", ">"
+	if desc {
+		dir, cmp = "DESC", "<"
+	}
+
+	// filters
+	var conditions []string
+	for name, value := range q.Filters {
+		condition, ok := s.filters[name]
+		if !ok {
+			err = fmt.Errorf("%w: unknown filter %s", internal.ErrListQueryInvalid, name)
+			return
+		}
+		var v any = value
+		if parse, ok := s.values[name]; ok {
+			v, err = parse(value)
+			if err != nil {
+				err = fmt.Errorf("%w: invalid filter %s", internal.ErrListQueryInvalid, name)
+				return
+			}
+		}
+		conditions = append(conditions, condition)
+		c.args = append(c.args, v)
+	}
+
+	// cursor
+	if q.Cursor != "" {
+		var cr listCursor
+		cr, err = decodeListCursor(q.Cursor)
+		if err != nil {
+			return
+		}
+		if cr.Sort != q.Sort {
+			err = fmt.Errorf("%w: cursor does not match sort", internal.ErrListQueryInvalid)
+			return
+		}
+		if column == s.id {
+			conditions = append(conditions, fmt.Sprintf("%s %s ?", s.id, cmp))
+			c.args = append(c.args, cr.Id)
+		} else {
+			conditions = append(conditions, fmt.Sprintf("(%s %s ? OR (%s = ? AND %s %s ?))", column, cmp, column, s.id, cmp))
+			c.args = append(c.args, cr.Value, cr.Value, cr.Id)
+		}
+	}
+	if len(conditions) > 0 {
+		c.where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	// order
+	if column != s.id {
+		c.order = append(c.order, column+" "+dir)
+	}
+	c.order = append(c.order, s.id+" "+dir)
+
+	// limit
+	if q.Limit < 0 {
+		err = fmt.Errorf("%w: negative limit", internal.ErrListQueryInvalid)
+		return
+	}
+	if q.Limit > 0 {
+		c.limit = q.Limit + 1
+	}
+
+	return
+}
+
+// page trims the items fetched with the clause built by build to the page size and returns the page metadata.
+func (s listSpec[T]) page(q internal.ListQuery, items []T) (p []T, pg internal.Page, err error) {
+	p = items
+	if q.Limit == 0 || len(items) <= q.Limit {
+		return
+	}
+
+	// there is a next page, starting after the last item of this one
+	p = items[:q.Limit]
+	last := p[len(p)-1]
+	cr := listCursor{Sort: q.Sort, Id: s.idValue(last)}
+	if sr, ok := s.sorts[strings.TrimPrefix(q.Sort, "-")]; ok && q.Sort != "" {
+		cr.Value = sr.value(last)
+	}
+	pg.NextCursor, err = encodeListCursor(cr)
+	return
+}
+
+// slice applies the query to the items in memory, with the same semantics as build and page.
+func (s listSpec[T]) slice(q internal.ListQuery, items []T) (p []T, pg internal.Page, err error) {
+	// sort
+	_, desc, err := s.sort(q.Sort)
+	if err != nil {
+		return
+	}
+	value := func(item T) any { return s.idValue(item) }
+	if q.Sort != "" {
+		value = s.sorts[strings.TrimPrefix(q.Sort, "-")].value
+	}
+	// - compare returns the order of a (value, id) pair against another, considering the direction
+	compare := func(av any, aid int, bv any, bid int) int {
+		c := compareListValues(av, bv)
+		if c == 0 {
+			c = compareListValues(aid, bid)
+		}
+		if desc {
+			c = -c
+		}
+		return c
+	}
+
+	// filters
+	for name := range q.Filters {
+		if _, ok := s.match[name]; !ok {
+			err = fmt.Errorf("%w: unknown filter %s", internal.ErrListQueryInvalid, name)
+			return
+		}
+	}
+	var filtered []T
+	for _, item := range items {
+		ok := true
+		for name, v := range q.Filters {
+			ok, err = s.match[name](item, v)
+			if err != nil {
+				err = fmt.Errorf("%w: invalid filter %s", internal.ErrListQueryInvalid, name)
+				return
+			}
+			if !ok {
+				break
+			}
+		}
+		if ok {
+			filtered = append(filtered, item)
+		}
+	}
+	sort.Slice(filtered, func(i, j int) bool {
+		return compare(value(filtered[i]), s.idValue(filtered[i]), value(filtered[j]), s.idValue(filtered[j])) < 0
+	})
+
+	// cursor
+	if q.Cursor != "" {
+		var cr listCursor
+		cr, err = decodeListCursor(q.Cursor)
+		if err != nil {
+			return
+		}
+		if cr.Sort != q.Sort {
+			err = fmt.Errorf("%w: cursor does not match sort", internal.ErrListQueryInvalid)
+			return
+		}
+		if q.Sort == "" {
+			cr.Value = cr.Id
+		}
+		start := sort.Search(len(filtered), func(i int) bool {
+			return compare(value(filtered[i]), s.idValue(filtered[i]), cr.Value, cr.Id) > 0
+		})
+		filtered = filtered[start:]
+	}
+
+	// limit
+	if q.Limit < 0 {
+		err = fmt.Errorf("%w: negative limit", internal.ErrListQueryInvalid)
+		return
+	}
+	if q.Limit > 0 && len(filtered) > q.Limit+1 {
+		filtered = filtered[:q.Limit+1]
+	}
+
+	p, pg, err = s.page(q, filtered)
+	return
+}
+
+// compareListValues compares two sort values, numbers are compared as float64 since cursors are decoded from JSON.
+func compareListValues(a, b any) int {
+	switch av := a.(type) {
+	case string:
+		bv, _ := b.(string)
+		return strings.Compare(av, bv)
+	case bool:
+		bv, _ := b.(bool)
+		switch {
+		case av == bv:
+			return 0
+		case !av:
+			return -1
+		default:
+			return 1
+		}
+	default:
+		af, bf := listFloat(a), listFloat(b)
+		switch {
+		case af < bf:
+			return -1
+		case af > bf:
+			return 1
+		default:
+			return 0
+		}
+	}
+}
+
+// listFloat converts a numeric sort value to float64.
+func listFloat(v any) float64 {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case float64:
+		return n
+	default:
+		return 0
+	}
+}
+
+// parseListBool parses the value of a boolean filter.
+func parseListBool(value string) (v any, err error) {
+	return strconv.ParseBool(value)
+}
+
+// parseListInt parses the value of an integer filter.
+func parseListInt(value string) (v any, err error) {
+	return strconv.Atoi(value)
+}
+
+// parseListFloat parses the value of a decimal filter.
+func parseListFloat(value string) (v any, err error) {
+	return strconv.ParseFloat(value, 64)
+}
+
+// parseListDate parses the value of a date filter, as YYYY-MM-DD.
+func parseListDate(value string) (v any, err error) {
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return
+	}
+	v = t.Format(time.DateOnly)
+	return
+}
+
+// sort returns the column and direction of the sort.
+func (s listSpec[T]) sort(sort string) (column string, desc bool, err error) {
+	if sort == "" {
+		column = s.id
+		return
+	}
+	name, desc := strings.CutPrefix(sort, "-")
+	sr, ok := s.sorts[name]
+	if !ok {
+		err = fmt.Errorf("%w: unknown sort %s", internal.ErrListQueryInvalid, name)
+		return
+	}
+	column = sr.column
+	return
+}
+
+// encodeListCursor encodes a cursor as url-safe base64 json.
+func encodeListCursor(cr listCursor) (s string, err error) {
+	b, err := json.Marshal(cr)
+	if err != nil {
+		return
+	}
+	s = base64.RawURLEncoding.EncodeToString(b)
+	return
+}
+
+// decodeListCursor decodes a cursor encoded by encodeListCursor.
+func decodeListCursor(s string) (cr listCursor, err error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		err = fmt.Errorf("%w: malformed cursor", internal.ErrListQueryInvalid)
+		return
+	}
+	err = json.Unmarshal(b, &cr)
+	if err != nil {
+		err = fmt.Errorf("%w: malformed cursor", internal.ErrListQueryInvalid)
+		return
+	}
+	return
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"supermarket/internal"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for listSpec.slice
+func TestListSpecSlice(t *testing.T) {
+	products := []internal.Product{
+		{Id: 3, ProductAttributes: internal.ProductAttributes{Name: "Apple", Price: 2}},
+		{Id: 1, ProductAttributes: internal.ProductAttributes{Name: "Banana", Price: 1}},
+		{Id: 2, ProductAttributes: internal.ProductAttributes{Name: "Cherry", Price: 2}},
+		{Id: 4, ProductAttributes: internal.ProductAttributes{Name: "Pineapple", Price: 3}},
+	}
+
+	t.Run("pages follow each other with ties broken by id", func(t *testing.T) {
+		// arrange
+		q := internal.ListQuery{Limit: 2, Sort: "-price"}
+
+		// act
+		p1, pg1, err1 := listSpecProducts.slice(q, products)
+		q.Cursor = pg1.NextCursor
+		p2, pg2, err2 := listSpecProducts.slice(q, products)
+
+		// assert
+		require.NoError(t, err1)
+		require.NoError(t, err2)
+		require.Equal(t, []internal.Product{products[3], products[0]}, p1)
+		require.Equal(t, []internal.Product{products[2], products[1]}, p2)
+		require.Empty(t, pg2.NextCursor)
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		// arrange
+		q := internal.ListQuery{Filters: map[string]string{"name": "apple", "price_min": "2.5"}}
+
+		// act
+		p, pg, err := listSpecProducts.slice(q, products)
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, []internal.Product{products[3]}, p)
+		require.Empty(t, pg.NextCursor)
+	})
+
+	t.Run("error - invalid filter value", func(t *testing.T) {
+		// arrange
+		q := internal.ListQuery{Filters: map[string]string{"price_min": "cheap"}}
+
+		// act
+		_, _, err := listSpecProducts.slice(q, products)
+
+		// assert
+		require.ErrorIs(t, err, internal.ErrListQueryInvalid)
+	})
+}
+
+// Tests for listSpec.build
+func TestListSpecBuild(t *testing.T) {
+	t.Run("filter values are bound with the type of their column", func(t *testing.T) {
+		// arrange
+		q := internal.ListQuery{Filters: map[string]string{"is_published": "true"}}
+
+		// act
+		c, err := listSpecProducts.build(q)
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, " WHERE is_published = ?", c.where)
+		require.Equal(t, []any{true}, c.args)
+	})
+
+	t.Run("error - invalid filter value", func(t *testing.T) {
+		// arrange
+		q := internal.ListQuery{Filters: map[string]string{"expiration_from": "tomorrow"}}
+
+		// act
+		_, err := listSpecProducts.build(q)
+
+		// assert
+		require.ErrorIs(t, err, internal.ErrListQueryInvalid)
+	})
+}
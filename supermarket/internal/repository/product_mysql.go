@@ -1,17 +1,24 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
 	"supermarket/internal"
+	"time"
 
 	"github.com/go-sql-driver/mysql"
 )
 
 // NewRepositoryProductMySQL creates a new repository product MySQL.
-func NewRepositoryProductMySQL(db *sql.DB) (rp *RepositoryProductMySQL) {
+func NewRepositoryProductMySQL(db *sql.DB, timeout time.Duration, lg *slog.Logger) (rp *RepositoryProductMySQL) {
 	rp = &RepositoryProductMySQL{
-		db: db,
+		db:      db,
+		timeout: timeout,
+		lg:      lg,
 	}
 	return
 }
@@ -20,18 +27,26 @@ func NewRepositoryProductMySQL(db *sql.DB) (rp *RepositoryProductMySQL) {
 type RepositoryProductMySQL struct {
 	// db is the connection to the database.
 	db *sql.DB
+	// timeout bounds each query, zero means no timeout.
+	timeout time.Duration
+	// lg is the logger of the queries that time out.
+	lg *slog.Logger
 }
 
 // FindById returns a product by its id.
-func (rp *RepositoryProductMySQL) FindById(id int) (p internal.Product, err error) {
+func (rp *RepositoryProductMySQL) FindById(ctx context.Context, id int) (p internal.Product, err error) {
+	// Bound the query.
+	ctx, cancel := queryContext(ctx, rp.timeout, rp.lg, "products", "FindById")
+	defer cancel()
+
 	// Query the database for the product.
-	row := rp.db.QueryRow("SELECT id, name, price, quantity, code_value, is_published, expiration, price, id_warehouse FROM products WHERE id = ?", id)
+	row := rp.db.QueryRowContext(ctx, "SELECT id, name, price, quantity, code_value, is_published, expiration, price, id_warehouse, updated_at FROM products WHERE id = ?", id)
 	if err := row.Err(); err != nil {
 		return p, err
 	}
 
 	// Scan the row into the product.
-	err = row.Scan(&p.Id, &p.Name, &p.Price, &p.Quantity, &p.CodeValue, &p.IsPublished, &p.Expiration, &p.Price, &p.WarehouseId)
+	err = row.Scan(&p.Id, &p.Name, &p.Price, &p.Quantity, &p.CodeValue, &p.IsPublished, &p.Expiration, &p.Price, &p.WarehouseId, &p.UpdatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			err = internal.ErrRepositoryProductNotFound
@@ -41,10 +56,115 @@ func (rp *RepositoryProductMySQL) FindById(id int) (p internal.Product, err erro
 	return
 }
 
-// GetAll returns all products.
-func (rp *RepositoryProductMySQL) GetAll() (p []internal.Product, err error) {
+// listSpecProducts is the list spec of the products, shared by the MySQL and store repositories.
+var listSpecProducts = listSpec[internal.Product]{
+	id:      "id",
+	idValue: func(p internal.Product) int { return p.Id },
+	sorts: map[string]listSort[internal.Product]{
+		"id":         {"id", func(p internal.Product) any { return p.Id }},
+		"name":       {"name", func(p internal.Product) any { return p.Name }},
+		"quantity":   {"quantity", func(p internal.Product) any { return p.Quantity }},
+		"price":      {"price", func(p internal.Product) any { return p.Price }},
+		"expiration": {"expiration", func(p internal.Product) any { return p.Expiration.Format(time.DateOnly) }},
+	},
+	filters: map[string]string{
+		"name":            "name LIKE CONCAT('%', ?, '%')",
+		"code_value":      "code_value = ?",
+		"is_published":    "is_published = ?",
+		"id_warehouse":    "id_warehouse = ?",
+		"price_min":       "price >= ?",
+		"price_max":       "price <= ?",
+		"expiration_from": "expiration >= ?",
+		"expiration_to":   "expiration <= ?",
+	},
+	values: map[string]func(v string) (any, error){
+		"is_published":    parseListBool,
+		"id_warehouse":    parseListInt,
+		"price_min":       parseListFloat,
+		"price_max":       parseListFloat,
+		"expiration_from": parseListDate,
+		"expiration_to":   parseListDate,
+	},
+	match: map[string]func(p internal.Product, v string) (bool, error){
+		"name": func(p internal.Product, v string) (bool, error) {
+			return strings.Contains(strings.ToLower(p.Name), strings.ToLower(v)), nil
+		},
+		"code_value": func(p internal.Product, v string) (bool, error) {
+			return p.CodeValue == v, nil
+		},
+		"is_published": func(p internal.Product, v string) (bool, error) {
+			b, err := strconv.ParseBool(v)
+			return p.IsPublished == b, err
+		},
+		"id_warehouse": func(p internal.Product, v string) (bool, error) {
+			id, err := strconv.Atoi(v)
+			return p.WarehouseId == id, err
+		},
+		"price_min": func(p internal.Product, v string) (bool, error) {
+			f, err := strconv.ParseFloat(v, 64)
+			return p.Price >= f, err
+		},
+		"price_max": func(p internal.Product, v string) (bool, error) {
+			f, err := strconv.ParseFloat(v, 64)
+			return p.Price <= f, err
+		},
+		"expiration_from": func(p internal.Product, v string) (bool, error) {
+			t, err := time.Parse(time.DateOnly, v)
+			return !p.Expiration.Before(t), err
+		},
+		"expiration_to": func(p internal.Product, v string) (bool, error) {
+			t, err := time.Parse(time.DateOnly, v)
+			return !p.Expiration.After(t), err
+		},
+	},
+}
+
+// GetAll returns a page of products.
+func (rp *RepositoryProductMySQL) GetAll(ctx context.Context, q internal.ListQuery) (p []internal.Product, pg internal.Page, err error) {
+	// Bound the query.
+	ctx, cancel := queryContext(ctx, rp.timeout, rp.lg, "products", "GetAll")
+	defer cancel()
+
+	// Build the clauses of the query.
+	cl, err := listSpecProducts.build(q)
+	if err != nil {
+		return
+	}
+
 	// Query the database for the products.
-	rows, err := rp.db.Query("SELECT id, name, price, quantity, code_value, is_published, expiration, price, id_warehouse FROM products")
+	err = rp.each(ctx, cl, func(product internal.Product) error {
+		p = append(p, product)
+		return nil
+	})
+	if err != nil {
+		return
+	}
+
+	// Trim the page.
+	p, pg, err = listSpecProducts.page(q, p)
+	return
+}
+
+// Stream calls fn with each product of the list as it is read from the database, without holding the list in memory.
+// The limit is the total number of products rather than the page size, zero streams all of them.
+// It is not bounded by the query timeout, only by ctx, since streaming a whole table may take longer.
+func (rp *RepositoryProductMySQL) Stream(ctx context.Context, q internal.ListQuery, fn func(product internal.Product) error) (err error) {
+	// Build the clauses of the query.
+	cl, err := listSpecProducts.build(q)
+	if err != nil {
+		return
+	}
+	cl.limit = q.Limit
+
+	// Query the database for the products.
+	err = rp.each(ctx, cl, fn)
+	return
+}
+
+// each queries the database with the list clauses and calls fn with each product.
+func (rp *RepositoryProductMySQL) each(ctx context.Context, cl listClause, fn func(product internal.Product) error) (err error) {
+	// Query the database for the products.
+	rows, err := rp.db.QueryContext(ctx, "SELECT id, name, price, quantity, code_value, is_published, expiration, price, id_warehouse, updated_at FROM products"+cl.sql(""), cl.args...)
 	if err != nil {
 		return
 	}
@@ -53,17 +173,25 @@ func (rp *RepositoryProductMySQL) GetAll() (p []internal.Product, err error) {
 	// Scan the rows into the products.
 	for rows.Next() {
 		var product internal.Product
-		err = rows.Scan(&product.Id, &product.Name, &product.Price, &product.Quantity, &product.CodeValue, &product.IsPublished, &product.Expiration, &product.Price, &product.WarehouseId)
+		err = rows.Scan(&product.Id, &product.Name, &product.Price, &product.Quantity, &product.CodeValue, &product.IsPublished, &product.Expiration, &product.Price, &product.WarehouseId, &product.UpdatedAt)
+		if err != nil {
+			return
+		}
+		err = fn(product)
 		if err != nil {
 			return
 		}
-		p = append(p, product)
 	}
+	err = rows.Err()
 	return
 }
 
-func (rp *RepositoryProductMySQL) Save(p *internal.Product) (err error) {
-	result, err := rp.db.Exec(
+func (rp *RepositoryProductMySQL) Save(ctx context.Context, p *internal.Product) (err error) {
+	// Bound the query.
+	ctx, cancel := queryContext(ctx, rp.timeout, rp.lg, "products", "Save")
+	defer cancel()
+
+	result, err := rp.db.ExecContext(ctx,
 		"INSERT INTO products (name, quantity, code_value, is_published, expiration, price, id_warehouse) VALUES (?, ?, ?, ?, ?, ?, ?)",
 		p.Name, p.Quantity, p.CodeValue, p.IsPublished, p.Expiration, p.Price, p.WarehouseId,
 	)
@@ -93,16 +221,36 @@ func (rp *RepositoryProductMySQL) Save(p *internal.Product) (err error) {
 	return
 }
 
-func (rp *RepositoryProductMySQL) UpdateOrSave(p *internal.Product) (err error) {
-	err = rp.Update(p)
+// bulkSpecProducts is the bulk spec of the products.
+var bulkSpecProducts = bulkSpec[internal.Product]{
+	repository: "products",
+	query:      "INSERT INTO products (name, quantity, code_value, is_published, expiration, price, id_warehouse) VALUES (?, ?, ?, ?, ?, ?, ?)",
+	args: func(p internal.Product) []any {
+		return []any{p.Name, p.Quantity, p.CodeValue, p.IsPublished, p.Expiration, p.Price, p.WarehouseId}
+	},
+	setId: func(p *internal.Product, id int) { p.Id = id },
+}
+
+// SaveBulk saves products in mode, setting their ids, and returns the error of each one, nil if it was saved.
+func (rp *RepositoryProductMySQL) SaveBulk(ctx context.Context, p []internal.Product, mode internal.BulkMode) (errs []error) {
+	errs = bulkSpecProducts.save(ctx, rp.db, rp.timeout, rp.lg, p, mode)
+	return
+}
+
+func (rp *RepositoryProductMySQL) UpdateOrSave(ctx context.Context, p *internal.Product) (err error) {
+	err = rp.Update(ctx, p)
 	if err == internal.ErrRepositoryProductNotFound {
-		err = rp.Save(p)
+		err = rp.Save(ctx, p)
 	}
 	return
 }
 
-func (rp *RepositoryProductMySQL) Update(p *internal.Product) (err error) {
-	_, err = rp.db.Exec(
+func (rp *RepositoryProductMySQL) Update(ctx context.Context, p *internal.Product) (err error) {
+	// Bound the query.
+	ctx, cancel := queryContext(ctx, rp.timeout, rp.lg, "products", "Update")
+	defer cancel()
+
+	_, err = rp.db.ExecContext(ctx,
 		"UPDATE products SET name = ?, quantity = ?, code_value = ?, is_published = ?, expiration = ?, price = ?, id_warehouse = ? WHERE id = ?",
 		p.Name, p.Quantity, p.CodeValue, p.IsPublished, p.Expiration, p.Price, p.WarehouseId, p.Id,
 	)
@@ -122,8 +270,12 @@ func (rp *RepositoryProductMySQL) Update(p *internal.Product) (err error) {
 	return
 }
 
-func (rp *RepositoryProductMySQL) Delete(id int) (err error) {
-	result, err := rp.db.Exec("DELETE FROM products WHERE id = ?", id)
+func (rp *RepositoryProductMySQL) Delete(ctx context.Context, id int) (err error) {
+	// Bound the query.
+	ctx, cancel := queryContext(ctx, rp.timeout, rp.lg, "products", "Delete")
+	defer cancel()
+
+	result, err := rp.db.ExecContext(ctx, "DELETE FROM products WHERE id = ?", id)
 	if err != nil {
 		return
 	}
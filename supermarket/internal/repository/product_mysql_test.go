@@ -0,0 +1,100 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"supermarket/internal"
+	"supermarket/internal/repository"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// storeProducts is a store of the products in p.
+type storeProducts struct {
+	p map[int]internal.Product
+}
+
+func (s *storeProducts) ReadAll() (p map[int]internal.Product, err error) {
+	p = s.p
+	return
+}
+
+func (s *storeProducts) WriteAll(p map[int]internal.Product) (err error) {
+	s.p = p
+	return
+}
+
+func TestRepositoryProductGetAllFilters(t *testing.T) {
+	// ARRANGE
+	db, err := sql.Open("txdb", "supermarket_test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	// populate products
+	_, err = db.Exec("DELETE FROM products")
+	require.NoError(t, err)
+	rows := [][]any{
+		{1, "Apple", 10, "A-1", "1", "2021-01-01", 1.5, 1},
+		{2, "Banana", 20, "B-1", "0", "2021-06-15", 2.25, 1},
+		{3, "Cherry", 30, "C-1", "1", "2021-12-31", 10, 2},
+		{4, "Pineapple", 40, "P-1", "0", "2022-01-01", 0.99, 2},
+	}
+	for _, r := range rows {
+		_, err = db.Exec("INSERT INTO products (id, name, quantity, code_value, is_published, expiration, price, id_warehouse) VALUES (?, ?, ?, ?, ?, ?, ?, ?)", r...)
+		require.NoError(t, err)
+	}
+
+	// repositories, the store holding the same products as the database
+	rpMySQL := repository.NewRepositoryProductMySQL(db, 0, slog.Default())
+	all, _, err := rpMySQL.GetAll(context.Background(), internal.ListQuery{})
+	require.NoError(t, err)
+	require.Len(t, all, 4)
+	st := &storeProducts{p: make(map[int]internal.Product)}
+	for _, p := range all {
+		st.p[p.Id] = p
+	}
+	rpStore := repository.NewRepositoryProductStore(st)
+
+	cases := []struct {
+		name     string
+		filters  map[string]string
+		expected []int
+	}{
+		{name: "published", filters: map[string]string{"is_published": "true"}, expected: []int{1, 3}},
+		{name: "not published", filters: map[string]string{"is_published": "0"}, expected: []int{2, 4}},
+		{name: "warehouse", filters: map[string]string{"id_warehouse": "2"}, expected: []int{3, 4}},
+		{name: "price range", filters: map[string]string{"price_min": "1.5", "price_max": "2.25"}, expected: []int{1, 2}},
+		{name: "expiration range", filters: map[string]string{"expiration_from": "2021-06-15", "expiration_to": "2021-12-31"}, expected: []int{2, 3}},
+	}
+	for _, c := range cases {
+		t.Run("should filter the same products in MySQL and the store - "+c.name, func(t *testing.T) {
+			// ACT
+			q := internal.ListQuery{Filters: c.filters}
+			pMySQL, _, errMySQL := rpMySQL.GetAll(context.Background(), q)
+			pStore, _, errStore := rpStore.GetAll(context.Background(), q)
+
+			// ASSERT
+			require.NoError(t, errMySQL)
+			require.NoError(t, errStore)
+			require.Equal(t, pStore, pMySQL)
+			ids := make([]int, len(pMySQL))
+			for ix, p := range pMySQL {
+				ids[ix] = p.Id
+			}
+			require.Equal(t, c.expected, ids)
+		})
+	}
+
+	t.Run("should reject an invalid filter value in MySQL and the store", func(t *testing.T) {
+		// ACT
+		q := internal.ListQuery{Filters: map[string]string{"is_published": "maybe"}}
+		_, _, errMySQL := rpMySQL.GetAll(context.Background(), q)
+		_, _, errStore := rpStore.GetAll(context.Background(), q)
+
+		// ASSERT
+		require.ErrorIs(t, errMySQL, internal.ErrListQueryInvalid)
+		require.ErrorIs(t, errStore, internal.ErrListQueryInvalid)
+	})
+}
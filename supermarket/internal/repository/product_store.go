@@ -1,6 +1,10 @@
 package repository
 
-import "supermarket/internal"
+import (
+	"context"
+	"supermarket/internal"
+	"time"
+)
 
 // NewRepositoryProductStore creates a new repository for products.
 func NewRepositoryProductStore(st internal.StoreProduct) (r *RepositoryProductStore) {
@@ -17,7 +21,7 @@ type RepositoryProductStore struct {
 }
 
 // FindById finds a product by id.
-func (r *RepositoryProductStore) FindById(id int) (p internal.Product, err error) {
+func (r *RepositoryProductStore) FindById(ctx context.Context, id int) (p internal.Product, err error) {
 	// read all products
 	ps, err := r.st.ReadAll()
 	if err != nil {
@@ -34,8 +38,8 @@ func (r *RepositoryProductStore) FindById(id int) (p internal.Product, err error
 	return
 }
 
-// GetAll gets all products.
-func (r *RepositoryProductStore) GetAll() (p []internal.Product, err error) {
+// GetAll gets a page of products.
+func (r *RepositoryProductStore) GetAll(ctx context.Context, q internal.ListQuery) (p []internal.Product, pg internal.Page, err error) {
 	// read all products
 	ps, err := r.st.ReadAll()
 	if err != nil {
@@ -47,11 +51,35 @@ func (r *RepositoryProductStore) GetAll() (p []internal.Product, err error) {
 		p = append(p, v)
 	}
 
+	// filter, sort and paginate
+	p, pg, err = listSpecProducts.slice(q, p)
+	return
+}
+
+// Stream calls fn with each product of the list.
+// The store is read as a whole, so the list is held in memory anyway.
+func (r *RepositoryProductStore) Stream(ctx context.Context, q internal.ListQuery, fn func(p internal.Product) error) (err error) {
+	// read the whole list, then limit it to q.Limit products
+	p, _, err := r.GetAll(ctx, internal.ListQuery{Sort: q.Sort, Cursor: q.Cursor, Filters: q.Filters})
+	if err != nil {
+		return
+	}
+	if q.Limit > 0 && len(p) > q.Limit {
+		p = p[:q.Limit]
+	}
+
+	// call fn
+	for _, v := range p {
+		err = fn(v)
+		if err != nil {
+			return
+		}
+	}
 	return
 }
 
 // Save saves a product.
-func (r *RepositoryProductStore) Save(p *internal.Product) (err error) {
+func (r *RepositoryProductStore) Save(ctx context.Context, p *internal.Product) (err error) {
 	// read all products
 	ps, err := r.st.ReadAll()
 	if err != nil {
@@ -76,6 +104,7 @@ func (r *RepositoryProductStore) Save(p *internal.Product) (err error) {
 	(*p).Id = maxId + 1
 
 	// add product
+	p.UpdatedAt = now()
 	ps[p.Id] = *p
 
 	// write all products
@@ -87,8 +116,55 @@ func (r *RepositoryProductStore) Save(p *internal.Product) (err error) {
 	return
 }
 
+// SaveBulk saves products, setting their ids, and returns the error of each one, nil if it was saved.
+// The store is written as a whole, so either every product is saved or, if the write fails, none is, whatever the mode.
+func (r *RepositoryProductStore) SaveBulk(ctx context.Context, p []internal.Product, mode internal.BulkMode) (errs []error) {
+	errs = make([]error, len(p))
+
+	// fail fails every product with err
+	fail := func(err error) {
+		for ix := range p {
+			errs[ix] = err
+			p[ix].Id = 0
+		}
+	}
+
+	// read all products
+	ps, err := r.st.ReadAll()
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	// find max id
+	var maxId int
+	for k := range ps {
+		if k > maxId {
+			maxId = k
+		}
+	}
+
+	// add products, with consecutive ids
+	updatedAt := now()
+	for ix := range p {
+		maxId++
+		p[ix].Id = maxId
+		p[ix].UpdatedAt = updatedAt
+		ps[maxId] = p[ix]
+	}
+
+	// write all products
+	err = r.st.WriteAll(ps)
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	return
+}
+
 // UpdateOrSave updates or saves a product.
-func (r *RepositoryProductStore) UpdateOrSave(p *internal.Product) (err error) {
+func (r *RepositoryProductStore) UpdateOrSave(ctx context.Context, p *internal.Product) (err error) {
 	// read all products
 	ps, err := r.st.ReadAll()
 	if err != nil {
@@ -96,6 +172,7 @@ func (r *RepositoryProductStore) UpdateOrSave(p *internal.Product) (err error) {
 	}
 
 	// update product
+	p.UpdatedAt = now()
 	_, ok := ps[p.Id]
 	switch ok {
 	case true:
@@ -132,7 +209,7 @@ func (r *RepositoryProductStore) UpdateOrSave(p *internal.Product) (err error) {
 }
 
 // Update updates a product.
-func (r *RepositoryProductStore) Update(p *internal.Product) (err error) {
+func (r *RepositoryProductStore) Update(ctx context.Context, p *internal.Product) (err error) {
 	// read all products
 	ps, err := r.st.ReadAll()
 	if err != nil {
@@ -147,6 +224,7 @@ func (r *RepositoryProductStore) Update(p *internal.Product) (err error) {
 	}
 
 	// update product
+	p.UpdatedAt = now()
 	ps[p.Id] = *p
 
 	// write all products
@@ -159,7 +237,7 @@ func (r *RepositoryProductStore) Update(p *internal.Product) (err error) {
 }
 
 // Delete deletes a product.
-func (r *RepositoryProductStore) Delete(id int) (err error) {
+func (r *RepositoryProductStore) Delete(ctx context.Context, id int) (err error) {
 	// read all products
 	ps, err := r.st.ReadAll()
 	if err != nil {
@@ -184,3 +262,8 @@ func (r *RepositoryProductStore) Delete(id int) (err error) {
 
 	return
 }
+
+// now returns the time a product is written at, at the second precision of Last-Modified.
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Second)
+}
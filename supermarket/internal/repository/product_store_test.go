@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"supermarket/internal"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// storeProductMap is a store of products in memory, failing to write with err.
+type storeProductMap struct {
+	p   map[int]internal.Product
+	err error
+}
+
+func (s *storeProductMap) ReadAll() (p map[int]internal.Product, err error) {
+	p = make(map[int]internal.Product, len(s.p))
+	for k, v := range s.p {
+		p[k] = v
+	}
+	return
+}
+
+func (s *storeProductMap) WriteAll(p map[int]internal.Product) (err error) {
+	if s.err != nil {
+		return s.err
+	}
+	s.p = p
+	return
+}
+
+// Tests for RepositoryProductStore.SaveBulk
+func TestRepositoryProductStoreSaveBulk(t *testing.T) {
+	t.Run("saves the products with the ids following the last one", func(t *testing.T) {
+		// arrange
+		st := &storeProductMap{p: map[int]internal.Product{4: {Id: 4}}}
+		rp := NewRepositoryProductStore(st)
+		p := []internal.Product{
+			{ProductAttributes: internal.ProductAttributes{Name: "Apple"}},
+			{ProductAttributes: internal.ProductAttributes{Name: "Banana"}},
+		}
+
+		// act
+		errs := rp.SaveBulk(context.Background(), p, internal.BulkBestEffort)
+
+		// assert
+		require.Equal(t, []error{nil, nil}, errs)
+		require.Equal(t, 5, p[0].Id)
+		require.Equal(t, 6, p[1].Id)
+		require.Len(t, st.p, 3)
+		require.Equal(t, "Banana", st.p[6].Name)
+		require.False(t, st.p[6].UpdatedAt.IsZero())
+	})
+
+	t.Run("fails every product if the store cannot be written", func(t *testing.T) {
+		// arrange
+		errWrite := errors.New("disk full")
+		st := &storeProductMap{p: map[int]internal.Product{}, err: errWrite}
+		rp := NewRepositoryProductStore(st)
+		p := []internal.Product{{}, {}}
+
+		// act
+		errs := rp.SaveBulk(context.Background(), p, internal.BulkAtomic)
+
+		// assert
+		require.Equal(t, []error{errWrite, errWrite}, errs)
+		require.Zero(t, p[0].Id)
+		require.Zero(t, p[1].Id)
+		require.Empty(t, st.p)
+	})
+}
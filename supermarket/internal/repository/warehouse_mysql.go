@@ -1,30 +1,43 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"log/slog"
 	"strings"
 	"supermarket/internal"
+	"time"
 )
 
 // RepositoryWarehouseMySQL is the repository warehouse MySQL.
 type RepositoryWarehouseMySQL struct {
 	// db is the connection to the database.
 	db *sql.DB
+	// timeout bounds each query, zero means no timeout.
+	timeout time.Duration
+	// lg is the logger of the queries that time out.
+	lg *slog.Logger
 }
 
 // NewRepositoryWarehouseMySQL creates a new repository warehouse MySQL.
-func NewRepositoryWarehouseMySQL(db *sql.DB) (rw *RepositoryWarehouseMySQL) {
+func NewRepositoryWarehouseMySQL(db *sql.DB, timeout time.Duration, lg *slog.Logger) (rw *RepositoryWarehouseMySQL) {
 	rw = &RepositoryWarehouseMySQL{
-		db: db,
+		db:      db,
+		timeout: timeout,
+		lg:      lg,
 	}
 	return
 }
 
 // FindById returns a warehouse by its id.
-func (rw *RepositoryWarehouseMySQL) FindById(id int) (w internal.Warehouse, err error) {
+func (rw *RepositoryWarehouseMySQL) FindById(ctx context.Context, id int) (w internal.Warehouse, err error) {
+	// Bound the query.
+	ctx, cancel := queryContext(ctx, rw.timeout, rw.lg, "warehouses", "FindById")
+	defer cancel()
+
 	// Query the database for the warehouse.
-	row := rw.db.QueryRow("SELECT id, name, address, telephone, capacity FROM warehouses WHERE id = ?", id)
+	row := rw.db.QueryRowContext(ctx, "SELECT id, name, address, telephone, capacity FROM warehouses WHERE id = ?", id)
 	if err := row.Err(); err != nil {
 		return w, err
 	}
@@ -40,10 +53,68 @@ func (rw *RepositoryWarehouseMySQL) FindById(id int) (w internal.Warehouse, err
 	return
 }
 
-// GetAll returns all warehouses.
-func (rw *RepositoryWarehouseMySQL) GetAll() (w []internal.Warehouse, err error) {
+// listSpecWarehouses is the list spec of the warehouses.
+var listSpecWarehouses = listSpec[internal.Warehouse]{
+	id:      "id",
+	idValue: func(w internal.Warehouse) int { return w.Id },
+	sorts: map[string]listSort[internal.Warehouse]{
+		"id":       {"id", func(w internal.Warehouse) any { return w.Id }},
+		"name":     {"name", func(w internal.Warehouse) any { return w.Name }},
+		"capacity": {"capacity", func(w internal.Warehouse) any { return w.Capacity }},
+	},
+	filters: map[string]string{
+		"name":         "name LIKE CONCAT('%', ?, '%')",
+		"capacity_min": "capacity >= ?",
+		"capacity_max": "capacity <= ?",
+	},
+}
+
+// GetAll returns a page of warehouses.
+func (rw *RepositoryWarehouseMySQL) GetAll(ctx context.Context, q internal.ListQuery) (w []internal.Warehouse, pg internal.Page, err error) {
+	// Bound the query.
+	ctx, cancel := queryContext(ctx, rw.timeout, rw.lg, "warehouses", "GetAll")
+	defer cancel()
+
+	// Build the clauses of the query.
+	cl, err := listSpecWarehouses.build(q)
+	if err != nil {
+		return
+	}
+
 	// Query the database for the warehouses.
-	rows, err := rw.db.Query("SELECT id, name, address, telephone, capacity FROM warehouses")
+	err = rw.each(ctx, cl, func(warehouse internal.Warehouse) error {
+		w = append(w, warehouse)
+		return nil
+	})
+	if err != nil {
+		return
+	}
+
+	// Trim the page.
+	w, pg, err = listSpecWarehouses.page(q, w)
+	return
+}
+
+// Stream calls fn with each warehouse of the list as it is read from the database, without holding the list in memory.
+// The limit is the total number of warehouses rather than the page size, zero streams all of them.
+// It is not bounded by the query timeout, only by ctx, since streaming a whole table may take longer.
+func (rw *RepositoryWarehouseMySQL) Stream(ctx context.Context, q internal.ListQuery, fn func(warehouse internal.Warehouse) error) (err error) {
+	// Build the clauses of the query.
+	cl, err := listSpecWarehouses.build(q)
+	if err != nil {
+		return
+	}
+	cl.limit = q.Limit
+
+	// Query the database for the warehouses.
+	err = rw.each(ctx, cl, fn)
+	return
+}
+
+// each queries the database with the list clauses and calls fn with each warehouse.
+func (rw *RepositoryWarehouseMySQL) each(ctx context.Context, cl listClause, fn func(warehouse internal.Warehouse) error) (err error) {
+	// Query the database for the warehouses.
+	rows, err := rw.db.QueryContext(ctx, "SELECT id, name, address, telephone, capacity FROM warehouses"+cl.sql(""), cl.args...)
 	if err != nil {
 		return
 	}
@@ -56,14 +127,22 @@ func (rw *RepositoryWarehouseMySQL) GetAll() (w []internal.Warehouse, err error)
 		if err != nil {
 			return
 		}
-		w = append(w, warehouse)
+		err = fn(warehouse)
+		if err != nil {
+			return
+		}
 	}
+	err = rows.Err()
 	return
 }
 
 // Create creates a warehouse.
-func (rw *RepositoryWarehouseMySQL) Save(w *internal.Warehouse) (err error) {
-	result, err := rw.db.Exec(
+func (rw *RepositoryWarehouseMySQL) Save(ctx context.Context, w *internal.Warehouse) (err error) {
+	// Bound the query.
+	ctx, cancel := queryContext(ctx, rw.timeout, rw.lg, "warehouses", "Save")
+	defer cancel()
+
+	result, err := rw.db.ExecContext(ctx,
 		"INSERT INTO warehouses (name, address, telephone, capacity) VALUES (?, ?, ?, ?)",
 		w.Name, w.Address, w.Telephone, w.Capacity,
 	)
@@ -83,7 +162,11 @@ func (rw *RepositoryWarehouseMySQL) Save(w *internal.Warehouse) (err error) {
 }
 
 // ReportProducts returns the amount of products by warehouse.
-func (rw *RepositoryWarehouseMySQL) ReportProducts(warehouseIds []int) (r []internal.WarehouseReportProducts, err error) {
+func (rw *RepositoryWarehouseMySQL) ReportProducts(ctx context.Context, warehouseIds []int) (r []internal.WarehouseReportProducts, err error) {
+	// Bound the query.
+	ctx, cancel := queryContext(ctx, rw.timeout, rw.lg, "warehouses", "ReportProducts")
+	defer cancel()
+
 	var query string
 	args := make([]interface{}, len(warehouseIds))
 	for i, id := range warehouseIds {
@@ -97,9 +180,8 @@ func (rw *RepositoryWarehouseMySQL) ReportProducts(warehouseIds []int) (r []inte
 		query = fmt.Sprintf("SELECT warehouses.name, COUNT(products.id) AS product_count FROM warehouses LEFT JOIN products ON warehouses.id = products.id_warehouse WHERE warehouses.id IN (%s) GROUP BY warehouses.id", placeholders)
 	}
 
-	rows, err := rw.db.Query(query, args...)
+	rows, err := rw.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		fmt.Println(err)
 		return
 	}
 
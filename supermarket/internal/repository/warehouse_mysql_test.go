@@ -1,7 +1,9 @@
 package repository_test
 
 import (
+	"context"
 	"database/sql"
+	"log/slog"
 	"supermarket/internal"
 	"supermarket/internal/repository"
 	"testing"
@@ -40,7 +42,7 @@ func TestRepositoryWarehouseSave(t *testing.T) {
 		}()
 
 		// repository
-		rw := repository.NewRepositoryWarehouseMySQL(db)
+		rw := repository.NewRepositoryWarehouseMySQL(db, 0, slog.Default())
 
 		// Warehouse
 		warehouse := internal.Warehouse{
@@ -51,7 +53,7 @@ func TestRepositoryWarehouseSave(t *testing.T) {
 		}
 
 		// ACT
-		err = rw.Save(&warehouse)
+		err = rw.Save(context.Background(), &warehouse)
 
 		// ASSERT
 		require.NoError(t, err)
@@ -84,10 +86,10 @@ func TestRepositoryWarehouseFindById(t *testing.T) {
 		require.NoError(t, err)
 
 		// repository
-		rw := repository.NewRepositoryWarehouseMySQL(db)
+		rw := repository.NewRepositoryWarehouseMySQL(db, 0, slog.Default())
 
 		// ACT
-		warehouse, err := rw.FindById(1)
+		warehouse, err := rw.FindById(context.Background(), 1)
 
 		// ASSERT
 		require.NoError(t, err)
@@ -104,10 +106,10 @@ func TestRepositoryWarehouseFindById(t *testing.T) {
 		defer db.Close()
 
 		// repository
-		rw := repository.NewRepositoryWarehouseMySQL(db)
+		rw := repository.NewRepositoryWarehouseMySQL(db, 0, slog.Default())
 
 		// ACT
-		warehouse, err := rw.FindById(1)
+		warehouse, err := rw.FindById(context.Background(), 1)
 
 		// ASSERT
 		require.Error(t, err)
@@ -30,6 +30,7 @@ type ProductJSON struct {
 	IsPublished bool    `json:"is_published"`
 	Expiration  string  `json:"expiration"`
 	Price       float64 `json:"price"`
+	UpdatedAt   string  `json:"updated_at,omitempty"`
 }
 
 // ReadAll reads all products from the store.
@@ -57,6 +58,14 @@ func (s *StoreProductJSON) ReadAll() (p map[int]internal.Product, err error) {
 			return
 		}
 
+		var upd time.Time
+		if v.UpdatedAt != "" {
+			upd, err = time.Parse(time.RFC3339, v.UpdatedAt)
+			if err != nil {
+				return
+			}
+		}
+
 		p[v.Id] = internal.Product{
 			Id: v.Id,
 			ProductAttributes: internal.ProductAttributes{
@@ -67,6 +76,7 @@ func (s *StoreProductJSON) ReadAll() (p map[int]internal.Product, err error) {
 				Expiration:  exp,
 				Price:       v.Price,
 			},
+			UpdatedAt: upd,
 		}
 	}
 
@@ -78,6 +88,10 @@ func (s *StoreProductJSON) WriteAll(p map[int]internal.Product) (err error) {
 	// serialize
 	var pr []ProductJSON
 	for _, v := range p {
+		var upd string
+		if !v.UpdatedAt.IsZero() {
+			upd = v.UpdatedAt.Format(time.RFC3339)
+		}
 		pr = append(pr, ProductJSON{
 			Id:          v.Id,
 			Name:        v.Name,
@@ -86,6 +100,7 @@ func (s *StoreProductJSON) WriteAll(p map[int]internal.Product) (err error) {
 			IsPublished: v.IsPublished,
 			Expiration:  v.Expiration.Format(time.DateOnly),
 			Price:       v.Price,
+			UpdatedAt:   upd,
 		})
 	}
 
@@ -1,6 +1,9 @@
 package internal
 
-import "errors"
+import (
+	"context"
+	"errors"
+)
 
 // Warehouse is a struct that contains the attributes of a warehouse
 type Warehouse struct {
@@ -32,11 +35,13 @@ var (
 // RepositoryWarehouse is an interface that contains the methods for a warehouse repository
 type RepositoryWarehouse interface {
 	// FindById returns a warehouse by its id
-	FindById(id int) (w Warehouse, err error)
-	// GetAll returns all warehouses
-	GetAll() (w []Warehouse, err error)
+	FindById(ctx context.Context, id int) (w Warehouse, err error)
+	// GetAll returns a page of warehouses
+	GetAll(ctx context.Context, q ListQuery) (w []Warehouse, pg Page, err error)
+	// Stream calls fn with each warehouse of the list, stopping at the first error
+	Stream(ctx context.Context, q ListQuery, fn func(w Warehouse) error) (err error)
 	// Save creates a warehouse
-	Save(w *Warehouse) (err error)
+	Save(ctx context.Context, w *Warehouse) (err error)
 	// ReportProducts returns a report of the amount of products in each warehouse
-	ReportProducts(warehouseIds []int) (r []WarehouseReportProducts, err error)
+	ReportProducts(ctx context.Context, warehouseIds []int) (r []WarehouseReportProducts, err error)
 }
@@ -0,0 +1,203 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// HeaderAPIKey is the header a static API key is read from.
+const HeaderAPIKey = "X-API-Key"
+
+var (
+	// ErrNoCredentials is returned when the request carries neither an API key nor a bearer token.
+	ErrNoCredentials = errors.New("auth: no credentials")
+	// ErrInvalidAPIKey is returned when the API key is unknown.
+	ErrInvalidAPIKey = errors.New("auth: invalid api key")
+	// ErrInvalidToken is returned when the bearer token is malformed, not signed with the secret or has invalid claims.
+	ErrInvalidToken = errors.New("auth: invalid token")
+	// ErrExpiredToken is returned when the bearer token is expired or not valid yet.
+	ErrExpiredToken = errors.New("auth: expired token")
+	// ErrInvalidRole is returned when a role name is unknown.
+	ErrInvalidRole = errors.New("auth: invalid role")
+)
+
+// Role is the role of a principal, each role is granted the permissions of the roles below it.
+type Role int
+
+const (
+	// RoleNone is the role of anonymous requests.
+	RoleNone Role = iota
+	// RoleViewer can read.
+	RoleViewer
+	// RoleClerk can read and create or update records.
+	RoleClerk
+	// RoleAdmin can do anything, including destructive and operational endpoints.
+	RoleAdmin
+)
+
+// roleNames are the names of the roles, as used in tokens and configuration.
+var roleNames = map[Role]string{
+	RoleNone:   "none",
+	RoleViewer: "viewer",
+	RoleClerk:  "clerk",
+	RoleAdmin:  "admin",
+}
+
+// String returns the name of the role.
+func (r Role) String() string {
+	if s, ok := roleNames[r]; ok {
+		return s
+	}
+	return fmt.Sprintf("Role(%d)", int(r))
+}
+
+// ParseRole returns the role named s.
+func ParseRole(s string) (r Role, err error) {
+	for k, v := range roleNames {
+		if k != RoleNone && v == s {
+			r = k
+			return
+		}
+	}
+	err = fmt.Errorf("%w: %q", ErrInvalidRole, s)
+	return
+}
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	// Subject identifies the caller, e.g. the owner of the API key or the sub claim of the token.
+	Subject string
+	// Role is the role of the caller.
+	Role Role
+}
+
+// principalKey is the context key of the principal.
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying p.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFrom returns the principal of ctx, ok is false for anonymous requests.
+func PrincipalFrom(ctx context.Context) (p Principal, ok bool) {
+	p, ok = ctx.Value(principalKey{}).(Principal)
+	return
+}
+
+// Config is the configuration of an Authenticator.
+type Config struct {
+	// APIKeys maps each static API key to its principal, see ParseAPIKeys.
+	APIKeys map[string]Principal
+	// Secret is the HMAC key of the HS256 bearer tokens. If empty, bearer tokens are rejected.
+	Secret []byte
+	// Now returns the current time, time.Now by default.
+	Now func() time.Time
+}
+
+// NewAuthenticator creates a new Authenticator.
+func NewAuthenticator(cfg Config) (a *Authenticator) {
+	a = &Authenticator{
+		keys:   make(map[[sha256.Size]byte]Principal, len(cfg.APIKeys)),
+		secret: cfg.Secret,
+		now:    cfg.Now,
+	}
+	if a.now == nil {
+		a.now = time.Now
+	}
+	for k, p := range cfg.APIKeys {
+		a.keys[sha256.Sum256([]byte(k))] = p
+	}
+	return
+}
+
+// Authenticator authenticates requests by static API key or HMAC-signed JWT.
+type Authenticator struct {
+	// keys maps the SHA-256 of each API key to its principal, so that lookups do not compare the raw keys.
+	keys map[[sha256.Size]byte]Principal
+	// secret is the HMAC key of the bearer tokens.
+	secret []byte
+	// now returns the current time.
+	now func() time.Time
+}
+
+// Authenticate returns the principal of the request.
+// The X-API-Key header takes precedence over an Authorization: Bearer token.
+func (a *Authenticator) Authenticate(r *http.Request) (p Principal, err error) {
+	if key := r.Header.Get(HeaderAPIKey); key != "" {
+		var ok bool
+		p, ok = a.keys[sha256.Sum256([]byte(key))]
+		if !ok {
+			err = ErrInvalidAPIKey
+		}
+		return
+	}
+
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		err = ErrNoCredentials
+		return
+	}
+	if len(a.secret) == 0 {
+		err = ErrInvalidToken
+		return
+	}
+	var c Claims
+	c, err = Verify(a.secret, token, a.now())
+	if err != nil {
+		return
+	}
+	p = Principal{Subject: c.Subject}
+	p.Role, err = ParseRole(c.Role)
+	if err != nil {
+		err = fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	return
+}
+
+// Middleware stores the principal of each request in its context.
+// Requests without credentials pass through as anonymous so that public routes stay reachable,
+// requests with invalid credentials are rejected with 401.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, err := a.Authenticate(r)
+		switch {
+		case errors.Is(err, ErrNoCredentials):
+			next.ServeHTTP(w, r)
+		case err != nil:
+			unauthorized(w, "invalid credentials")
+		default:
+			next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), p)))
+		}
+	})
+}
+
+// ParseAPIKeys parses a comma separated list of key:role:subject entries, e.g. "k1:admin:ops,k2:viewer:dashboard".
+// An empty string yields no keys.
+func ParseAPIKeys(s string) (keys map[string]Principal, err error) {
+	keys = make(map[string]Principal)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		fields := strings.SplitN(entry, ":", 3)
+		if len(fields) != 3 || fields[0] == "" || fields[2] == "" {
+			err = errors.New("auth: invalid api key entry, want key:role:subject")
+			return
+		}
+		var p Principal
+		p.Role, err = ParseRole(fields[1])
+		if err != nil {
+			return
+		}
+		p.Subject = fields[2]
+		keys[fields[0]] = p
+	}
+	return
+}
@@ -0,0 +1,163 @@
+package auth_test
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"supermarket/platform/auth"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	secret = []byte("test-secret")
+	now    = time.Unix(1700000000, 0)
+)
+
+// newServer returns a handler with the authenticator in front of a route requiring role.
+func newServer(role auth.Role) http.Handler {
+	au := auth.NewAuthenticator(auth.Config{
+		APIKeys: map[string]auth.Principal{
+			"viewer-key": {Subject: "dashboard", Role: auth.RoleViewer},
+			"admin-key":  {Subject: "ops", Role: auth.RoleAdmin},
+		},
+		Secret: secret,
+		Now:    func() time.Time { return now },
+	})
+	return au.Middleware(auth.Require(role)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, _ := auth.PrincipalFrom(r.Context())
+		w.Write([]byte(p.Subject))
+	})))
+}
+
+// Tests for Sign and Verify
+func TestVerify(t *testing.T) {
+	t.Run("round trips the claims", func(t *testing.T) {
+		// arrange
+		c := auth.Claims{Subject: "alice", Role: "clerk", ExpiresAt: now.Add(time.Hour).Unix()}
+		token, err := auth.Sign(secret, c)
+		require.NoError(t, err)
+
+		// act
+		got, err := auth.Verify(secret, token, now)
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, c, got)
+	})
+
+	t.Run("rejects invalid tokens", func(t *testing.T) {
+		// arrange
+		valid, err := auth.Sign(secret, auth.Claims{Subject: "alice", Role: "clerk", ExpiresAt: now.Add(time.Hour).Unix()})
+		require.NoError(t, err)
+		parts := strings.Split(valid, ".")
+		none := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." + parts[1] + "."
+		noExp, err := auth.Sign(secret, auth.Claims{Subject: "alice", Role: "clerk"})
+		require.NoError(t, err)
+		cases := map[string]string{
+			"malformed":      "abc",
+			"other secret":   valid[:len(valid)-2] + "xx",
+			"alg none":       none,
+			"missing exp":    noExp,
+			"tampered claim": parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"alice","role":"admin","exp":1900000000}`)) + "." + parts[2],
+		}
+
+		for name, token := range cases {
+			// act
+			_, err := auth.Verify(secret, token, now)
+
+			// assert
+			require.ErrorIs(t, err, auth.ErrInvalidToken, name)
+		}
+	})
+
+	t.Run("rejects expired tokens", func(t *testing.T) {
+		// arrange
+		token, err := auth.Sign(secret, auth.Claims{Subject: "alice", Role: "clerk", ExpiresAt: now.Unix()})
+		require.NoError(t, err)
+
+		// act
+		_, err = auth.Verify(secret, token, now)
+
+		// assert
+		require.ErrorIs(t, err, auth.ErrExpiredToken)
+	})
+}
+
+// Tests for Middleware and Require
+func TestRequire(t *testing.T) {
+	token := func(role string) string {
+		tk, err := auth.Sign(secret, auth.Claims{Subject: "alice", Role: role, ExpiresAt: now.Add(time.Hour).Unix()})
+		require.NoError(t, err)
+		return tk
+	}
+	cases := []struct {
+		name   string
+		role   auth.Role
+		header string
+		value  string
+		code   int
+		body   string
+	}{
+		{name: "anonymous", role: auth.RoleViewer, code: http.StatusUnauthorized},
+		{name: "unknown api key", role: auth.RoleViewer, header: auth.HeaderAPIKey, value: "nope", code: http.StatusUnauthorized},
+		{name: "api key granted", role: auth.RoleViewer, header: auth.HeaderAPIKey, value: "viewer-key", code: http.StatusOK, body: "dashboard"},
+		{name: "api key forbidden", role: auth.RoleClerk, header: auth.HeaderAPIKey, value: "viewer-key", code: http.StatusForbidden},
+		{name: "admin inherits lower roles", role: auth.RoleClerk, header: auth.HeaderAPIKey, value: "admin-key", code: http.StatusOK, body: "ops"},
+		{name: "token granted", role: auth.RoleClerk, header: "Authorization", value: "Bearer " + token("clerk"), code: http.StatusOK, body: "alice"},
+		{name: "token forbidden", role: auth.RoleAdmin, header: "Authorization", value: "Bearer " + token("clerk"), code: http.StatusForbidden},
+		{name: "token with unknown role", role: auth.RoleViewer, header: "Authorization", value: "Bearer " + token("root"), code: http.StatusUnauthorized},
+		{name: "other scheme", role: auth.RoleViewer, header: "Authorization", value: "Basic YTpi", code: http.StatusUnauthorized},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// arrange
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if c.header != "" {
+				req.Header.Set(c.header, c.value)
+			}
+			res := httptest.NewRecorder()
+
+			// act
+			newServer(c.role).ServeHTTP(res, req)
+
+			// assert
+			require.Equal(t, c.code, res.Code)
+			if c.code == http.StatusOK {
+				require.Equal(t, c.body, res.Body.String())
+			}
+			if c.code == http.StatusUnauthorized {
+				require.NotEmpty(t, res.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+// Tests for ParseAPIKeys
+func TestParseAPIKeys(t *testing.T) {
+	t.Run("parses the entries", func(t *testing.T) {
+		// act
+		keys, err := auth.ParseAPIKeys(" k1:admin:ops, k2:viewer:dashboard,")
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, map[string]auth.Principal{
+			"k1": {Subject: "ops", Role: auth.RoleAdmin},
+			"k2": {Subject: "dashboard", Role: auth.RoleViewer},
+		}, keys)
+	})
+
+	t.Run("rejects invalid entries", func(t *testing.T) {
+		for _, s := range []string{"k1", "k1:admin", "k1:root:ops", ":admin:ops", "k1:none:ops"} {
+			// act
+			_, err := auth.ParseAPIKeys(s)
+
+			// assert
+			require.Error(t, err, s)
+		}
+	})
+}
@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"net/http"
+	"supermarket/platform/web/response"
+)
+
+// Require rejects the requests whose principal is not granted role: 401 for anonymous requests, 403 otherwise.
+// It must run after Authenticator.Middleware, and is meant to be declared next to each route, e.g.
+//
+//	r.With(auth.Require(auth.RoleAdmin)).Delete("/{id}", hd.Delete())
+func Require(role Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p, ok := PrincipalFrom(r.Context())
+			if !ok {
+				unauthorized(w, "authentication required")
+				return
+			}
+			if p.Role < role {
+				response.Errorf(w, http.StatusForbidden, "role %s required", role)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// unauthorized responds 401, advertising the bearer scheme.
+func unauthorized(w http.ResponseWriter, message string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+	response.Error(w, http.StatusUnauthorized, message)
+}
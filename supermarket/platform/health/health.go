@@ -0,0 +1,159 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Check checks a dependency of the application, returning an error if it is not ready.
+type Check func(ctx context.Context) (err error)
+
+// Result is the result of a check.
+type Result struct {
+	// Ok is true if the dependency is ready.
+	Ok bool
+	// Error is the reason the dependency is not ready, empty if it is.
+	// It is generic, ReasonTimeout or ReasonUnavailable, so that it can be shown to anyone.
+	Error string
+	// Err is the error of the check, nil if the dependency is ready.
+	// It may hold details of the dependency such as its address, so it is meant to be logged rather than shown.
+	Err error
+	// Duration is how long the check took.
+	Duration time.Duration
+}
+
+// Reasons a dependency is not ready, see Result.
+const (
+	// ReasonTimeout is the reason of a check that did not finish within the timeout of the checker.
+	ReasonTimeout = "timeout"
+	// ReasonUnavailable is the reason of a check that failed.
+	ReasonUnavailable = "unavailable"
+)
+
+// Report is the result of every check.
+type Report struct {
+	// Ok is true if every dependency is ready.
+	Ok bool
+	// Results are the results of the checks, by name.
+	Results map[string]Result
+}
+
+// NewChecker returns a new Checker that runs its checks within timeout.
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout, checks: make(map[string]Check)}
+}
+
+// Checker runs the readiness checks of the dependencies of an application.
+type Checker struct {
+	// timeout is the deadline of a run of the checks.
+	timeout time.Duration
+	// checks are the checks, by name.
+	checks map[string]Check
+}
+
+// Add adds a check named name.
+func (c *Checker) Add(name string, ck Check) {
+	c.checks[name] = ck
+}
+
+// Run runs every check concurrently, within the timeout of the checker.
+func (c *Checker) Run(ctx context.Context) (r Report) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	// run the checks
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	r = Report{Ok: true, Results: make(map[string]Result, len(c.checks))}
+	for name, ck := range c.checks {
+		wg.Add(1)
+		go func(name string, ck Check) {
+			defer wg.Done()
+			start := time.Now()
+			err := ck(ctx)
+			res := Result{Ok: err == nil, Err: err, Duration: time.Since(start)}
+			switch {
+			case errors.Is(err, context.DeadlineExceeded):
+				res.Error = ReasonTimeout
+			case err != nil:
+				res.Error = ReasonUnavailable
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			r.Results[name] = res
+			r.Ok = r.Ok && res.Ok
+		}(name, ck)
+	}
+	wg.Wait()
+	return
+}
+
+// Ping returns a check that pings db.
+func Ping(db *sql.DB) Check {
+	return func(ctx context.Context) (err error) {
+		err = db.PingContext(ctx)
+		return
+	}
+}
+
+// ErrSchemaVersion is returned when the schema of the database is older than the one the application needs.
+var ErrSchemaVersion = errors.New("health: schema version mismatch")
+
+// SchemaVersion returns a check that the version recorded in the schema_version table of db is at least version.
+func SchemaVersion(db *sql.DB, version int) Check {
+	return func(ctx context.Context) (err error) {
+		var current sql.NullInt64
+		err = db.QueryRowContext(ctx, "SELECT MAX(`version`) FROM `schema_version`").Scan(&current)
+		if err != nil {
+			return
+		}
+		if !current.Valid || int(current.Int64) < version {
+			err = fmt.Errorf("%w: got %d, want at least %d", ErrSchemaVersion, current.Int64, version)
+			return
+		}
+		return
+	}
+}
+
+// File returns a check that the file at path can be opened for reading.
+func File(path string) Check {
+	return func(ctx context.Context) (err error) {
+		f, err := os.Open(path)
+		if err != nil {
+			return
+		}
+		err = f.Close()
+		return
+	}
+}
+
+// maxRetryWait is the maximum wait between the attempts of Retry.
+const maxRetryWait = 5 * time.Second
+
+// Retry calls ck until it succeeds, waiting between attempts with an exponential backoff starting at wait.
+// It gives up after timeout, returning the last error.
+func Retry(ctx context.Context, ck Check, wait, timeout time.Duration) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		err = ck(ctx)
+		if err == nil {
+			return
+		}
+
+		// backoff
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		wait = min(wait*2, maxRetryWait)
+	}
+}
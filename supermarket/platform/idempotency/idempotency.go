@@ -0,0 +1,146 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrKeyInvalid is returned when an idempotency key is empty, too long or not printable ascii.
+var ErrKeyInvalid = errors.New("idempotency: invalid key")
+
+// maxKeyLength is the maximum length of an idempotency key.
+const maxKeyLength = 255
+
+// sweepInterval is the minimum duration between two purges of the expired keys of a store.
+const sweepInterval = time.Minute
+
+// ValidateKey returns ErrKeyInvalid if key is not a valid idempotency key.
+func ValidateKey(key string) error {
+	if key == "" || len(key) > maxKeyLength {
+		return ErrKeyInvalid
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x21 || key[i] > 0x7e {
+			return ErrKeyInvalid
+		}
+	}
+	return nil
+}
+
+// Response is a response stored for the replays of its key.
+type Response struct {
+	// Status is the status code of the response.
+	Status int
+	// ContentType is the Content-Type header of the response.
+	ContentType string
+	// Body is the body of the response.
+	Body []byte
+}
+
+// Record is the state of a reserved key.
+type Record struct {
+	// Hash is the hash of the request that reserved the key.
+	Hash string
+	// Response is the response to the request, nil while it is in progress.
+	Response *Response
+}
+
+// Store persists the keys of the clients and their responses until they expire.
+type Store interface {
+	// Reserve reserves key of client for a request with hash until now plus ttl and returns true,
+	// or returns the record of the key and false if it is already reserved and not expired.
+	Reserve(ctx context.Context, client, key, hash string, now time.Time, ttl time.Duration) (rec Record, reserved bool, err error)
+	// Complete stores res as the response of key of client, unless the key is reserved by another request than the one with hash,
+	// e.g. after it expired and was reserved again.
+	Complete(ctx context.Context, client, key, hash string, res Response) (err error)
+	// Release forgets key of client, so that its request can be retried, unless the key is reserved by another request
+	// than the one with hash, like Complete.
+	Release(ctx context.Context, client, key, hash string) (err error)
+}
+
+// NewMemory creates a new Memory store.
+func NewMemory() (m *Memory) {
+	m = &Memory{
+		keys: make(map[memoryKey]memoryRecord),
+	}
+	return
+}
+
+// Memory is a Store in memory, safe for concurrent use, e.g. for an application without a database.
+// The keys are lost on restart.
+type Memory struct {
+	// mu guards keys and swept.
+	mu sync.Mutex
+	// keys are the records by client and key.
+	keys map[memoryKey]memoryRecord
+	// swept is the last time the expired keys were forgotten.
+	swept time.Time
+}
+
+// memoryKey is the key of a record of Memory.
+type memoryKey struct {
+	client string
+	key    string
+}
+
+// memoryRecord is a record of Memory with its expiration.
+type memoryRecord struct {
+	Record
+	expires time.Time
+}
+
+// Reserve reserves key of client, see Store.
+func (m *Memory) Reserve(ctx context.Context, client, key, hash string, now time.Time, ttl time.Duration) (rec Record, reserved bool, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// forget the expired keys, at most once per sweepInterval
+	if now.Sub(m.swept) >= sweepInterval {
+		for k, v := range m.keys {
+			if !now.Before(v.expires) {
+				delete(m.keys, k)
+			}
+		}
+		m.swept = now
+	}
+
+	// reserve the key, unless it is reserved and not expired
+	k := memoryKey{client: client, key: key}
+	if v, ok := m.keys[k]; ok && now.Before(v.expires) {
+		rec = v.Record
+		return
+	}
+	m.keys[k] = memoryRecord{Record: Record{Hash: hash}, expires: now.Add(ttl)}
+	reserved = true
+	return
+}
+
+// Complete stores the response of key of client, see Store.
+func (m *Memory) Complete(ctx context.Context, client, key, hash string, res Response) (err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	k := memoryKey{client: client, key: key}
+	v, ok := m.keys[k]
+	if !ok || v.Hash != hash {
+		return
+	}
+	v.Response = &res
+	m.keys[k] = v
+	return
+}
+
+// Release forgets key of client, see Store.
+func (m *Memory) Release(ctx context.Context, client, key, hash string) (err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	k := memoryKey{client: client, key: key}
+	if v, ok := m.keys[k]; !ok || v.Hash != hash {
+		return
+	}
+	delete(m.keys, k)
+	return
+}
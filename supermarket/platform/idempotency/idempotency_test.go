@@ -0,0 +1,295 @@
+package idempotency_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"supermarket/platform/idempotency"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for ValidateKey function
+func TestValidateKey(t *testing.T) {
+	require.NoError(t, idempotency.ValidateKey("3f2c9a7e-4b1d-4c8e-9f6a-2d7b8e1c0a5f"))
+	require.ErrorIs(t, idempotency.ValidateKey(""), idempotency.ErrKeyInvalid)
+	require.ErrorIs(t, idempotency.ValidateKey("a b"), idempotency.ErrKeyInvalid)
+	require.ErrorIs(t, idempotency.ValidateKey("ñ"), idempotency.ErrKeyInvalid)
+	require.ErrorIs(t, idempotency.ValidateKey(strings.Repeat("a", 256)), idempotency.ErrKeyInvalid)
+}
+
+// Tests for Memory store
+func TestMemory(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+
+	t.Run("reserves a key once until it expires", func(t *testing.T) {
+		// arrange
+		m := idempotency.NewMemory()
+
+		// act & assert
+		_, reserved, err := m.Reserve(ctx, "a", "k", "h1", now, time.Hour)
+		require.NoError(t, err)
+		require.True(t, reserved)
+
+		rec, reserved, err := m.Reserve(ctx, "a", "k", "h2", now.Add(time.Minute), time.Hour)
+		require.NoError(t, err)
+		require.False(t, reserved)
+		require.Equal(t, idempotency.Record{Hash: "h1"}, rec)
+
+		_, reserved, err = m.Reserve(ctx, "b", "k", "h2", now, time.Hour)
+		require.NoError(t, err)
+		require.True(t, reserved)
+
+		_, reserved, err = m.Reserve(ctx, "a", "k", "h2", now.Add(time.Hour), time.Hour)
+		require.NoError(t, err)
+		require.True(t, reserved)
+	})
+
+	t.Run("returns the completed response", func(t *testing.T) {
+		// arrange
+		m := idempotency.NewMemory()
+		m.Reserve(ctx, "a", "k", "h", now, time.Hour)
+		res := idempotency.Response{Status: http.StatusCreated, ContentType: "application/json", Body: []byte(`{}`)}
+
+		// act
+		require.NoError(t, m.Complete(ctx, "a", "k", "h", res))
+		rec, reserved, err := m.Reserve(ctx, "a", "k", "h", now, time.Hour)
+
+		// assert
+		require.NoError(t, err)
+		require.False(t, reserved)
+		require.Equal(t, &res, rec.Response)
+	})
+
+	t.Run("does not complete a key reserved by another request", func(t *testing.T) {
+		// arrange
+		m := idempotency.NewMemory()
+		m.Reserve(ctx, "a", "k", "h2", now, time.Hour)
+
+		// act
+		require.NoError(t, m.Complete(ctx, "a", "k", "h1", idempotency.Response{Status: http.StatusCreated}))
+		rec, reserved, err := m.Reserve(ctx, "a", "k", "h2", now, time.Hour)
+
+		// assert
+		require.NoError(t, err)
+		require.False(t, reserved)
+		require.Nil(t, rec.Response)
+	})
+
+	t.Run("forgets a released key", func(t *testing.T) {
+		// arrange
+		m := idempotency.NewMemory()
+		m.Reserve(ctx, "a", "k", "h", now, time.Hour)
+
+		// act
+		require.NoError(t, m.Release(ctx, "a", "k", "h"))
+		_, reserved, err := m.Reserve(ctx, "a", "k", "h", now, time.Hour)
+
+		// assert
+		require.NoError(t, err)
+		require.True(t, reserved)
+	})
+
+	t.Run("does not release a key reserved again by another request", func(t *testing.T) {
+		// arrange
+		m := idempotency.NewMemory()
+		m.Reserve(ctx, "a", "k", "h1", now, time.Hour)
+		m.Reserve(ctx, "a", "k", "h2", now.Add(time.Hour), time.Hour)
+
+		// act
+		require.NoError(t, m.Release(ctx, "a", "k", "h1"))
+		rec, reserved, err := m.Reserve(ctx, "a", "k", "h3", now.Add(time.Hour), time.Hour)
+
+		// assert
+		require.NoError(t, err)
+		require.False(t, reserved)
+		require.Equal(t, idempotency.Record{Hash: "h2"}, rec)
+	})
+}
+
+// newCounter returns a handler answering 201 with the number of times it ran and the request body,
+// or 500 if the body is fail.
+func newCounter() (h http.HandlerFunc, calls *int) {
+	calls = new(int)
+	h = func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		b := new(strings.Builder)
+		fmt.Fprintf(b, "%d ", *calls)
+		buf := make([]byte, 64)
+		n, _ := r.Body.Read(buf)
+		b.Write(buf[:n])
+		if string(buf[:n]) == "fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(b.String()))
+	}
+	return
+}
+
+// Tests for Middleware function
+func TestMiddleware(t *testing.T) {
+	// request returns a POST request to path with body, and key as Idempotency-Key unless it is empty
+	request := func(path, key, body string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		if key != "" {
+			r.Header.Set(idempotency.HeaderKey, key)
+		}
+		return r
+	}
+
+	t.Run("replays the response of a key", func(t *testing.T) {
+		// arrange
+		h, calls := newCounter()
+		mw := idempotency.Middleware(idempotency.Config{})(h)
+
+		// act
+		rr1 := httptest.NewRecorder()
+		mw.ServeHTTP(rr1, request("/sales", "k1", "sale"))
+		rr2 := httptest.NewRecorder()
+		mw.ServeHTTP(rr2, request("/sales", "k1", "sale"))
+
+		// assert
+		require.Equal(t, 1, *calls)
+		require.Equal(t, http.StatusCreated, rr2.Code)
+		require.Equal(t, "1 sale", rr2.Body.String())
+		require.Equal(t, "text/plain", rr2.Header().Get("Content-Type"))
+		require.Equal(t, "true", rr2.Header().Get(idempotency.HeaderReplayed))
+		require.Empty(t, rr1.Header().Get(idempotency.HeaderReplayed))
+	})
+
+	t.Run("runs the requests without a key", func(t *testing.T) {
+		// arrange
+		h, calls := newCounter()
+		mw := idempotency.Middleware(idempotency.Config{})(h)
+
+		// act
+		mw.ServeHTTP(httptest.NewRecorder(), request("/sales", "", "sale"))
+		mw.ServeHTTP(httptest.NewRecorder(), request("/sales", "", "sale"))
+
+		// assert
+		require.Equal(t, 2, *calls)
+	})
+
+	t.Run("422 - key reused with another body or path", func(t *testing.T) {
+		// arrange
+		h, _ := newCounter()
+		mw := idempotency.Middleware(idempotency.Config{})(h)
+		mw.ServeHTTP(httptest.NewRecorder(), request("/sales", "k1", "sale"))
+
+		// act
+		rrBody := httptest.NewRecorder()
+		mw.ServeHTTP(rrBody, request("/sales", "k1", "other sale"))
+		rrPath := httptest.NewRecorder()
+		mw.ServeHTTP(rrPath, request("/sales?mode=best_effort", "k1", "sale"))
+
+		// assert
+		require.Equal(t, http.StatusUnprocessableEntity, rrBody.Code)
+		require.Contains(t, rrBody.Body.String(), `"code":"idempotency_key_reused"`)
+		require.Equal(t, http.StatusUnprocessableEntity, rrPath.Code)
+	})
+
+	t.Run("keys are per client", func(t *testing.T) {
+		// arrange
+		h, calls := newCounter()
+		mw := idempotency.Middleware(idempotency.Config{Client: func(r *http.Request) string { return r.Header.Get("X-Client") }})(h)
+		r1, r2 := request("/sales", "k1", "sale"), request("/sales", "k1", "sale")
+		r1.Header.Set("X-Client", "a")
+		r2.Header.Set("X-Client", "b")
+
+		// act
+		mw.ServeHTTP(httptest.NewRecorder(), r1)
+		rr := httptest.NewRecorder()
+		mw.ServeHTTP(rr, r2)
+
+		// assert
+		require.Equal(t, 2, *calls)
+		require.Empty(t, rr.Header().Get(idempotency.HeaderReplayed))
+	})
+
+	t.Run("409 - key of a request in progress", func(t *testing.T) {
+		// arrange
+		var rr *httptest.ResponseRecorder
+		var mw http.Handler
+		mw = idempotency.Middleware(idempotency.Config{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rr = httptest.NewRecorder()
+			mw.ServeHTTP(rr, request("/sales", "k1", "sale"))
+			w.WriteHeader(http.StatusCreated)
+		}))
+
+		// act
+		mw.ServeHTTP(httptest.NewRecorder(), request("/sales", "k1", "sale"))
+
+		// assert
+		require.Equal(t, http.StatusConflict, rr.Code)
+		require.Equal(t, "1", rr.Header().Get("Retry-After"))
+		require.Contains(t, rr.Body.String(), `"code":"idempotency_key_in_progress"`)
+	})
+
+	t.Run("5xx responses release the key", func(t *testing.T) {
+		// arrange
+		h, calls := newCounter()
+		mw := idempotency.Middleware(idempotency.Config{})(h)
+
+		// act
+		mw.ServeHTTP(httptest.NewRecorder(), request("/sales", "k1", "fail"))
+		rr := httptest.NewRecorder()
+		mw.ServeHTTP(rr, request("/sales", "k1", "fail"))
+
+		// assert
+		require.Equal(t, 2, *calls)
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+
+	t.Run("panics release the key", func(t *testing.T) {
+		// arrange
+		st := idempotency.NewMemory()
+		mw := idempotency.Middleware(idempotency.Config{Store: st})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		}))
+
+		// act
+		require.Panics(t, func() { mw.ServeHTTP(httptest.NewRecorder(), request("/sales", "k1", "sale")) })
+		_, reserved, err := st.Reserve(context.Background(), "", "k1", "h", time.Now(), time.Hour)
+
+		// assert
+		require.NoError(t, err)
+		require.True(t, reserved)
+	})
+
+	t.Run("400 - invalid key", func(t *testing.T) {
+		// arrange
+		h, calls := newCounter()
+		mw := idempotency.Middleware(idempotency.Config{})(h)
+
+		// act
+		rr := httptest.NewRecorder()
+		mw.ServeHTTP(rr, request("/sales", "not a key", "sale"))
+
+		// assert
+		require.Equal(t, 0, *calls)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), `"code":"invalid_parameter"`)
+	})
+
+	t.Run("413 - body over the maximum size", func(t *testing.T) {
+		// arrange
+		h, calls := newCounter()
+		mw := idempotency.Middleware(idempotency.Config{MaxBytes: 4})(h)
+
+		// act
+		rr := httptest.NewRecorder()
+		mw.ServeHTTP(rr, request("/sales", "k1", "sales"))
+
+		// assert
+		require.Equal(t, 0, *calls)
+		require.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+	})
+}
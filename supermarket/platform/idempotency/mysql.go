@@ -0,0 +1,131 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+)
+
+// NewMySQL creates a new MySQL store, on the idempotency_keys table of db, see docs/db/mysql.
+func NewMySQL(db *sql.DB) (m *MySQL) {
+	m = &MySQL{
+		db: db,
+	}
+	return
+}
+
+// MySQL is a Store on a MySQL table, shared by the instances of an application.
+// Expired keys are purged in batches by the reservations, at most once per sweepInterval per instance.
+type MySQL struct {
+	// db is the connection to the database.
+	db *sql.DB
+	// mu guards swept.
+	mu sync.Mutex
+	// swept is the last time the expired keys were purged.
+	swept time.Time
+}
+
+// sweepBatch is the maximum number of expired keys a purge deletes.
+const sweepBatch = 1000
+
+// Reserve reserves key of client, see Store.
+// A key reserved again and again concurrently with its expiration or release is reported as in progress.
+func (m *MySQL) Reserve(ctx context.Context, client, key, hash string, now time.Time, ttl time.Duration) (rec Record, reserved bool, err error) {
+	now = now.UTC()
+
+	// purge the expired keys
+	err = m.sweep(ctx, now)
+	if err != nil {
+		return
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		// reserve the key, unless it is reserved
+		var res sql.Result
+		res, err = m.db.ExecContext(ctx,
+			"INSERT IGNORE INTO `idempotency_keys` (`client`, `key`, `request_hash`, `expires_at`) VALUES (?, ?, ?, ?)",
+			client, key, hash, now.Add(ttl),
+		)
+		if err != nil {
+			return
+		}
+		var n int64
+		n, err = res.RowsAffected()
+		if err != nil {
+			return
+		}
+		if n == 1 {
+			reserved = true
+			return
+		}
+
+		// read the record of the key
+		var status sql.NullInt64
+		var contentType sql.NullString
+		var body []byte
+		var expired bool
+		err = m.db.QueryRowContext(ctx,
+			"SELECT `request_hash`, `status`, `content_type`, `body`, `expires_at` <= ? FROM `idempotency_keys` WHERE `client` = ? AND `key` = ?",
+			now, client, key,
+		).Scan(&rec.Hash, &status, &contentType, &body, &expired)
+		if errors.Is(err, sql.ErrNoRows) {
+			// released meanwhile
+			err = nil
+			continue
+		}
+		if err != nil {
+			return
+		}
+		if !expired {
+			if status.Valid {
+				rec.Response = &Response{Status: int(status.Int64), ContentType: contentType.String, Body: body}
+			}
+			return
+		}
+
+		// forget the expired key, then reserve it again
+		_, err = m.db.ExecContext(ctx,
+			"DELETE FROM `idempotency_keys` WHERE `client` = ? AND `key` = ? AND `expires_at` <= ?",
+			client, key, now,
+		)
+		if err != nil {
+			return
+		}
+	}
+	rec = Record{Hash: hash}
+	return
+}
+
+// Complete stores the response of key of client, see Store.
+func (m *MySQL) Complete(ctx context.Context, client, key, hash string, res Response) (err error) {
+	_, err = m.db.ExecContext(ctx,
+		"UPDATE `idempotency_keys` SET `status` = ?, `content_type` = ?, `body` = ? WHERE `client` = ? AND `key` = ? AND `request_hash` = ?",
+		res.Status, res.ContentType, res.Body, client, key, hash,
+	)
+	return
+}
+
+// Release forgets key of client, see Store.
+func (m *MySQL) Release(ctx context.Context, client, key, hash string) (err error) {
+	_, err = m.db.ExecContext(ctx,
+		"DELETE FROM `idempotency_keys` WHERE `client` = ? AND `key` = ? AND `request_hash` = ?",
+		client, key, hash,
+	)
+	return
+}
+
+// sweep deletes a batch of the keys expired at now, unless it did less than sweepInterval ago.
+func (m *MySQL) sweep(ctx context.Context, now time.Time) (err error) {
+	m.mu.Lock()
+	if now.Sub(m.swept) < sweepInterval {
+		m.mu.Unlock()
+		return
+	}
+	m.swept = now
+	m.mu.Unlock()
+
+	_, err = m.db.ExecContext(ctx, "DELETE FROM `idempotency_keys` WHERE `expires_at` <= ? LIMIT ?", now, sweepBatch)
+	return
+}
@@ -0,0 +1,110 @@
+package logging_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"supermarket/platform/logging"
+	"supermarket/platform/web/response"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for RequestIDMiddleware
+func TestRequestIDMiddleware(t *testing.T) {
+	t.Run("propagates the id of the request", func(t *testing.T) {
+		// arrange
+		var got string
+		hd := logging.RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = logging.RequestID(r.Context())
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(logging.HeaderRequestID, "abc-123")
+		res := httptest.NewRecorder()
+
+		// act
+		hd.ServeHTTP(res, req)
+
+		// assert
+		require.Equal(t, "abc-123", got)
+		require.Equal(t, "abc-123", res.Header().Get(logging.HeaderRequestID))
+	})
+
+	t.Run("generates an id when missing or invalid", func(t *testing.T) {
+		// arrange
+		var got string
+		hd := logging.RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = logging.RequestID(r.Context())
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(logging.HeaderRequestID, "has spaces\n")
+		res := httptest.NewRecorder()
+
+		// act
+		hd.ServeHTTP(res, req)
+
+		// assert
+		require.Len(t, got, 32)
+		require.Equal(t, got, res.Header().Get(logging.HeaderRequestID))
+	})
+}
+
+// Tests for ContextHandler
+func TestContextHandler(t *testing.T) {
+	t.Run("records logged with the request context carry its id and route", func(t *testing.T) {
+		// arrange
+		var buf bytes.Buffer
+		lg := logging.New(&buf, slog.LevelInfo)
+		rt := chi.NewRouter()
+		rt.Use(logging.RequestIDMiddleware)
+		rt.Get("/customers/{id}", func(w http.ResponseWriter, r *http.Request) {
+			lg.ErrorContext(r.Context(), "error getting customer", "customer_id", 1)
+		})
+		req := httptest.NewRequest(http.MethodGet, "/customers/1", nil)
+		req.Header.Set(logging.HeaderRequestID, "abc-123")
+
+		// act
+		rt.ServeHTTP(httptest.NewRecorder(), req)
+
+		// assert
+		var line map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+		require.Equal(t, "error getting customer", line["msg"])
+		require.Equal(t, "abc-123", line["request_id"])
+		require.Equal(t, "/customers/{id}", line["route"])
+		require.Equal(t, float64(1), line["customer_id"])
+	})
+}
+
+// Tests for Middleware
+func TestMiddleware(t *testing.T) {
+	t.Run("5xx responses are logged at error level", func(t *testing.T) {
+		// arrange
+		var buf bytes.Buffer
+		lg := logging.New(&buf, slog.LevelInfo)
+		rt := chi.NewRouter()
+		rt.Use(logging.RequestIDMiddleware, logging.Middleware(lg), logging.Recoverer(lg))
+		rt.Get("/panic", func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		})
+		res := httptest.NewRecorder()
+
+		// act
+		rt.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+		// assert
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		require.Equal(t, http.StatusInternalServerError, res.Code)
+		require.Equal(t, response.ContentTypeProblem, res.Header().Get("Content-Type"))
+		require.Len(t, lines, 2)
+		require.Contains(t, lines[0], `"msg":"panic serving request"`)
+		require.Contains(t, lines[1], `"level":"ERROR","msg":"request"`)
+		require.Contains(t, lines[1], `"status":500`)
+		require.Contains(t, lines[1], `"route":"/panic"`)
+	})
+}
@@ -0,0 +1,63 @@
+package logging
+
+import (
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"supermarket/platform/web/response"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Middleware logs each request once it is served, with its status, size and duration.
+// Requests answered with a 5xx are logged at error level, so that they can be matched
+// with the line the handler logged with the underlying error.
+func Middleware(lg *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r)
+
+			code := ww.Status()
+			if code == 0 {
+				code = http.StatusOK
+			}
+			level := slog.LevelInfo
+			if code >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+			lg.Log(r.Context(), level, "request",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"status", code,
+				"bytes", ww.BytesWritten(),
+				"duration_ms", time.Since(start).Milliseconds(),
+				"remote_addr", r.RemoteAddr,
+			)
+		})
+	}
+}
+
+// Recoverer recovers from panics in the next handlers, logging the panic with its stack
+// and answering 500 if nothing was written yet.
+func Recoverer(lg *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				rv := recover()
+				if rv == nil {
+					return
+				}
+				// let the server abort the connection, as net/http does
+				if rv == http.ErrAbortHandler {
+					panic(rv)
+				}
+				lg.ErrorContext(r.Context(), "panic serving request", "panic", rv, "stack", string(debug.Stack()))
+				response.Error(w, http.StatusInternalServerError, "internal server error")
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package metrics
+
+import (
+	"database/sql"
+)
+
+// DBStats returns the gauges and counters of the connection pool of db, read on each scrape.
+func DBStats(db *sql.DB) []Collector {
+	return []Collector{
+		NewGaugeFunc("db_open_connections", "Number of established connections, in use or idle.", "gauge", func() float64 {
+			return float64(db.Stats().OpenConnections)
+		}),
+		NewGaugeFunc("db_in_use_connections", "Number of connections in use.", "gauge", func() float64 {
+			return float64(db.Stats().InUse)
+		}),
+		NewGaugeFunc("db_idle_connections", "Number of idle connections.", "gauge", func() float64 {
+			return float64(db.Stats().Idle)
+		}),
+		NewGaugeFunc("db_wait_count_total", "Number of connections waited for.", "counter", func() float64 {
+			return float64(db.Stats().WaitCount)
+		}),
+		NewGaugeFunc("db_wait_duration_seconds_total", "Time blocked waiting for a connection.", "counter", func() float64 {
+			return db.Stats().WaitDuration.Seconds()
+		}),
+	}
+}
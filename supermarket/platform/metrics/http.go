@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// NewHTTP returns a new HTTP with its families not yet registered.
+func NewHTTP() *HTTP {
+	return &HTTP{
+		Requests: NewCounterVec("http_requests_total", "Number of HTTP requests served.", "method", "route", "code"),
+		Duration: NewHistogramVec("http_request_duration_seconds", "Latency of the HTTP requests served.", DefBuckets, "method", "route", "code"),
+	}
+}
+
+// HTTP measures the requests served by a chi router, by route pattern rather than path
+// so that the number of series does not grow with the ids requested.
+type HTTP struct {
+	// Requests counts the requests served.
+	Requests *CounterVec
+	// Duration observes the latency of the requests served.
+	Duration *HistogramVec
+}
+
+// Collectors returns the families to register.
+func (m *HTTP) Collectors() []Collector {
+	return []Collector{m.Requests, m.Duration}
+}
+
+// Middleware measures each request once it is served.
+func (m *HTTP) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		// the route pattern is only known once the router matched the request
+		route := "unmatched"
+		if rc := chi.RouteContext(r.Context()); rc != nil && rc.RoutePattern() != "" {
+			route = rc.RoutePattern()
+		}
+		code := ww.Status()
+		if code == 0 {
+			code = http.StatusOK
+		}
+		lvs := []string{r.Method, route, strconv.Itoa(code)}
+		m.Requests.Inc(lvs...)
+		m.Duration.Observe(time.Since(start).Seconds(), lvs...)
+	})
+}
@@ -0,0 +1,330 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the media type of the Prometheus text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefBuckets are the default buckets of a latency histogram, in seconds.
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Collector is a metric family that can be written in the Prometheus text exposition format.
+type Collector interface {
+	// Write writes the family, with its HELP and TYPE lines.
+	Write(w io.Writer) (err error)
+}
+
+// NewRegistry returns a new empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Registry is a set of collectors exposed together.
+type Registry struct {
+	// mu guards collectors.
+	mu sync.Mutex
+	// collectors are the registered collectors, in registration order.
+	collectors []Collector
+}
+
+// Register adds the collectors to the registry.
+func (r *Registry) Register(cs ...Collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, cs...)
+}
+
+// Write writes every collector in the Prometheus text exposition format.
+func (r *Registry) Write(w io.Writer) (err error) {
+	r.mu.Lock()
+	cs := append([]Collector(nil), r.collectors...)
+	r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, c := range cs {
+		err = c.Write(bw)
+		if err != nil {
+			return
+		}
+	}
+	err = bw.Flush()
+	return
+}
+
+// Handler returns the handler that exposes the registry, for scrapers.
+func (r *Registry) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		w.WriteHeader(http.StatusOK)
+		_ = r.Write(w)
+	}
+}
+
+// vec holds the values of a metric family by label values.
+type vec[T any] struct {
+	// name is the name of the family.
+	name string
+	// help is the description of the family.
+	help string
+	// labels are the label names.
+	labels []string
+	// mu guards values.
+	mu sync.Mutex
+	// values are the values by label values joined by a separator.
+	values map[string]*T
+	// new returns a zero value.
+	new func() *T
+}
+
+// with returns the value of the label values, creating it if needed, with the lock held.
+func (v *vec[T]) with(lvs []string) *T {
+	if len(lvs) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", v.name, len(v.labels), len(lvs)))
+	}
+	key := strings.Join(lvs, "\xff")
+	t, ok := v.values[key]
+	if !ok {
+		t = v.new()
+		v.values[key] = t
+	}
+	return t
+}
+
+// sorted returns the label values of every value, sorted so that the output is stable.
+func (v *vec[T]) sorted() (keys []string) {
+	for k := range v.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return
+}
+
+// header writes the HELP and TYPE lines of the family.
+func (v *vec[T]) header(w io.Writer, typ string) (err error) {
+	_, err = fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", v.name, escapeHelp(v.help), v.name, typ)
+	return
+}
+
+// NewCounterVec returns a new counter family partitioned by labels.
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{vec[float64]{
+		name:   name,
+		help:   help,
+		labels: labels,
+		values: make(map[string]*float64),
+		new:    func() *float64 { return new(float64) },
+	}}
+}
+
+// CounterVec is a family of counters, values that only go up.
+type CounterVec struct {
+	vec[float64]
+}
+
+// Inc adds one to the counter of the label values.
+func (c *CounterVec) Inc(lvs ...string) {
+	c.Add(1, lvs...)
+}
+
+// Add adds v, which must not be negative, to the counter of the label values.
+func (c *CounterVec) Add(v float64, lvs ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	*c.with(lvs) += v
+}
+
+// Write writes the family in the Prometheus text exposition format.
+func (c *CounterVec) Write(w io.Writer) (err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	err = c.header(w, "counter")
+	if err != nil {
+		return
+	}
+	for _, k := range c.sorted() {
+		err = sample(w, c.name, c.labels, c.split(k), *c.values[k])
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// histogram is the value of a histogram.
+type histogram struct {
+	// counts are the observations per bucket, not cumulative.
+	counts []uint64
+	// count is the number of observations.
+	count uint64
+	// sum is the sum of the observations.
+	sum float64
+}
+
+// NewHistogramVec returns a new histogram family partitioned by labels, with the given upper bounds.
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	return &HistogramVec{
+		vec: vec[histogram]{
+			name:   name,
+			help:   help,
+			labels: labels,
+			values: make(map[string]*histogram),
+			new:    func() *histogram { return &histogram{counts: make([]uint64, len(buckets))} },
+		},
+		buckets: buckets,
+	}
+}
+
+// HistogramVec is a family of histograms, observations counted in buckets.
+type HistogramVec struct {
+	vec[histogram]
+	// buckets are the upper bounds of the buckets, sorted, without +Inf.
+	buckets []float64
+}
+
+// Observe adds v to the histogram of the label values.
+func (h *HistogramVec) Observe(v float64, lvs ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	hs := h.with(lvs)
+	ix := sort.SearchFloat64s(h.buckets, v)
+	if ix < len(h.buckets) {
+		hs.counts[ix]++
+	}
+	hs.count++
+	hs.sum += v
+}
+
+// Write writes the family in the Prometheus text exposition format.
+func (h *HistogramVec) Write(w io.Writer) (err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	err = h.header(w, "histogram")
+	if err != nil {
+		return
+	}
+	labels := append(append([]string(nil), h.labels...), "le")
+	for _, k := range h.sorted() {
+		lvs := h.split(k)
+		hs := h.values[k]
+
+		// buckets, cumulative
+		var cumulative uint64
+		for ix, b := range h.buckets {
+			cumulative += hs.counts[ix]
+			err = sample(w, h.name+"_bucket", labels, append(lvs, formatFloat(b)), float64(cumulative))
+			if err != nil {
+				return
+			}
+		}
+		err = sample(w, h.name+"_bucket", labels, append(lvs, "+Inf"), float64(hs.count))
+		if err != nil {
+			return
+		}
+
+		// sum and count
+		err = sample(w, h.name+"_sum", h.labels, lvs, hs.sum)
+		if err != nil {
+			return
+		}
+		err = sample(w, h.name+"_count", h.labels, lvs, float64(hs.count))
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// NewGaugeFunc returns a new gauge whose value is read from fn when written.
+// typ is the metric type, "gauge" or "counter" for values that only go up.
+func NewGaugeFunc(name, help, typ string, fn func() float64) *GaugeFunc {
+	return &GaugeFunc{name: name, help: help, typ: typ, fn: fn}
+}
+
+// GaugeFunc is a metric whose value is read when written.
+type GaugeFunc struct {
+	// name is the name of the metric.
+	name string
+	// help is the description of the metric.
+	help string
+	// typ is the metric type.
+	typ string
+	// fn returns the value.
+	fn func() float64
+}
+
+// Write writes the metric in the Prometheus text exposition format.
+func (g *GaugeFunc) Write(w io.Writer) (err error) {
+	_, err = fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", g.name, escapeHelp(g.help), g.name, g.typ)
+	if err != nil {
+		return
+	}
+	err = sample(w, g.name, nil, nil, g.fn())
+	return
+}
+
+// sample writes a sample line.
+func sample(w io.Writer, name string, labels, lvs []string, v float64) (err error) {
+	var sb strings.Builder
+	sb.WriteString(name)
+	if len(labels) > 0 {
+		sb.WriteByte('{')
+		for ix, l := range labels {
+			if ix > 0 {
+				sb.WriteByte(',')
+			}
+			sb.WriteString(l)
+			sb.WriteString(`="`)
+			sb.WriteString(escapeLabel(lvs[ix]))
+			sb.WriteByte('"')
+		}
+		sb.WriteByte('}')
+	}
+	sb.WriteByte(' ')
+	sb.WriteString(formatFloat(v))
+	sb.WriteByte('\n')
+	_, err = io.WriteString(w, sb.String())
+	return
+}
+
+// split returns the label values joined by with.
+func (v *vec[T]) split(k string) []string {
+	if len(v.labels) == 0 {
+		return nil
+	}
+	return strings.Split(k, "\xff")
+}
+
+// formatFloat formats a sample value.
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+// escapeLabel escapes a label value.
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// escapeHelp escapes a help text.
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
@@ -0,0 +1,280 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"supermarket/platform/auth"
+	"supermarket/platform/web/response"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Version is the version of the OpenAPI specification the documents follow.
+const Version = "3.0.3"
+
+// Schema is a JSON schema. A Schema given as a body is used as is instead of being derived by reflection.
+type Schema map[string]any
+
+// Param is a query parameter of an operation.
+type Param struct {
+	// Name is the name of the parameter.
+	Name string
+	// Description describes the parameter.
+	Description string
+	// Type is the JSON type of the parameter, string by default.
+	Type string
+	// In is where the parameter is, query by default, or header.
+	In string
+}
+
+// Response is a response of an operation. Responses of an operation with the same status are alternative content types.
+type Response struct {
+	// Status is the status code of the response.
+	Status int
+	// Description describes the response, the status text by default.
+	Description string
+	// ContentType is the media type of the body, application/json by default.
+	ContentType string
+	// Body is a value of the type of the body, nil for no body.
+	Body any
+}
+
+// Operation is an operation of the API, i.e. a method on a path.
+type Operation struct {
+	// Method is the HTTP method.
+	Method string
+	// Path is the route pattern, with chi {param} placeholders.
+	Path string
+	// Summary describes the operation in a line.
+	Summary string
+	// Tags group the operations.
+	Tags []string
+	// Role is the role required to call the operation, none for public operations.
+	Role auth.Role
+	// Params are the query parameters, path parameters are derived from Path.
+	Params []Param
+	// Body is a value of the type of the JSON request body, nil for no body.
+	Body any
+	// Responses are the responses of the operation.
+	Responses []Response
+}
+
+// New creates a new Document.
+func New(title, version string) (d *Document) {
+	d = &Document{
+		title:   title,
+		version: version,
+	}
+	return
+}
+
+// Document is an OpenAPI document built from the operations added to it.
+type Document struct {
+	// title is the title of the API.
+	title string
+	// version is the version of the API.
+	version string
+	// ops are the operations.
+	ops []Operation
+}
+
+// Add adds operations to the document.
+func (d *Document) Add(ops ...Operation) {
+	d.ops = append(d.ops, ops...)
+}
+
+// Build returns the OpenAPI document as a JSON value.
+// If routes is not nil, only the operations registered in routes are described.
+func (d *Document) Build(routes chi.Routes) (doc map[string]any, err error) {
+	var registered map[string]bool
+	if routes != nil {
+		registered, err = walk(routes)
+		if err != nil {
+			return
+		}
+	}
+
+	r := newReflector()
+	paths := make(map[string]map[string]any)
+	secured := false
+	for _, op := range d.ops {
+		path := normalize(op.Path)
+		if registered != nil && !registered[key(op.Method, path)] {
+			continue
+		}
+		if paths[path] == nil {
+			paths[path] = make(map[string]any)
+		}
+		paths[path][strings.ToLower(op.Method)] = r.operation(op)
+		secured = secured || op.Role != auth.RoleNone
+	}
+
+	doc = map[string]any{
+		"openapi": Version,
+		"info":    map[string]any{"title": d.title, "version": d.version},
+		"paths":   paths,
+	}
+	components := map[string]any{}
+	if len(r.schemas) > 0 {
+		components["schemas"] = r.schemas
+	}
+	if secured {
+		components["securitySchemes"] = map[string]any{
+			"ApiKey": map[string]any{"type": "apiKey", "in": "header", "name": auth.HeaderAPIKey},
+			"Bearer": map[string]any{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+		}
+	}
+	if len(components) > 0 {
+		doc["components"] = components
+	}
+	return
+}
+
+// Handler serves the document, describing the operations registered in routes.
+// It is built on the first request, once every route is registered.
+func (d *Document) Handler(routes chi.Routes) http.HandlerFunc {
+	var (
+		once sync.Once
+		doc  map[string]any
+		err  error
+	)
+	return func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() { doc, err = d.Build(routes) })
+		if err != nil {
+			response.Error(w, http.StatusInternalServerError, "error building the openapi document")
+			return
+		}
+		response.JSONConditional(w, r, http.StatusOK, doc, time.Time{})
+	}
+}
+
+// Missing returns the routes registered in routes that the document does not describe, as "METHOD /path", sorted.
+func (d *Document) Missing(routes chi.Routes) (missing []string, err error) {
+	registered, err := walk(routes)
+	if err != nil {
+		return
+	}
+	described := make(map[string]bool, len(d.ops))
+	for _, op := range d.ops {
+		described[key(op.Method, normalize(op.Path))] = true
+	}
+	for k := range registered {
+		if !described[k] {
+			missing = append(missing, k)
+		}
+	}
+	sort.Strings(missing)
+	return
+}
+
+// walk returns the routes registered in routes by key.
+func walk(routes chi.Routes) (registered map[string]bool, err error) {
+	registered = make(map[string]bool)
+	err = chi.Walk(routes, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		registered[key(method, normalize(route))] = true
+		return nil
+	})
+	return
+}
+
+// key returns the key of a route.
+func key(method, path string) string {
+	return strings.ToUpper(method) + " " + path
+}
+
+// normalize removes the trailing slash chi leaves on the root of a sub router, e.g. /customers/.
+func normalize(path string) string {
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+	return path
+}
+
+// pathParam matches the placeholders of a route pattern, optionally with a regexp.
+var pathParam = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+
+// operation returns the JSON value of op.
+func (r *reflector) operation(op Operation) map[string]any {
+	o := map[string]any{}
+	if op.Summary != "" {
+		o["summary"] = op.Summary
+	}
+	if len(op.Tags) > 0 {
+		o["tags"] = op.Tags
+	}
+
+	// parameters
+	var params []any
+	for _, m := range pathParam.FindAllStringSubmatch(op.Path, -1) {
+		params = append(params, map[string]any{
+			"name": m[1], "in": "path", "required": true, "schema": Schema{"type": "integer"},
+		})
+	}
+	for _, p := range op.Params {
+		typ := p.Type
+		if typ == "" {
+			typ = "string"
+		}
+		in := p.In
+		if in == "" {
+			in = "query"
+		}
+		param := map[string]any{"name": p.Name, "in": in, "schema": Schema{"type": typ}}
+		if p.Description != "" {
+			param["description"] = p.Description
+		}
+		params = append(params, param)
+	}
+	if len(params) > 0 {
+		o["parameters"] = params
+	}
+
+	// request body
+	if op.Body != nil {
+		o["requestBody"] = map[string]any{
+			"required": true,
+			"content":  map[string]any{"application/json": map[string]any{"schema": r.schemaOfValue(op.Body)}},
+		}
+	}
+
+	// responses
+	responses := map[string]any{}
+	for _, rs := range op.Responses {
+		desc := rs.Description
+		if desc == "" {
+			desc = http.StatusText(rs.Status)
+		}
+		// responses of the same status are alternative representations, merged into its content
+		v, ok := responses[strconv.Itoa(rs.Status)].(map[string]any)
+		if !ok {
+			v = map[string]any{"description": desc}
+			responses[strconv.Itoa(rs.Status)] = v
+		}
+		if rs.Body != nil {
+			ct := rs.ContentType
+			if ct == "" {
+				ct = "application/json"
+			}
+			content, _ := v["content"].(map[string]any)
+			if content == nil {
+				content = map[string]any{}
+				v["content"] = content
+			}
+			content[ct] = map[string]any{"schema": r.schemaOfValue(rs.Body)}
+		}
+	}
+	o["responses"] = responses
+
+	// security
+	if op.Role != auth.RoleNone {
+		o["security"] = []any{map[string]any{"ApiKey": []string{}}, map[string]any{"Bearer": []string{}}}
+		o["description"] = fmt.Sprintf("Requires the %s role or above.", op.Role)
+	}
+	return o
+}
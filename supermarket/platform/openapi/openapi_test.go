@@ -0,0 +1,207 @@
+package openapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"supermarket/platform/auth"
+	"supermarket/platform/openapi"
+	"supermarket/platform/web/request"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+type itemJSON struct {
+	Id      int       `json:"id"`
+	Name    string    `json:"name,omitempty"`
+	Tags    []string  `json:"tags"`
+	Parent  *itemJSON `json:"parent,omitempty"`
+	Created time.Time `json:"created"`
+	secret  string
+}
+
+type detailJSON struct {
+	itemJSON
+	Score float64 `json:"score"`
+}
+
+type envelope[T any] struct {
+	Data T `json:"data"`
+}
+
+// newDocument returns a document describing GET /items and GET /items/{id}.
+func newDocument() *openapi.Document {
+	d := openapi.New("test", "1.0.0")
+	d.Add(
+		openapi.Operation{Method: http.MethodGet, Path: "/items", Summary: "List the items.", Role: auth.RoleViewer,
+			Params:    []openapi.Param{{Name: "limit", Type: "integer"}},
+			Responses: []openapi.Response{{Status: http.StatusOK, Body: envelope[[]itemJSON]{}}}},
+		openapi.Operation{Method: http.MethodGet, Path: "/items/{id}", Params: []openapi.Param{{Name: "X-Trace", In: "header"}},
+			Responses: []openapi.Response{{Status: http.StatusOK, Body: detailJSON{}}, {Status: http.StatusNotFound}}},
+	)
+	return d
+}
+
+// Tests for Document.Build
+func TestDocumentBuild(t *testing.T) {
+	t.Run("derives the schemas from the json tags", func(t *testing.T) {
+		// act
+		doc, err := newDocument().Build(nil)
+		require.NoError(t, err)
+		b, err := json.Marshal(doc)
+		require.NoError(t, err)
+
+		// assert
+		var got struct {
+			Paths      map[string]map[string]json.RawMessage `json:"paths"`
+			Components struct {
+				Schemas         map[string]json.RawMessage `json:"schemas"`
+				SecuritySchemes map[string]json.RawMessage `json:"securitySchemes"`
+			} `json:"components"`
+		}
+		require.NoError(t, json.Unmarshal(b, &got))
+		require.JSONEq(t, `{
+			"type": "object",
+			"properties": {
+				"id": {"type": "integer"},
+				"name": {"type": "string"},
+				"tags": {"type": "array", "items": {"type": "string"}},
+				"parent": {"allOf": [{"$ref": "#/components/schemas/itemJSON"}], "nullable": true},
+				"created": {"type": "string", "format": "date-time"}
+			},
+			"required": ["id", "tags", "created"]
+		}`, string(got.Components.Schemas["itemJSON"]))
+		require.JSONEq(t, `{
+			"type": "object",
+			"properties": {
+				"id": {"type": "integer"},
+				"name": {"type": "string"},
+				"tags": {"type": "array", "items": {"type": "string"}},
+				"parent": {"allOf": [{"$ref": "#/components/schemas/itemJSON"}], "nullable": true},
+				"created": {"type": "string", "format": "date-time"},
+				"score": {"type": "number"}
+			},
+			"required": ["id", "tags", "created", "score"]
+		}`, string(got.Components.Schemas["detailJSON"]))
+		require.JSONEq(t, `{
+			"summary": "List the items.",
+			"parameters": [{"name": "limit", "in": "query", "schema": {"type": "integer"}}],
+			"responses": {"200": {"description": "OK", "content": {"application/json": {"schema": {
+				"type": "object",
+				"properties": {"data": {"type": "array", "items": {"$ref": "#/components/schemas/itemJSON"}}},
+				"required": ["data"]
+			}}}}},
+			"security": [{"ApiKey": []}, {"Bearer": []}],
+			"description": "Requires the viewer role or above."
+		}`, string(got.Paths["/items"]["get"]))
+		require.Contains(t, string(got.Paths["/items/{id}"]["get"]), `"in":"path"`)
+		require.Contains(t, string(got.Paths["/items/{id}"]["get"]), `{"in":"header","name":"X-Trace","schema":{"type":"string"}}`)
+		require.Len(t, got.Components.SecuritySchemes, 2)
+	})
+
+	t.Run("constrains the schemas with the validate tags", func(t *testing.T) {
+		// arrange
+		type bodyJSON struct {
+			Name      string             `json:"name" validate:"required,max=45"`
+			Condition request.Field[int] `json:"condition" validate:"required,oneof=0 1"`
+			Date      string             `json:"date" validate:"required,date"`
+			Lines     []string           `json:"lines" validate:"min=1"`
+		}
+		d := openapi.New("test", "1.0.0")
+		d.Add(openapi.Operation{Method: http.MethodPost, Path: "/items", Body: bodyJSON{}})
+
+		// act
+		doc, err := d.Build(nil)
+		require.NoError(t, err)
+		b, err := json.Marshal(doc["components"])
+		require.NoError(t, err)
+
+		// assert
+		require.JSONEq(t, `{"schemas": {"bodyJSON": {
+			"type": "object",
+			"properties": {
+				"name": {"type": "string", "maxLength": 45},
+				"condition": {"type": "integer", "enum": [0, 1]},
+				"date": {"type": "string", "format": "date"},
+				"lines": {"type": "array", "items": {"type": "string"}, "minItems": 1}
+			},
+			"required": ["name", "condition", "date", "lines"]
+		}}}`, string(b))
+	})
+
+	t.Run("merges the content types of the responses of a status", func(t *testing.T) {
+		// arrange
+		d := openapi.New("test", "1.0.0")
+		d.Add(openapi.Operation{Method: http.MethodGet, Path: "/items", Responses: []openapi.Response{
+			{Status: http.StatusOK, Body: []int{}},
+			{Status: http.StatusOK, ContentType: "text/csv", Body: openapi.Schema{"type": "string"}},
+		}})
+
+		// act
+		doc, err := d.Build(nil)
+		require.NoError(t, err)
+		b, err := json.Marshal(doc["paths"])
+		require.NoError(t, err)
+
+		// assert
+		require.JSONEq(t, `{"/items": {"get": {"responses": {"200": {"description": "OK", "content": {
+			"application/json": {"schema": {"type": "array", "items": {"type": "integer"}}},
+			"text/csv": {"schema": {"type": "string"}}
+		}}}}}}`, string(b))
+	})
+
+	t.Run("describes only the registered routes", func(t *testing.T) {
+		// arrange
+		rt := chi.NewRouter()
+		rt.Route("/items", func(r chi.Router) {
+			r.Get("/", func(w http.ResponseWriter, r *http.Request) {})
+		})
+
+		// act
+		doc, err := newDocument().Build(rt)
+
+		// assert
+		require.NoError(t, err)
+		require.Len(t, doc["paths"], 1)
+		require.Contains(t, doc["paths"], "/items")
+	})
+}
+
+// Tests for Document.Missing
+func TestDocumentMissing(t *testing.T) {
+	// arrange
+	rt := chi.NewRouter()
+	rt.Get("/items", func(w http.ResponseWriter, r *http.Request) {})
+	rt.Get("/items/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	rt.Delete("/items/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	// act
+	missing, err := newDocument().Missing(rt)
+
+	// assert
+	require.NoError(t, err)
+	require.Equal(t, []string{"DELETE /items/{id}"}, missing)
+}
+
+// Tests for Document.Handler
+func TestDocumentHandler(t *testing.T) {
+	// arrange
+	rt := chi.NewRouter()
+	d := newDocument()
+	rt.Get("/openapi.json", d.Handler(rt))
+	rt.Get("/items", func(w http.ResponseWriter, r *http.Request) {})
+
+	// act
+	res := httptest.NewRecorder()
+	rt.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	// assert
+	require.Equal(t, http.StatusOK, res.Code)
+	require.Equal(t, "application/json", res.Header().Get("Content-Type"))
+	require.NotEmpty(t, res.Header().Get("ETag"))
+	require.Contains(t, res.Body.String(), `"openapi":"3.0.3"`)
+	require.Contains(t, res.Body.String(), `"/items"`)
+}
@@ -0,0 +1,169 @@
+package openapi
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// newReflector creates a new reflector.
+func newReflector() *reflector {
+	return &reflector{schemas: make(map[string]Schema)}
+}
+
+// reflector derives the schemas of Go types from their json tags.
+// Named struct types are added to the components and referenced, so that each is described once.
+type reflector struct {
+	// schemas are the component schemas by type name.
+	schemas map[string]Schema
+}
+
+// timeType is the type of time.Time, described as a date-time string rather than a struct.
+var timeType = reflect.TypeOf(time.Time{})
+
+// optionalType is the type of the fields described as their Value, e.g. request.Field, see validate.Optional.
+var optionalType = reflect.TypeOf((*interface{ Optional() (any, bool) })(nil)).Elem()
+
+// schemaOfValue returns the schema of the type of v, or v itself if it is a Schema.
+func (r *reflector) schemaOfValue(v any) Schema {
+	if s, ok := v.(Schema); ok {
+		return s
+	}
+	return r.schema(reflect.TypeOf(v))
+}
+
+// schema returns the schema of t.
+func (r *reflector) schema(t reflect.Type) Schema {
+	switch t.Kind() {
+	case reflect.Pointer:
+		s := Schema{}
+		for k, v := range r.schema(t.Elem()) {
+			s[k] = v
+		}
+		if _, ok := s["$ref"]; ok {
+			return Schema{"allOf": []any{s}, "nullable": true}
+		}
+		s["nullable"] = true
+		return s
+	case reflect.Bool:
+		return Schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return Schema{"type": "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return Schema{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return Schema{"type": "number"}
+	case reflect.String:
+		return Schema{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return Schema{"type": "string", "format": "byte"}
+		}
+		return Schema{"type": "array", "items": r.schema(t.Elem())}
+	case reflect.Map:
+		return Schema{"type": "object", "additionalProperties": r.schema(t.Elem())}
+	case reflect.Struct:
+		if t == timeType {
+			return Schema{"type": "string", "format": "date-time"}
+		}
+		if v, ok := t.FieldByName("Value"); ok && t.Implements(optionalType) {
+			return r.schema(v.Type)
+		}
+		// generic and anonymous types are described inline, as their names are not valid component names
+		name := t.Name()
+		if name == "" || strings.Contains(name, "[") {
+			return r.object(t)
+		}
+		if _, ok := r.schemas[name]; !ok {
+			r.schemas[name] = Schema{} // placeholder, for recursive types
+			r.schemas[name] = r.object(t)
+		}
+		return Schema{"$ref": "#/components/schemas/" + name}
+	default:
+		return Schema{}
+	}
+}
+
+// object returns the schema of the struct t, a field being required unless its tag has omitempty.
+// Embedded structs without a json name are flattened, as encoding/json does.
+func (r *reflector) object(t reflect.Type) Schema {
+	props := Schema{}
+	var required []string
+	var walk func(t reflect.Type)
+	walk = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag := f.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name, opts, _ := strings.Cut(tag, ",")
+			if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+				walk(f.Type)
+				continue
+			}
+			if !f.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			props[name] = r.schema(f.Type)
+			constrain(props[name].(Schema), f.Tag.Get("validate"))
+			if !strings.Contains(opts, "omitempty") {
+				required = append(required, name)
+			}
+		}
+	}
+	walk(t)
+
+	s := Schema{"type": "object", "properties": props}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+// constrain adds the rules of a validate tag, see package validate, to the schema s of a field.
+func constrain(s Schema, tag string) {
+	if tag == "" || s["$ref"] != nil || s["allOf"] != nil {
+		return
+	}
+	for _, rule := range strings.Split(tag, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		switch name {
+		case "min", "max", "len":
+			n, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				continue
+			}
+			var keys []string
+			switch s["type"] {
+			case "integer", "number":
+				keys = map[string][]string{"min": {"minimum"}, "max": {"maximum"}}[name]
+			case "string":
+				keys = map[string][]string{"min": {"minLength"}, "max": {"maxLength"}, "len": {"minLength", "maxLength"}}[name]
+			case "array":
+				keys = map[string][]string{"min": {"minItems"}, "max": {"maxItems"}, "len": {"minItems", "maxItems"}}[name]
+			}
+			for _, k := range keys {
+				s[k] = n
+			}
+		case "oneof":
+			var enum []any
+			for _, v := range strings.Fields(arg) {
+				if n, err := strconv.ParseFloat(v, 64); err == nil && (s["type"] == "integer" || s["type"] == "number") {
+					enum = append(enum, n)
+					continue
+				}
+				enum = append(enum, v)
+			}
+			s["enum"] = enum
+		case "date":
+			s["format"] = "date"
+		case "datetime":
+			s["pattern"] = `^\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}$`
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"supermarket/platform/auth"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newTestLimiter returns a limiter whose clock is advanced by the returned function.
+func newTestLimiter(l Limit) (lm *Limiter, advance func(time.Duration)) {
+	now := time.Unix(1700000000, 0)
+	lm = NewLimiter(l)
+	lm.now = func() time.Time { return now }
+	advance = func(d time.Duration) { now = now.Add(d) }
+	return
+}
+
+// Tests for Limiter.Allow
+func TestLimiterAllow(t *testing.T) {
+	t.Run("allows a burst then refills at the rate", func(t *testing.T) {
+		// arrange
+		lm, advance := newTestLimiter(Limit{Rate: 2, Burst: 3})
+
+		// act & assert
+		for i := 2; i >= 0; i-- {
+			r := lm.Allow("a")
+			require.True(t, r.Allowed)
+			require.Equal(t, i, r.Remaining)
+		}
+		r := lm.Allow("a")
+		require.False(t, r.Allowed)
+		require.Equal(t, 500*time.Millisecond, r.RetryAfter)
+		require.Equal(t, 1500*time.Millisecond, r.Reset)
+
+		advance(500 * time.Millisecond)
+		require.True(t, lm.Allow("a").Allowed)
+		require.False(t, lm.Allow("a").Allowed)
+	})
+
+	t.Run("keeps a bucket per key", func(t *testing.T) {
+		// arrange
+		lm, _ := newTestLimiter(Limit{Rate: 1, Burst: 1})
+
+		// act & assert
+		require.True(t, lm.Allow("a").Allowed)
+		require.False(t, lm.Allow("a").Allowed)
+		require.True(t, lm.Allow("b").Allowed)
+	})
+
+	t.Run("forgets the buckets that refilled", func(t *testing.T) {
+		// arrange
+		lm, advance := newTestLimiter(Limit{Rate: 1, Burst: 2})
+		lm.Allow("a")
+		lm.Allow("b")
+
+		// act
+		advance(2 * time.Second)
+		lm.Allow("c")
+
+		// assert
+		require.Len(t, lm.buckets, 1)
+	})
+
+	t.Run("is safe for concurrent use", func(t *testing.T) {
+		// arrange
+		lm, _ := newTestLimiter(Limit{Rate: 1, Burst: 50})
+		var mu sync.Mutex
+		allowed := 0
+
+		// act
+		var wg sync.WaitGroup
+		for i := 0; i < 100; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if lm.Allow("a").Allowed {
+					mu.Lock()
+					allowed++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		// assert
+		require.Equal(t, 50, allowed)
+	})
+}
+
+// Tests for Middleware
+func TestMiddleware(t *testing.T) {
+	t.Run("responds 429 with the rate limit headers", func(t *testing.T) {
+		// arrange
+		lm, _ := newTestLimiter(Limit{Rate: 0.5, Burst: 1})
+		hd := Middleware(lm, ClientKey)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+		// act
+		first := httptest.NewRecorder()
+		hd.ServeHTTP(first, req)
+		second := httptest.NewRecorder()
+		hd.ServeHTTP(second, req)
+
+		// assert
+		require.Equal(t, http.StatusOK, first.Code)
+		require.Equal(t, "1", first.Header().Get("RateLimit-Limit"))
+		require.Equal(t, "0", first.Header().Get("RateLimit-Remaining"))
+		require.Equal(t, "2", first.Header().Get("RateLimit-Reset"))
+		require.Equal(t, http.StatusTooManyRequests, second.Code)
+		require.Equal(t, "2", second.Header().Get("Retry-After"))
+	})
+
+	t.Run("keys by principal before client ip", func(t *testing.T) {
+		// arrange
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		authed := req.WithContext(auth.WithPrincipal(req.Context(), auth.Principal{Subject: "ops", Role: auth.RoleAdmin}))
+
+		// act & assert
+		require.Equal(t, "ip:10.0.0.1", ClientKey(req))
+		require.Equal(t, "principal:ops", ClientKey(authed))
+	})
+}
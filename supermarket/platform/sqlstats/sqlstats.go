@@ -0,0 +1,236 @@
+package sqlstats
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config is the configuration for Open.
+type Config struct {
+	// SlowThreshold is the duration above which a statement is logged with its EXPLAIN plan.
+	// Zero disables the slow statement log.
+	SlowThreshold time.Duration
+	// ExplainTimeout bounds the EXPLAIN of a slow statement.
+	ExplainTimeout time.Duration
+	// MaxExplains is the maximum number of EXPLAIN running at once, 4 by default.
+	// The slow statements beyond it are logged without their plan.
+	MaxExplains int
+	// Logger is the logger of the slow statements.
+	Logger *slog.Logger
+}
+
+// Open returns a database handle whose statements are recorded by the returned Stats,
+// normalised so that statements differing only in their literals are grouped together.
+//
+//	cn, err := mysql.NewConnector(cfg)
+//	db, st := sqlstats.Open(cn, sqlstats.Config{SlowThreshold: 500 * time.Millisecond, Logger: lg})
+func Open(c driver.Connector, cfg Config) (db *sql.DB, st *Stats) {
+	if cfg.ExplainTimeout <= 0 {
+		cfg.ExplainTimeout = 5 * time.Second
+	}
+	if cfg.MaxExplains <= 0 {
+		cfg.MaxExplains = 4
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+	st = &Stats{cfg: cfg, queries: make(map[string]*QueryStats), explains: make(chan struct{}, cfg.MaxExplains)}
+	db = sql.OpenDB(&connector{c: c, st: st})
+	st.db = db
+	return
+}
+
+// QueryStats are the statistics of a normalised statement.
+type QueryStats struct {
+	// Query is the normalised text of the statement.
+	Query string
+	// Calls is the number of times the statement ran.
+	Calls int64
+	// Errors is the number of times the statement failed.
+	Errors int64
+	// Rows is the number of rows read or affected.
+	Rows int64
+	// Total is the time spent running the statement.
+	Total time.Duration
+	// Max is the longest run of the statement.
+	Max time.Duration
+}
+
+// Mean returns the mean duration of a run of the statement.
+func (q QueryStats) Mean() time.Duration {
+	if q.Calls == 0 {
+		return 0
+	}
+	return q.Total / time.Duration(q.Calls)
+}
+
+// Stats records the statements run through a handle returned by Open.
+type Stats struct {
+	// cfg is the configuration.
+	cfg Config
+	// db is the handle the EXPLAIN of slow statements are run with.
+	db *sql.DB
+	// mu guards queries.
+	mu sync.Mutex
+	// queries are the statistics by normalised statement.
+	queries map[string]*QueryStats
+	// explains holds a token per EXPLAIN running, up to Config.MaxExplains.
+	explains chan struct{}
+}
+
+// Top returns the statistics of the n statements with the most total time, all of them if n is not positive.
+func (s *Stats) Top(n int) (q []QueryStats) {
+	s.mu.Lock()
+	q = make([]QueryStats, 0, len(s.queries))
+	for _, v := range s.queries {
+		q = append(q, *v)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(q, func(i, j int) bool {
+		if q[i].Total != q[j].Total {
+			return q[i].Total > q[j].Total
+		}
+		return q[i].Query < q[j].Query
+	})
+	if n > 0 && len(q) > n {
+		q = q[:n]
+	}
+	return
+}
+
+// Reset forgets the recorded statistics.
+func (s *Stats) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queries = make(map[string]*QueryStats)
+}
+
+// record records a run of a statement, logging it if it is slow.
+func (s *Stats) record(ctx context.Context, query string, args []driver.NamedValue, d time.Duration, rows int64, err error) {
+	norm := Normalize(query)
+
+	s.mu.Lock()
+	q, ok := s.queries[norm]
+	if !ok {
+		q = &QueryStats{Query: norm}
+		s.queries[norm] = q
+	}
+	q.Calls++
+	q.Rows += rows
+	q.Total += d
+	q.Max = max(q.Max, d)
+	if err != nil {
+		q.Errors++
+	}
+	s.mu.Unlock()
+
+	if s.cfg.SlowThreshold <= 0 || d < s.cfg.SlowThreshold {
+		return
+	}
+	// the plan is read on another connection, without holding the caller,
+	// unless too many are being read already, e.g. when the database is overloaded
+	select {
+	case s.explains <- struct{}{}:
+	default:
+		s.cfg.Logger.WarnContext(ctx, "slow query", "query", norm, "duration_ms", d.Milliseconds(), "rows", rows, "explain_skipped", true)
+		return
+	}
+	values := make([]any, len(args))
+	for ix, v := range args {
+		values[ix] = v.Value
+	}
+	go func() {
+		defer func() { <-s.explains }()
+		s.explain(context.WithoutCancel(ctx), query, norm, values, d, rows)
+	}()
+}
+
+// explainKey marks the context of the EXPLAIN statements, so that they are not recorded.
+type explainKey struct{}
+
+// explain logs a slow statement with its EXPLAIN plan.
+func (s *Stats) explain(ctx context.Context, query, norm string, args []any, d time.Duration, rows int64) {
+	attrs := []any{"query", norm, "duration_ms", d.Milliseconds(), "rows", rows}
+
+	// the plan is read outside of the request, e.g. of its trace
+	plan, err := s.plan(context.Background(), query, args)
+	if err != nil {
+		attrs = append(attrs, "explain_error", err.Error())
+	} else {
+		attrs = append(attrs, "plan", plan)
+	}
+	s.cfg.Logger.WarnContext(ctx, "slow query", attrs...)
+}
+
+// plan returns the rows of the EXPLAIN of the statement, as column-value maps.
+func (s *Stats) plan(ctx context.Context, query string, args []any) (plan []map[string]string, err error) {
+	ctx, cancel := context.WithTimeout(context.WithValue(ctx, explainKey{}, true), s.cfg.ExplainTimeout)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, "EXPLAIN "+query, args...)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return
+	}
+	for rows.Next() {
+		values := make([]sql.NullString, len(cols))
+		dest := make([]any, len(cols))
+		for ix := range values {
+			dest[ix] = &values[ix]
+		}
+		err = rows.Scan(dest...)
+		if err != nil {
+			return
+		}
+		row := make(map[string]string, len(cols))
+		for ix, c := range cols {
+			if values[ix].Valid {
+				row[c] = values[ix].String
+			}
+		}
+		plan = append(plan, row)
+	}
+	err = rows.Err()
+	return
+}
+
+var (
+	// reString matches the string literals, with their escaped quotes.
+	reString = regexp.MustCompile(`'(?:[^'\\]|\\.|'')*'|"(?:[^"\\]|\\.|"")*"`)
+	// reNumber matches the number literals that are not part of an identifier.
+	reNumber = regexp.MustCompile(`\b\d+(?:\.\d+)?\b`)
+	// reList matches the lists of placeholders of IN, e.g. IN (?, ?, ?).
+	reList = regexp.MustCompile(`(?i)\bIN\s*\(\s*\?(?:\s*,\s*\?)+\s*\)`)
+	// reTuples matches repeated tuples, e.g. in VALUES (?, ?), (?, ?).
+	reTuples = regexp.MustCompile(`\(\?(?:, \?)*\)(?:\s*,\s*\(\?(?:, \?)*\))+`)
+	// reSpace matches runs of white space.
+	reSpace = regexp.MustCompile(`\s+`)
+)
+
+// Normalize returns the statement with its literals replaced by placeholders, lists of placeholders
+// and repeated tuples collapsed and white space collapsed, so that runs differing only in their
+// arguments are grouped together.
+func Normalize(query string) string {
+	q := reSpace.ReplaceAllString(strings.TrimSpace(query), " ")
+	q = reString.ReplaceAllString(q, "?")
+	q = reNumber.ReplaceAllString(q, "?")
+	q = reList.ReplaceAllString(q, "IN (?, ...)")
+	q = reTuples.ReplaceAllStringFunc(q, func(s string) string {
+		return fmt.Sprintf("%s, ...", s[:strings.Index(s, ")")+1])
+	})
+	return q
+}
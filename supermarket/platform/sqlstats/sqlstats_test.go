@@ -0,0 +1,162 @@
+package sqlstats_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"io"
+	"log/slog"
+	"strings"
+	"supermarket/platform/sqlstats"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for Normalize
+func TestNormalize(t *testing.T) {
+	t.Run("literals, lists and white space are collapsed", func(t *testing.T) {
+		// arrange
+		cases := map[string]string{
+			"SELECT id FROM customers WHERE id = 12":                             "SELECT id FROM customers WHERE id = ?",
+			"SELECT  id\n\tFROM t1 WHERE name = 'o''brien' AND price > 10.5":     "SELECT id FROM t1 WHERE name = ? AND price > ?",
+			"SELECT id FROM warehouses WHERE id IN (?, ?,?)":                     "SELECT id FROM warehouses WHERE id IN (?, ...)",
+			"INSERT INTO sales (`quantity`, `product_id`) VALUES (?, ?), (?, ?)": "INSERT INTO sales (`quantity`, `product_id`) VALUES (?, ?), ...",
+		}
+
+		for in, expected := range cases {
+			// act
+			out := sqlstats.Normalize(in)
+
+			// assert
+			require.Equal(t, expected, out)
+		}
+	})
+}
+
+// connectorStub is a driver returning 2 rows for every query and 3 affected rows for every statement,
+// each taking delay.
+type connectorStub struct{ delay time.Duration }
+
+func (c connectorStub) Connect(context.Context) (driver.Conn, error) { return connStub(c), nil }
+func (c connectorStub) Driver() driver.Driver                        { return nil }
+
+type connStub struct{ delay time.Duration }
+
+func (c connStub) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c connStub) Close() error                        { return nil }
+func (c connStub) Begin() (driver.Tx, error)           { return nil, driver.ErrSkip }
+func (c connStub) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	time.Sleep(c.delay)
+	return &rowsStub{n: 2}, nil
+}
+func (c connStub) ExecContext(context.Context, string, []driver.NamedValue) (driver.Result, error) {
+	time.Sleep(c.delay)
+	return driver.RowsAffected(3), nil
+}
+
+type rowsStub struct{ n int }
+
+func (r *rowsStub) Columns() []string { return []string{"id"} }
+func (r *rowsStub) Close() error      { return nil }
+func (r *rowsStub) Next(dest []driver.Value) error {
+	if r.n == 0 {
+		return io.EOF
+	}
+	dest[0] = int64(r.n)
+	r.n--
+	return nil
+}
+
+// syncBuffer is a buffer safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// Tests for Stats
+func TestStats(t *testing.T) {
+	t.Run("statements are grouped by normalised text and sorted by total time", func(t *testing.T) {
+		// arrange
+		db, st := sqlstats.Open(connectorStub{}, sqlstats.Config{})
+		defer db.Close()
+		ctx := context.Background()
+
+		// act
+		for _, id := range []int{1, 2} {
+			rows, err := db.QueryContext(ctx, "SELECT id FROM customers WHERE id = "+string(rune('0'+id)))
+			require.NoError(t, err)
+			for rows.Next() {
+			}
+			require.NoError(t, rows.Close())
+		}
+		_, err := db.ExecContext(ctx, "UPDATE invoices SET total = 0")
+		require.NoError(t, err)
+		top := st.Top(0)
+
+		// assert
+		require.Len(t, top, 2)
+		byQuery := map[string]sqlstats.QueryStats{top[0].Query: top[0], top[1].Query: top[1]}
+		require.Equal(t, int64(2), byQuery["SELECT id FROM customers WHERE id = ?"].Calls)
+		require.Equal(t, int64(4), byQuery["SELECT id FROM customers WHERE id = ?"].Rows)
+		require.Equal(t, int64(1), byQuery["UPDATE invoices SET total = ?"].Calls)
+		require.Equal(t, int64(3), byQuery["UPDATE invoices SET total = ?"].Rows)
+		require.GreaterOrEqual(t, top[0].Total, top[1].Total)
+	})
+
+	t.Run("slow statements are logged with their plan", func(t *testing.T) {
+		// arrange
+		var buf syncBuffer
+		lg := slog.New(slog.NewJSONHandler(&buf, nil))
+		db, st := sqlstats.Open(connectorStub{delay: 5 * time.Millisecond}, sqlstats.Config{SlowThreshold: time.Millisecond, Logger: lg})
+		defer db.Close()
+
+		// act
+		_, err := db.ExecContext(context.Background(), "UPDATE invoices SET total = 0")
+
+		// assert
+		require.NoError(t, err)
+		require.Eventually(t, func() bool { return strings.Contains(buf.String(), `"msg":"slow query"`) }, time.Second, time.Millisecond)
+		require.Contains(t, buf.String(), `"query":"UPDATE invoices SET total = ?"`)
+		require.Contains(t, buf.String(), `"plan":[{"id":"2"},{"id":"1"}]`)
+		// - the EXPLAIN is not recorded
+		require.Len(t, st.Top(0), 1)
+	})
+	t.Run("slow statements beyond the maximum of EXPLAIN are logged without their plan", func(t *testing.T) {
+		// arrange
+		var buf syncBuffer
+		lg := slog.New(slog.NewJSONHandler(&buf, nil))
+		db, _ := sqlstats.Open(connectorStub{delay: 50 * time.Millisecond}, sqlstats.Config{SlowThreshold: time.Millisecond, MaxExplains: 1, Logger: lg})
+		defer db.Close()
+
+		// act
+		var wg sync.WaitGroup
+		for i := 0; i < 3; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				db.ExecContext(context.Background(), "UPDATE invoices SET total = 0")
+			}()
+		}
+		wg.Wait()
+
+		// assert
+		require.Eventually(t, func() bool { return strings.Count(buf.String(), `"msg":"slow query"`) == 3 }, time.Second, time.Millisecond)
+		require.Equal(t, 1, strings.Count(buf.String(), `"plan":`))
+		require.Equal(t, 2, strings.Count(buf.String(), `"explain_skipped":true`))
+	})
+}
@@ -0,0 +1,46 @@
+package tracing
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// HeaderTraceparent is the W3C Trace Context header.
+const HeaderTraceparent = "traceparent"
+
+// Middleware starts a span for each request, continuing the trace of its traceparent header if valid,
+// and writes the traceparent of the span to the response so that clients can look the trace up.
+// The span is named after the route pattern once the router matched the request.
+func Middleware(t *Tracer) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			parent, _ := ParseTraceparent(r.Header.Get(HeaderTraceparent))
+			ctx, sp := t.Start(r.Context(), r.Method, parent)
+			defer sp.End()
+
+			w.Header().Set(HeaderTraceparent, sp.Context().Traceparent())
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			route := "unmatched"
+			if rc := chi.RouteContext(r.Context()); rc != nil && rc.RoutePattern() != "" {
+				route = rc.RoutePattern()
+			}
+			code := ww.Status()
+			if code == 0 {
+				code = http.StatusOK
+			}
+			sp.SetName(r.Method + " " + route)
+			sp.SetAttribute("http.method", r.Method)
+			sp.SetAttribute("http.route", route)
+			sp.SetAttribute("http.target", r.URL.RequestURI())
+			sp.SetAttribute("http.status_code", code)
+			if code >= http.StatusInternalServerError {
+				sp.SetError(errors.New(http.StatusText(code)))
+			}
+		})
+	}
+}
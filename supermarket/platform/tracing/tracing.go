@@ -0,0 +1,261 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrTraceparentInvalid is returned when a traceparent header is malformed.
+var ErrTraceparentInvalid = errors.New("tracing: invalid traceparent")
+
+// TraceID identifies a trace, as in W3C Trace Context.
+type TraceID [16]byte
+
+// String returns the id hex encoded.
+func (t TraceID) String() string { return hex.EncodeToString(t[:]) }
+
+// SpanID identifies a span within a trace, as in W3C Trace Context.
+type SpanID [8]byte
+
+// String returns the id hex encoded.
+func (s SpanID) String() string { return hex.EncodeToString(s[:]) }
+
+// SpanContext is the part of a span propagated across process boundaries.
+type SpanContext struct {
+	// TraceID is the id of the trace.
+	TraceID TraceID
+	// SpanID is the id of the span.
+	SpanID SpanID
+	// Sampled reports whether the trace is recorded.
+	Sampled bool
+}
+
+// ParseTraceparent parses a W3C traceparent header, e.g. 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01.
+func ParseTraceparent(s string) (sc SpanContext, err error) {
+	// version 00 is 55 characters long, later versions may append fields
+	if len(s) < 55 || s[2] != '-' || s[35] != '-' || s[52] != '-' || (len(s) > 55 && s[55] != '-') {
+		err = ErrTraceparentInvalid
+		return
+	}
+	version, errV := hex.DecodeString(s[0:2])
+	_, errT := hex.Decode(sc.TraceID[:], []byte(s[3:35]))
+	_, errS := hex.Decode(sc.SpanID[:], []byte(s[36:52]))
+	flags, errF := hex.DecodeString(s[53:55])
+	if errV != nil || errT != nil || errS != nil || errF != nil || version[0] == 0xff || (version[0] == 0 && len(s) != 55) {
+		err = ErrTraceparentInvalid
+		return
+	}
+	if sc.TraceID == (TraceID{}) || sc.SpanID == (SpanID{}) {
+		err = ErrTraceparentInvalid
+		return
+	}
+	sc.Sampled = flags[0]&0x01 == 1
+	return
+}
+
+// Traceparent returns the W3C traceparent header of the span context.
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%s-%s", sc.TraceID, sc.SpanID, flags)
+}
+
+// SpanData is a finished span, as handed to the exporter.
+type SpanData struct {
+	// Name is the name of the operation.
+	Name string `json:"name"`
+	// TraceID is the id of the trace.
+	TraceID string `json:"trace_id"`
+	// SpanID is the id of the span.
+	SpanID string `json:"span_id"`
+	// ParentID is the id of the parent span, empty for a root span.
+	ParentID string `json:"parent_id,omitempty"`
+	// Start is the time the span started.
+	Start time.Time `json:"start"`
+	// End is the time the span ended.
+	End time.Time `json:"end"`
+	// DurationMs is the duration of the span, in milliseconds.
+	DurationMs float64 `json:"duration_ms"`
+	// Attributes describe the operation.
+	Attributes map[string]any `json:"attributes,omitempty"`
+	// Error is the error the operation failed with, empty if it succeeded.
+	Error string `json:"error,omitempty"`
+}
+
+// Exporter receives the finished spans.
+type Exporter interface {
+	// Export exports a finished span. It must be safe for concurrent use.
+	Export(s SpanData) (err error)
+}
+
+// NewTracer returns a tracer that exports the spans it records to exp.
+func NewTracer(exp Exporter) *Tracer {
+	return &Tracer{exp: exp}
+}
+
+// Tracer starts root spans, the spans started from their context share its exporter.
+type Tracer struct {
+	// exp receives the finished spans.
+	exp Exporter
+}
+
+// Start starts a span, child of the span in ctx if any, or of parent if it is valid, or a new trace otherwise.
+func (t *Tracer) Start(ctx context.Context, name string, parent SpanContext) (context.Context, *Span) {
+	if p := SpanFromContext(ctx); p != nil {
+		return Start(ctx, name)
+	}
+	sp := &Span{tracer: t, name: name, start: time.Now()}
+	if parent.TraceID != (TraceID{}) {
+		sp.sc.TraceID = parent.TraceID
+		sp.parent = parent.SpanID
+		sp.sc.Sampled = parent.Sampled
+	} else {
+		_, _ = rand.Read(sp.sc.TraceID[:])
+		sp.sc.Sampled = true
+	}
+	_, _ = rand.Read(sp.sc.SpanID[:])
+	return ContextWithSpan(ctx, sp), sp
+}
+
+// Start starts a child of the span in ctx. Without a span in ctx, e.g. in batch jobs,
+// the returned span records nothing, so that instrumented code does not need to check.
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+	p := SpanFromContext(ctx)
+	if p == nil {
+		return ctx, nil
+	}
+	sp := &Span{tracer: p.tracer, name: name, start: time.Now(), parent: p.sc.SpanID}
+	sp.sc.TraceID = p.sc.TraceID
+	sp.sc.Sampled = p.sc.Sampled
+	_, _ = rand.Read(sp.sc.SpanID[:])
+	return ContextWithSpan(ctx, sp), sp
+}
+
+// Span is an operation of a trace. A nil span is valid and records nothing.
+type Span struct {
+	// tracer is the tracer the span is exported with.
+	tracer *Tracer
+	// name is the name of the operation.
+	name string
+	// sc is the context of the span.
+	sc SpanContext
+	// parent is the id of the parent span, zero for a root span.
+	parent SpanID
+	// start is the time the span started.
+	start time.Time
+	// mu guards the fields below.
+	mu sync.Mutex
+	// attrs describe the operation.
+	attrs map[string]any
+	// err is the error the operation failed with.
+	err error
+	// ended reports whether End was called.
+	ended bool
+}
+
+// Context returns the context of the span, to be propagated.
+func (s *Span) Context() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.sc
+}
+
+// SetName renames the span, e.g. once the route of a request is known.
+func (s *Span) SetName(name string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.name = name
+}
+
+// SetAttribute sets an attribute describing the operation.
+func (s *Span) SetAttribute(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.attrs == nil {
+		s.attrs = make(map[string]any)
+	}
+	s.attrs[key] = value
+}
+
+// SetError records the error the operation failed with, nil is ignored.
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+// End ends the span and exports it if the trace is sampled. Only the first call has effect.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	end := time.Now()
+
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	d := SpanData{
+		Name:       s.name,
+		TraceID:    s.sc.TraceID.String(),
+		SpanID:     s.sc.SpanID.String(),
+		Start:      s.start,
+		End:        end,
+		DurationMs: float64(end.Sub(s.start).Microseconds()) / 1000,
+		Attributes: s.attrs,
+	}
+	if s.parent != (SpanID{}) {
+		d.ParentID = s.parent.String()
+	}
+	if s.err != nil {
+		d.Error = s.err.Error()
+	}
+	s.mu.Unlock()
+
+	if !s.sc.Sampled || s.tracer == nil || s.tracer.exp == nil {
+		return
+	}
+	_ = s.tracer.exp.Export(d)
+}
+
+// EndError records *err, if any, and ends the span.
+// It is meant to be deferred by functions with a named error result.
+func (s *Span) EndError(err *error) {
+	if err != nil {
+		s.SetError(*err)
+	}
+	s.End()
+}
+
+// spanKey is the context key of the current span.
+type spanKey struct{}
+
+// ContextWithSpan returns a copy of ctx carrying the span as the current span.
+func ContextWithSpan(ctx context.Context, s *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, s)
+}
+
+// SpanFromContext returns the current span of ctx, nil if there is none.
+func SpanFromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
@@ -0,0 +1,37 @@
+package request
+
+import "encoding/json"
+
+// Field is a field of a request body that tells a missing field from one set to its zero value or null,
+// e.g. for the fields whose zero value is valid. Its validate rules apply to its value, required to be present.
+type Field[T any] struct {
+	// Value is the value of the field, the zero value if it is missing or null.
+	Value T
+	// Set is true if the field is present in the body, even if null.
+	Set bool
+	// Null is true if the field is present in the body as null.
+	Null bool
+}
+
+// UnmarshalJSON decodes the field, recording that it is present.
+func (f *Field[T]) UnmarshalJSON(b []byte) error {
+	f.Set = true
+	if string(b) == "null" {
+		f.Null = true
+		return nil
+	}
+	return json.Unmarshal(b, &f.Value)
+}
+
+// MarshalJSON encodes the value of the field, or null if it is missing or null.
+func (f Field[T]) MarshalJSON() ([]byte, error) {
+	if !f.Set || f.Null {
+		return []byte("null"), nil
+	}
+	return json.Marshal(f.Value)
+}
+
+// Optional returns the value of the field and true if it is set and not null, see validate.Optional.
+func (f Field[T]) Optional() (v any, present bool) {
+	return f.Value, f.Set && !f.Null
+}
@@ -4,31 +4,184 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"strings"
+	"supermarket/platform/web/validate"
 )
 
-// JSON decodes json from request body to ptr
 var (
 	// ErrRequestContentTypeNotJSON is used when the request content type is not application/json.
 	ErrRequestContentTypeNotJSON = errors.New("request content type is not application/json")
 	// ErrRequestJSONInvalid is used when the request json is invalid.
 	ErrRequestJSONInvalid = errors.New("request json invalid")
+	// ErrRequestBodyTooLarge is used when the request body is larger than the maximum size.
+	ErrRequestBodyTooLarge = errors.New("request body too large")
 )
 
-// JSON decodes json from request body to ptr
+// DefaultMaxBytes is the maximum size of a request body unless configured otherwise.
+const DefaultMaxBytes = 1 << 20
+
+// Config is the configuration of a Decoder.
+// The zero value decodes strictly: unknown fields are rejected and the body is limited to DefaultMaxBytes.
+type Config struct {
+	// MaxBytes is the maximum size of the body, DefaultMaxBytes if zero and unlimited if negative.
+	MaxBytes int64
+	// AllowUnknownFields accepts fields the destination does not have, ignoring them.
+	AllowUnknownFields bool
+}
+
+// NewDecoder creates a new Decoder.
+func NewDecoder(cfg Config) *Decoder {
+	if cfg.MaxBytes == 0 {
+		cfg.MaxBytes = DefaultMaxBytes
+	}
+	return &Decoder{cfg: cfg}
+}
+
+// Decoder decodes the json bodies of the requests.
+type Decoder struct {
+	// cfg is the configuration of the decoder.
+	cfg Config
+}
+
+// defaultDecoder is the decoder of JSON.
+var defaultDecoder = NewDecoder(Config{})
+
+// JSON decodes json from request body to ptr, with the default configuration
 func JSON(r *http.Request, ptr any) (err error) {
+	return defaultDecoder.JSON(r, ptr)
+}
+
+// JSON decodes json from request body to ptr, then validates it against its validate struct tags.
+// The body must be a single json value, sent as application/json or a +json media type in utf-8.
+// Errors wrap ErrRequestContentTypeNotJSON, ErrRequestBodyTooLarge or ErrRequestJSONInvalid,
+// the latter as a *DecodeError locating the problem, or are validate.Errors listing the fields failing their rules.
+func (d *Decoder) JSON(r *http.Request, ptr any) (err error) {
 	// check content type
-	if r.Header.Get("Content-Type") != "application/json" {
+	if !isJSON(r.Header.Get("Content-Type")) {
 		err = ErrRequestContentTypeNotJSON
 		return
 	}
 
+	// limit body, MaxBytesReader only uses the response writer to close the connection, which is up to the server
+	body := io.Reader(r.Body)
+	if d.cfg.MaxBytes > 0 {
+		body = http.MaxBytesReader(nil, r.Body, d.cfg.MaxBytes)
+	}
+
 	// get body
-	err = json.NewDecoder(r.Body).Decode(ptr)
+	cr := &countingReader{r: body}
+	dec := json.NewDecoder(cr)
+	if !d.cfg.AllowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	err = dec.Decode(ptr)
 	if err != nil {
-		err = fmt.Errorf("%w. %v", ErrRequestJSONInvalid, err)
+		err = decodeError(err, dec.InputOffset(), cr.n)
 		return
 	}
 
+	// check there is nothing but whitespace after the value
+	var extra json.RawMessage
+	offset := dec.InputOffset()
+	err = dec.Decode(&extra)
+	switch {
+	case err == io.EOF:
+	case err == nil:
+		err = &DecodeError{Offset: offset, Reason: "body must contain a single json value"}
+		return
+	default:
+		err = decodeError(err, offset, cr.n)
+		return
+	}
+
+	// validate
+	err = validate.Struct(ptr)
 	return
-}
\ No newline at end of file
+}
+
+// countingReader counts the bytes read, to locate the end of a truncated body.
+type countingReader struct {
+	// r is the reader.
+	r io.Reader
+	// n is the number of bytes read.
+	n int64
+}
+
+// Read reads from r.
+func (c *countingReader) Read(p []byte) (n int, err error) {
+	n, err = c.r.Read(p)
+	c.n += int64(n)
+	return
+}
+
+// isJSON returns true if contentType is application/json or a +json media type, in utf-8.
+func isJSON(contentType string) bool {
+	mt, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	if mt != "application/json" && !(strings.HasPrefix(mt, "application/") && strings.HasSuffix(mt, "+json")) {
+		return false
+	}
+	if cs, ok := params["charset"]; ok && !strings.EqualFold(cs, "utf-8") {
+		return false
+	}
+	return true
+}
+
+// DecodeError is the error of a request body that is not valid json for its destination.
+type DecodeError struct {
+	// Field is the path of the offending field, e.g. lines.0.price, empty if the problem is not in a field.
+	Field string
+	// Offset is the byte offset in the body the problem was found at.
+	Offset int64
+	// Reason describes the problem.
+	Reason string
+}
+
+// Error returns the message of the error.
+func (e *DecodeError) Error() string {
+	msg := ErrRequestJSONInvalid.Error()
+	if e.Field != "" {
+		msg += fmt.Sprintf(": field %q", e.Field)
+	}
+	return msg + fmt.Sprintf(": %s at offset %d", e.Reason, e.Offset)
+}
+
+// Unwrap returns ErrRequestJSONInvalid.
+func (e *DecodeError) Unwrap() error {
+	return ErrRequestJSONInvalid
+}
+
+// decodeError translates an error of encoding/json found at offset, in a body of size bytes read so far.
+func decodeError(err error, offset, size int64) error {
+	var (
+		syntaxErr    *json.SyntaxError
+		typeErr      *json.UnmarshalTypeError
+		maxBytesErr  *http.MaxBytesError
+		unmarshalErr *json.InvalidUnmarshalError
+	)
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return fmt.Errorf("%w: limit is %d bytes", ErrRequestBodyTooLarge, maxBytesErr.Limit)
+	case errors.As(err, &unmarshalErr):
+		// a programming error, not a problem of the request
+		return err
+	case errors.As(err, &syntaxErr):
+		return &DecodeError{Offset: syntaxErr.Offset, Reason: strings.TrimPrefix(syntaxErr.Error(), "json: ")}
+	case errors.As(err, &typeErr):
+		return &DecodeError{Field: typeErr.Field, Offset: typeErr.Offset, Reason: fmt.Sprintf("cannot be a %s", typeErr.Value)}
+	case errors.Is(err, io.EOF):
+		return &DecodeError{Offset: size, Reason: "body is empty"}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return &DecodeError{Offset: size, Reason: "body ends unexpectedly"}
+	}
+	// unknown fields are only reported by the message of the error
+	if name, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		return &DecodeError{Field: strings.Trim(name, `"`), Offset: offset, Reason: "unknown field"}
+	}
+	return &DecodeError{Offset: offset, Reason: strings.TrimPrefix(err.Error(), "json: ")}
+}
@@ -0,0 +1,175 @@
+package response
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/csv"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// CSV encodes rows, a slice of structs or of pointers to them, as csv with a header row.
+// The columns are the fields of the struct named after their json tags, in the order of the fields:
+// embedded structs are flattened, nested structs are flattened with the name of their field as a prefix,
+// e.g. customer.first_name, and slices and maps, which do not fit in a row, are left out.
+// The header row is written even if there are no rows. Rows that are not such a slice panic, as it is a programming error.
+func CSV(rows any) ([]byte, error) {
+	rv := reflect.ValueOf(rows)
+	if rv.Kind() != reflect.Slice || elemType(rv.Type().Elem()).Kind() != reflect.Struct {
+		panic(fmt.Sprintf("response: csv rows must be a slice of structs, got %T", rows))
+	}
+	cols := columns(elemType(rv.Type().Elem()), "", nil)
+
+	var b bytes.Buffer
+	cw := csv.NewWriter(&b)
+
+	// header
+	record := make([]string, len(cols))
+	for i, c := range cols {
+		record[i] = c.name
+	}
+	cw.Write(record)
+
+	// rows
+	for i := 0; i < rv.Len(); i++ {
+		row := rv.Index(i)
+		for j, c := range cols {
+			record[j] = ""
+			if v, ok := fieldByIndex(row, c.index); ok {
+				record[j] = formatScalar(v)
+			}
+		}
+		cw.Write(record)
+	}
+
+	cw.Flush()
+	return b.Bytes(), cw.Error()
+}
+
+// column is a column of a csv.
+type column struct {
+	// name is the name of the column, the path of the field from its json names.
+	name string
+	// index is the index sequence of the field for reflect.Value.FieldByIndex.
+	index []int
+}
+
+// columns returns the columns of the scalar fields of the struct t, prefixing their names with prefix.
+func columns(t reflect.Type, prefix string, index []int) (cols []column) {
+	for _, f := range jsonFields(t) {
+		idx := append(append([]int{}, index...), f.index...)
+		ft := elemType(f.typ)
+		switch {
+		case ft.Kind() == reflect.Struct && !isScalar(ft):
+			cols = append(cols, columns(ft, prefix+f.name+".", idx)...)
+		case ft.Kind() == reflect.Slice || ft.Kind() == reflect.Array || ft.Kind() == reflect.Map:
+		default:
+			cols = append(cols, column{name: prefix + f.name, index: idx})
+		}
+	}
+	return
+}
+
+// jsonField is a field of a struct as encoding/json encodes it.
+type jsonField struct {
+	// name is the json name of the field.
+	name string
+	// index is the index sequence of the field, through the embedded structs.
+	index []int
+	// typ is the type of the field.
+	typ reflect.Type
+	// omitEmpty is true if the field is left out when empty.
+	omitEmpty bool
+}
+
+// jsonFields returns the fields of the struct t encoding/json encodes, flattening the embedded structs without a json name.
+func jsonFields(t reflect.Type) (fields []jsonField) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" && elemType(f.Type).Kind() == reflect.Struct {
+			for _, ef := range jsonFields(elemType(f.Type)) {
+				ef.index = append([]int{i}, ef.index...)
+				fields = append(fields, ef)
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, jsonField{name: name, index: []int{i}, typ: f.Type, omitEmpty: strings.Contains(opts, "omitempty")})
+	}
+	return
+}
+
+// fieldByIndex returns the field of the struct v at index, following pointers, or false if one of them is nil.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for _, i := range index {
+		for v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}, false
+		}
+		v = v.Elem()
+	}
+	return v, true
+}
+
+// elemType returns t without its pointers.
+func elemType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// textMarshalerType is the type of encoding.TextMarshaler.
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// isScalar returns true if the values of t are written as text, i.e. they are not structs, or marshal themselves as text.
+func isScalar(t reflect.Type) bool {
+	return t.Kind() != reflect.Struct || t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType)
+}
+
+// formatScalar formats v as text, floats without exponent.
+func formatScalar(v reflect.Value) string {
+	if v.CanInterface() {
+		if tm, ok := v.Interface().(encoding.TextMarshaler); ok {
+			b, err := tm.MarshalText()
+			if err == nil {
+				return string(b)
+			}
+		}
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 32)
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,85 @@
+package response
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ETag returns the strong entity tag of body, a quoted prefix of its SHA-256.
+func ETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// JSONConditional writes json response like JSON, tagged with a strong ETag and, unless lastModified is zero,
+// a Last-Modified header. If the preconditions of a GET or HEAD request show the client already has this
+// representation, it responds 304 Not Modified without body instead.
+func JSONConditional(w http.ResponseWriter, r *http.Request, code int, body any, lastModified time.Time) {
+	// marshal body
+	bytes, err := json.Marshal(body)
+	if err != nil {
+		// default error
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeConditional(w, r, code, "application/json", bytes, lastModified)
+}
+
+// writeConditional writes a response of contentType, conditionally, see JSONConditional.
+func writeConditional(w http.ResponseWriter, r *http.Request, code int, contentType string, bytes []byte, lastModified time.Time) {
+	// set validators
+	etag := ETag(bytes)
+	w.Header().Set("ETag", etag)
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	// not modified
+	if code == http.StatusOK && NotModified(r, etag, lastModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	// set header
+	w.Header().Set("Content-Type", contentType)
+
+	// set status code
+	w.WriteHeader(code)
+
+	// write body
+	w.Write(bytes)
+}
+
+// NotModified reports whether a GET or HEAD request already has the representation tagged etag and last modified at lastModified.
+// As in RFC 9110, If-None-Match takes precedence and If-Modified-Since is only evaluated without it, at a second precision.
+func NotModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	// If-None-Match, compared weakly as the spec requires for this header
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	// If-Modified-Since
+	if lastModified.IsZero() {
+		return false
+	}
+	ims, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	return !lastModified.Truncate(time.Second).After(ims)
+}
@@ -0,0 +1,73 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// ContentTypeNDJSON is the media type of newline delimited json
+const ContentTypeNDJSON = "application/x-ndjson"
+
+// NewNDJSON returns a new NDJSON writer that responds with code
+func NewNDJSON(w http.ResponseWriter, code int) *NDJSON {
+	return &NDJSON{w: w, rc: http.NewResponseController(w), code: code, enc: json.NewEncoder(w)}
+}
+
+// NDJSON writes a newline delimited json response, one value per line.
+// Every line is flushed so that the client receives it as soon as it is written.
+// The header is written with the first line, so that an error before it can still be responded with a different status code.
+type NDJSON struct {
+	// w is the response writer
+	w http.ResponseWriter
+	// rc is the response controller of w, used to flush
+	rc *http.ResponseController
+	// code is the status code of the response
+	code int
+	// enc is the json encoder of w, it ends every value with a newline
+	enc *json.Encoder
+	// started is true once the header is written
+	started bool
+}
+
+// Started returns true if the header was already written
+func (n *NDJSON) Started() bool {
+	return n.started
+}
+
+// Write writes v as a line and flushes it
+func (n *NDJSON) Write(v any) (err error) {
+	n.start()
+
+	// write line
+	err = n.enc.Encode(v)
+	if err != nil {
+		return
+	}
+
+	// flush, not every response writer supports it
+	_ = n.rc.Flush()
+	return
+}
+
+// Close writes the header if no line was written, so that an empty stream is still a valid response
+func (n *NDJSON) Close() {
+	n.start()
+}
+
+// start writes the header once
+func (n *NDJSON) start() {
+	if n.started {
+		return
+	}
+	n.started = true
+
+	// a stream may outlast the write timeout of the server, not every response writer supports clearing it
+	_ = n.rc.SetWriteDeadline(time.Time{})
+
+	// set header
+	n.w.Header().Set("Content-Type", ContentTypeNDJSON)
+
+	// set status code
+	n.w.WriteHeader(n.code)
+}
@@ -0,0 +1,222 @@
+package response_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"supermarket/platform/web/response"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for NegotiateFormat function
+func TestNegotiateFormat(t *testing.T) {
+	cases := []struct {
+		name     string
+		query    string
+		accept   string
+		expected response.Format
+		err      error
+	}{
+		{name: "json without accept", expected: response.FormatJSON},
+		{name: "csv", accept: "text/csv", expected: response.FormatCSV},
+		{name: "xml", accept: "application/xml", expected: response.FormatXML},
+		{name: "text xml", accept: "text/xml", expected: response.FormatXML},
+		{name: "json for a wildcard", accept: "*/*", expected: response.FormatJSON},
+		{name: "csv for a text wildcard", accept: "text/*", expected: response.FormatCSV},
+		{name: "the highest quality", accept: "application/json;q=0.5, text/csv;q=0.9, */*;q=0.1", expected: response.FormatCSV},
+		{name: "the earlier of the same quality", accept: "application/xml, application/json", expected: response.FormatXML},
+		{name: "skips what it cannot respond with", accept: "text/html, application/xml;q=0.8", expected: response.FormatXML},
+		{name: "skips quality 0", accept: "text/csv;q=0, */*", expected: response.FormatJSON},
+		{name: "query param over accept", query: "format=CSV", accept: "application/xml", expected: response.FormatCSV},
+		{name: "unknown query param", query: "format=xlsx", err: response.ErrFormatUnknown},
+		{name: "not acceptable", accept: "text/html", err: response.ErrFormatNotAcceptable},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// arrange
+			req := httptest.NewRequest(http.MethodGet, "/?"+c.query, nil)
+			if c.accept != "" {
+				req.Header.Set("Accept", c.accept)
+			}
+
+			// act
+			f, err := response.NegotiateFormat(req)
+
+			// assert
+			require.ErrorIs(t, err, c.err)
+			require.Equal(t, c.expected, f)
+		})
+	}
+}
+
+// invoiceRow is a row of a table in the tests.
+type invoiceRow struct {
+	Id       int          `json:"id"`
+	Total    float64      `json:"total"`
+	Customer *customerRow `json:"customer,omitempty"`
+	Lines    []int        `json:"lines,omitempty"`
+	Note     string       `json:"-"`
+}
+
+// customerRow is a nested struct of a row in the tests.
+type customerRow struct {
+	FirstName string `json:"first_name"`
+}
+
+// balanceRow is a row embedding another in the tests.
+type balanceRow struct {
+	invoiceRow
+	Balance float64 `json:"balance"`
+}
+
+// Tests for CSV function
+func TestCSV(t *testing.T) {
+	t.Run("header row from the json tags", func(t *testing.T) {
+		// arrange
+		rows := []invoiceRow{
+			{Id: 1, Total: 1250.5, Customer: &customerRow{FirstName: "Smith, Jo"}, Lines: []int{1}},
+			{Id: 2, Total: 0.0000001},
+		}
+
+		// act
+		b, err := response.CSV(rows)
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, "id,total,customer.first_name\n1,1250.5,\"Smith, Jo\"\n2,0.0000001,\n", string(b))
+	})
+
+	t.Run("embedded structs are flattened", func(t *testing.T) {
+		// act
+		b, err := response.CSV([]*balanceRow{{invoiceRow: invoiceRow{Id: 1, Total: 10}, Balance: 10}})
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, "id,total,customer.first_name,balance\n1,10,,10\n", string(b))
+	})
+
+	t.Run("header row without rows", func(t *testing.T) {
+		// act
+		b, err := response.CSV([]invoiceRow{})
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, "id,total,customer.first_name\n", string(b))
+	})
+
+	t.Run("panics on rows that are not structs", func(t *testing.T) {
+		require.Panics(t, func() { response.CSV([]int{1}) })
+	})
+}
+
+// Tests for XML function
+func TestXML(t *testing.T) {
+	// arrange
+	body := map[string]any{
+		"message": "invoices found",
+		"data":    []invoiceRow{{Id: 1, Total: 1.5, Customer: &customerRow{FirstName: "Jo & Al"}}, {Id: 2}},
+		"page":    nil,
+	}
+
+	// act
+	b, err := response.XML(body)
+
+	// assert
+	require.NoError(t, err)
+	expected := `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<response>` +
+		`<data><item><id>1</id><total>1.5</total><customer><first_name>Jo &amp; Al</first_name></customer></item><item><id>2</id><total>0</total></item></data>` +
+		`<message>invoices found</message><page></page></response>`
+	require.Equal(t, expected, string(b))
+}
+
+// Tests for Negotiated function
+func TestNegotiated(t *testing.T) {
+	table := response.Table{
+		Body:     map[string]any{"message": "invoices found", "data": []invoiceRow{{Id: 1, Total: 2}}},
+		Rows:     []invoiceRow{{Id: 1, Total: 2}},
+		Filename: "invoices",
+	}
+
+	t.Run("200 - json", func(t *testing.T) {
+		// arrange
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+		// act
+		rr := httptest.NewRecorder()
+		response.Negotiated(rr, req, http.StatusOK, response.FormatJSON, table)
+
+		// assert
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+		require.Equal(t, "Accept", rr.Header().Get("Vary"))
+		require.Empty(t, rr.Header().Get("Content-Disposition"))
+		require.JSONEq(t, `{"message":"invoices found","data":[{"id":1,"total":2}]}`, rr.Body.String())
+	})
+
+	t.Run("200 - csv download", func(t *testing.T) {
+		// arrange
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+		// act
+		rr := httptest.NewRecorder()
+		response.Negotiated(rr, req, http.StatusOK, response.FormatCSV, table)
+
+		// assert
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Equal(t, response.ContentTypeCSV, rr.Header().Get("Content-Type"))
+		require.Equal(t, `attachment; filename=invoices.csv`, rr.Header().Get("Content-Disposition"))
+		require.Equal(t, "id,total,customer.first_name\n1,2,\n", rr.Body.String())
+	})
+
+	t.Run("200 - xml download", func(t *testing.T) {
+		// arrange
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+		// act
+		rr := httptest.NewRecorder()
+		response.Negotiated(rr, req, http.StatusOK, response.FormatXML, table)
+
+		// assert
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Equal(t, response.ContentTypeXML, rr.Header().Get("Content-Type"))
+		require.Equal(t, `attachment; filename=invoices.xml`, rr.Header().Get("Content-Disposition"))
+		require.Contains(t, rr.Body.String(), `<data><item><id>1</id><total>2</total></item></data>`)
+	})
+
+	t.Run("304 - tagged per format", func(t *testing.T) {
+		// arrange
+		rr := httptest.NewRecorder()
+		response.Negotiated(rr, httptest.NewRequest(http.MethodGet, "/", nil), http.StatusOK, response.FormatCSV, table)
+		etag := rr.Header().Get("ETag")
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("If-None-Match", etag)
+
+		// act
+		rrCSV := httptest.NewRecorder()
+		response.Negotiated(rrCSV, req, http.StatusOK, response.FormatCSV, table)
+		rrJSON := httptest.NewRecorder()
+		response.Negotiated(rrJSON, req, http.StatusOK, response.FormatJSON, table)
+
+		// assert
+		require.Equal(t, http.StatusNotModified, rrCSV.Code)
+		require.Equal(t, http.StatusOK, rrJSON.Code)
+		require.NotEqual(t, etag, rrJSON.Header().Get("ETag"))
+	})
+
+	t.Run("200 - last modified", func(t *testing.T) {
+		// arrange
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		table := table
+		table.LastModified = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+		// act
+		rr := httptest.NewRecorder()
+		response.Negotiated(rr, req, http.StatusOK, response.FormatXML, table)
+
+		// assert
+		require.Equal(t, "Tue, 02 Jan 2024 03:04:05 GMT", rr.Header().Get("Last-Modified"))
+	})
+}
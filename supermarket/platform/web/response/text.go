@@ -12,4 +12,4 @@ func Text(w http.ResponseWriter, code int, body string) {
 
 	// write body
 	w.Write([]byte(body))
-}
\ No newline at end of file
+}
@@ -0,0 +1,251 @@
+// Package validate validates the request bodies against the rules of their validate struct tags.
+//
+// The rules of a field are separated by commas:
+//   - required: the value is not the zero value
+//   - min=n, max=n: a number is at least or at most n, a string or a slice has at least or at most n elements
+//   - len=n: a string or a slice has exactly n elements
+//   - oneof=a b c: the value, formatted, is one of the given ones
+//   - date: a string is a date in the YYYY-MM-DD format
+//   - datetime: a string is a date and time in the YYYY-MM-DD hh:mm:ss format
+//
+// A zero value passes every rule but required, so that optional fields can be omitted.
+// An Optional field, e.g. request.Field, is required to be present rather than not zero, and its other rules apply to its value.
+// Lengths are counted in runes. Nested structs, and structs in slices, are validated too.
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"supermarket/platform/web/response"
+	"time"
+	"unicode/utf8"
+)
+
+// Optional is a field that tells a missing value from its zero value, e.g. request.Field.
+type Optional interface {
+	// Optional returns the value of the field and true if it is present, neither missing nor null.
+	Optional() (v any, present bool)
+}
+
+// optionalType is the type of Optional.
+var optionalType = reflect.TypeOf((*Optional)(nil)).Elem()
+
+// ErrValidation is matched by the errors of Struct.
+var ErrValidation = errors.New("validation failed")
+
+// FieldError is a field failing one of its rules.
+type FieldError struct {
+	// Field is the path of the field from its json names, e.g. lines.0.price.
+	Field string
+	// Rule is the rule the field fails, e.g. min.
+	Rule string
+	// Message describes the failure.
+	Message string
+}
+
+// Errors are the fields failing their rules, in the order of the fields.
+type Errors []FieldError
+
+// Error returns the message of the error.
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, v := range e {
+		msgs[i] = v.Field + " " + v.Message
+	}
+	return ErrValidation.Error() + ": " + strings.Join(msgs, "; ")
+}
+
+// Is returns true for ErrValidation.
+func (e Errors) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// InvalidParams returns the fields as the invalid params of an error response.
+func (e Errors) InvalidParams() []response.InvalidParam {
+	ps := make([]response.InvalidParam, len(e))
+	for i, v := range e {
+		ps[i] = response.InvalidParam{Name: v.Field, Reason: v.Message}
+	}
+	return ps
+}
+
+// Struct validates the struct v, or the struct v points to, returning Errors if any field fails its rules.
+// A malformed tag panics, as it is a programming error.
+func Struct(v any) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var errs Errors
+	validateStruct(rv, "", &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validateStruct appends the errors of the fields of the struct rv, whose path is prefix, to errs.
+func validateStruct(rv reflect.Value, prefix string, errs *Errors) {
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+		fv := rv.Field(i)
+
+		// rules of the field
+		if tag := f.Tag.Get("validate"); tag != "" {
+			if msg, rule := validateField(fv, tag); rule != "" {
+				*errs = append(*errs, FieldError{Field: path, Rule: rule, Message: msg})
+				continue
+			}
+		}
+
+		// nested structs
+		validateNested(fv, path, errs)
+	}
+}
+
+// validateNested validates the structs in fv, whose path is path.
+func validateNested(fv reflect.Value, path string, errs *Errors) {
+	for fv.Kind() == reflect.Pointer {
+		if fv.IsNil() {
+			return
+		}
+		fv = fv.Elem()
+	}
+	switch fv.Kind() {
+	case reflect.Struct:
+		if fv.Type() != timeType {
+			validateStruct(fv, path, errs)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < fv.Len(); i++ {
+			validateNested(fv.Index(i), path+"."+strconv.Itoa(i), errs)
+		}
+	}
+}
+
+// timeType is the type of time.Time, whose fields are not validated.
+var timeType = reflect.TypeOf(time.Time{})
+
+// validateField returns the message and the rule of the first rule of tag fv fails, or an empty rule if it passes them all.
+func validateField(fv reflect.Value, tag string) (msg, rule string) {
+	zero := fv.IsZero()
+	if fv.Type().Implements(optionalType) {
+		v, present := fv.Interface().(Optional).Optional()
+		fv, zero = reflect.ValueOf(v), !present
+	}
+	for _, r := range strings.Split(tag, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(r), "=")
+		if name == "required" {
+			if zero {
+				return "is required", name
+			}
+			continue
+		}
+		if zero {
+			continue
+		}
+
+		for fv.Kind() == reflect.Pointer {
+			fv = fv.Elem()
+		}
+		switch name {
+		case "min", "max", "len":
+			n, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				panic(fmt.Sprintf("validate: invalid %s=%s", name, arg))
+			}
+			if msg = checkSize(fv, name, n); msg != "" {
+				return msg, name
+			}
+		case "oneof":
+			options := strings.Fields(arg)
+			value := fmt.Sprint(fv.Interface())
+			found := false
+			for _, o := range options {
+				found = found || o == value
+			}
+			if !found {
+				return "must be one of " + strings.Join(options, ", "), name
+			}
+		case "date", "datetime":
+			layout, want := time.DateOnly, "YYYY-MM-DD"
+			if name == "datetime" {
+				layout, want = time.DateTime, "YYYY-MM-DD hh:mm:ss"
+			}
+			if _, err := time.Parse(layout, fv.String()); fv.Kind() != reflect.String || err != nil {
+				return "must be a date in the " + want + " format", name
+			}
+		default:
+			panic(fmt.Sprintf("validate: unknown rule %s", name))
+		}
+	}
+	return "", ""
+}
+
+// checkSize returns the message of fv failing the min, max or len rule with n, empty if it passes it.
+func checkSize(fv reflect.Value, rule string, n float64) string {
+	var (
+		v    float64
+		unit string
+	)
+	switch fv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v = float64(fv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v = float64(fv.Uint())
+	case reflect.Float32, reflect.Float64:
+		v = fv.Float()
+	case reflect.String:
+		v, unit = float64(utf8.RuneCountInString(fv.String())), " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		v, unit = float64(fv.Len()), " elements"
+	default:
+		panic(fmt.Sprintf("validate: %s on a %s", rule, fv.Kind()))
+	}
+
+	if rule == "len" && unit == "" {
+		panic(fmt.Sprintf("validate: len on a %s", fv.Kind()))
+	}
+
+	bound := strconv.FormatFloat(n, 'f', -1, 64)
+	switch {
+	case rule == "min" && v < n:
+		if unit != "" {
+			return "must have at least " + bound + unit
+		}
+		return "must be at least " + bound
+	case rule == "max" && v > n:
+		if unit != "" {
+			return "must have at most " + bound + unit
+		}
+		return "must be at most " + bound
+	case rule == "len" && v != n:
+		return "must have exactly " + bound + unit
+	}
+	return ""
+}